@@ -99,6 +99,8 @@ func (s *SavedRuleSet) UnmarshalBinary(data []byte) error {
 
 type Tracker interface {
 	Leave()
+	// UpdateChain replaces the displayed outbound chain, e.g. after falling back to a retry outbound.
+	UpdateChain(chain []string)
 }
 
 type OutboundGroup interface {
@@ -0,0 +1,58 @@
+package adapter
+
+import "context"
+
+type RouteExplainer interface {
+	Explain(ctx context.Context, request RouteExplainRequest) (*RouteExplanation, error)
+	RouteChain(metadata InboundContext) []string
+}
+
+type RouteExplainRequest struct {
+	Inbound          string   `json:"inbound,omitempty"`
+	Network          string   `json:"network,omitempty"`
+	Source           string   `json:"source,omitempty"`
+	SourceMACAddress string   `json:"source_mac_address,omitempty"`
+	SourceHostname   string   `json:"source_hostname,omitempty"`
+	InboundInterface string   `json:"inbound_interface,omitempty"`
+	Destination      string   `json:"destination,omitempty"`
+	Domain           string   `json:"domain,omitempty"`
+	Protocol         string   `json:"protocol,omitempty"`
+	ALPN             []string `json:"alpn,omitempty"`
+	JA3              string   `json:"ja3,omitempty"`
+	JA4              string   `json:"ja4,omitempty"`
+	HTTPMethod       string   `json:"http_method,omitempty"`
+	HTTPPath         string   `json:"http_path,omitempty"`
+	UserAgent        string   `json:"user_agent,omitempty"`
+	ProcessPath      string   `json:"process_path,omitempty"`
+	PackageName      string   `json:"package_name,omitempty"`
+	User             string   `json:"user,omitempty"`
+	WIFISSID         string   `json:"wifi_ssid,omitempty"`
+	WIFIBSSID        string   `json:"wifi_bssid,omitempty"`
+}
+
+type RouteExplanation struct {
+	Steps    []string          `json:"steps,omitempty"`
+	Rules    []RuleExplanation `json:"rules"`
+	Final    bool              `json:"final"`
+	Outbound string            `json:"outbound"`
+	Chain    []string          `json:"chain"`
+	Hops     []string          `json:"hops,omitempty"`
+}
+
+type RuleExplanation struct {
+	Index    int                   `json:"index"`
+	Rule     string                `json:"rule"`
+	Outbound string                `json:"outbound,omitempty"`
+	Hops     []string              `json:"hops,omitempty"`
+	Mode     string                `json:"mode,omitempty"`
+	Invert   bool                  `json:"invert,omitempty"`
+	Matched  bool                  `json:"matched"`
+	Items    []RuleItemExplanation `json:"items,omitempty"`
+	Rules    []RuleExplanation     `json:"rules,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+type RuleItemExplanation struct {
+	Item    string `json:"item"`
+	Matched bool   `json:"matched"`
+}
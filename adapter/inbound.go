@@ -36,6 +36,15 @@ type InboundContext struct {
 	User        string
 	Outbound    string
 
+	// sniff
+
+	ALPN       []string
+	JA3        string
+	JA4        string
+	HTTPMethod string
+	HTTPPath   string
+	UserAgent  string
+
 	// cache
 
 	InboundDetour        string
@@ -48,6 +57,10 @@ type InboundContext struct {
 	ProcessInfo          *process.Info
 	QueryType            uint16
 	FakeIP               bool
+	SourceHardwareAddr   net.HardwareAddr
+	SourceHostname       string
+	InboundInterface     string
+	WIFIState            *WIFIState
 
 	// rule cache
 
@@ -18,3 +18,10 @@ type Outbound interface {
 	NewConnection(ctx context.Context, conn net.Conn, metadata InboundContext) error
 	NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext) error
 }
+
+// SharedSessionOutbound is implemented by outbounds that may carry a connection over a session opened by another one,
+// such as multiplexed or QUIC based outbounds, so their dialer can not be chosen per connection.
+type SharedSessionOutbound interface {
+	Outbound
+	SharedSession() bool
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/netip"
+	"time"
 
 	"github.com/sagernet/sing-box/common/geoip"
 	dns "github.com/sagernet/sing-dns"
@@ -29,6 +30,7 @@ type Router interface {
 	FakeIPStore() FakeIPStore
 
 	ConnectionRouter
+	RouteExplainer
 
 	GeoIPReader() *geoip.Reader
 	LoadGeosite(code string) (Rule, error)
@@ -39,6 +41,8 @@ type Router interface {
 	Exchange(ctx context.Context, message *mdns.Msg) (*mdns.Msg, error)
 	Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error)
 	LookupDefault(ctx context.Context, domain string) ([]netip.Addr, error)
+	LookupWithOptions(ctx context.Context, domain string, options DNSQueryOptions) ([]netip.Addr, error)
+	DNSTransport(tag string) (dns.Transport, bool)
 	ClearDNSCache()
 
 	InterfaceFinder() control.InterfaceFinder
@@ -51,6 +55,7 @@ type Router interface {
 	InterfaceMonitor() tun.DefaultInterfaceMonitor
 	PackageManager() tun.PackageManager
 	WIFIState() WIFIState
+	TimeFunc() func() time.Time
 	Rules() []Rule
 
 	ClashServer() ClashServer
@@ -72,6 +77,12 @@ func RouterFromContext(ctx context.Context) Router {
 	return service.FromContext[Router](ctx)
 }
 
+type DNSQueryOptions struct {
+	Transport    string
+	Strategy     dns.DomainStrategy
+	DisableCache bool
+}
+
 type HeadlessRule interface {
 	Match(metadata *InboundContext) bool
 }
@@ -82,6 +93,8 @@ type Rule interface {
 	Type() string
 	UpdateGeosite() error
 	Outbound() string
+	Chain() []string
+	Retry() []string
 	String() string
 }
 
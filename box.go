@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/capture"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental"
@@ -112,6 +113,11 @@ func New(options Options) (*Box, error) {
 		}
 		scripts = append(scripts, s)
 	}
+	var captureManager *capture.Manager
+	if needClashAPI {
+		captureManager = capture.NewManager(ctx, logFactory.NewLogger("capture"))
+		service.MustRegisterPtr(ctx, captureManager)
+	}
 	router, err := route.NewRouter(
 		ctx,
 		logFactory,
@@ -125,6 +131,10 @@ func New(options Options) (*Box, error) {
 	if err != nil {
 		return nil, E.Cause(err, "parse route options")
 	}
+	err = inbound.CheckAutoRoute(options.Inbounds)
+	if err != nil {
+		return nil, err
+	}
 	inbounds := make([]adapter.Inbound, 0, len(options.Inbounds))
 	outbounds := make([]adapter.Outbound, 0, len(options.Outbounds))
 	for i, inboundOptions := range options.Inbounds {
@@ -234,6 +244,7 @@ func New(options Options) (*Box, error) {
 			return nil, E.Cause(err, "create clash api server")
 		}
 		router.SetClashServer(clashServer)
+		preServices1["capture"] = captureManager
 		preServices2["clash api"] = clashServer
 	}
 	if needV2RayAPI {
@@ -244,6 +255,14 @@ func New(options Options) (*Box, error) {
 		router.SetV2RayServer(v2rayServer)
 		preServices2["v2ray api"] = v2rayServer
 	}
+	if accessLogOptions := common.PtrValueOrDefault(options.Log).Access; accessLogOptions != nil {
+		accessLogger, err := log.NewAccessLogger(ctx, *accessLogOptions)
+		if err != nil {
+			return nil, E.Cause(err, "create access logger")
+		}
+		router.SetAccessLogger(accessLogger)
+		preServices1["access log"] = accessLogger
+	}
 	return &Box{
 		router:         router,
 		inbounds:       inbounds,
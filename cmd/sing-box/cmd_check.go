@@ -4,7 +4,11 @@ import (
 	"context"
 
 	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/common/jsonschema"
 	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
 
 	"github.com/spf13/cobra"
 )
@@ -26,7 +30,11 @@ func init() {
 }
 
 func check() error {
-	options, err := readConfigAndMerge()
+	optionsList, err := readConfigWithSchema(option.Schema())
+	if err != nil {
+		return err
+	}
+	options, err := mergeConfig(optionsList)
 	if err != nil {
 		return err
 	}
@@ -39,5 +47,18 @@ func check() error {
 		instance.Close()
 	}
 	cancel()
-	return err
+	return configSecrets(optionsList).MaskError(err)
+}
+
+// validateConfig reports schema errors with JSON pointer paths, which are more precise than decode errors.
+func validateConfig(schema *jsonschema.Schema, content []byte) error {
+	value, err := json.UnmarshalExtended[any](content)
+	if err != nil {
+		return err
+	}
+	var errors []error
+	for _, validationError := range jsonschema.Validate(schema, value) {
+		errors = append(errors, validationError)
+	}
+	return E.Errors(errors...)
 }
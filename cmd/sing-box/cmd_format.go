@@ -49,6 +49,7 @@ func format() error {
 		if err != nil {
 			return E.Cause(err, "encode config")
 		}
+		buffer = bytes.NewBuffer(optionsEntry.secrets.Restore(buffer.Bytes()))
 		outputPath, _ := filepath.Abs(optionsEntry.path)
 		if !commandFormatFlagWrite {
 			if len(optionsList) > 1 {
@@ -34,7 +34,11 @@ func init() {
 }
 
 func merge(outputPath string) error {
-	mergedOptions, err := readConfigAndMerge()
+	optionsList, err := readConfig()
+	if err != nil {
+		return err
+	}
+	mergedOptions, err := mergeConfig(optionsList)
 	if err != nil {
 		return err
 	}
@@ -49,6 +53,7 @@ func merge(outputPath string) error {
 	if err != nil {
 		return E.Cause(err, "encode config")
 	}
+	buffer = bytes.NewBuffer(configSecrets(optionsList).Restore(buffer.Bytes()))
 	if existsContent, err := os.ReadFile(outputPath); err != nil {
 		if string(existsContent) == buffer.String() {
 			return nil
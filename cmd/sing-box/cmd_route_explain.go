@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandRouteTestRequest  adapter.RouteExplainRequest
+	commandRouteTestFlagJSON bool
+)
+
+var commandRouteTest = &cobra.Command{
+	Use:   "test [destination]",
+	Short: "Explain which rule matches a connection",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			commandRouteTestRequest.Destination = args[0]
+		}
+		err := routeTest(commandRouteTestRequest)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	flags := commandRouteTest.Flags()
+	flags.StringVarP(&commandRouteTestRequest.Inbound, "inbound", "i", "", "Inbound tag")
+	flags.StringVarP(&commandRouteTestRequest.Network, "network", "n", "tcp", "Network type")
+	flags.StringVar(&commandRouteTestRequest.Source, "source", "", "Source address")
+	flags.StringVar(&commandRouteTestRequest.SourceMACAddress, "source-mac", "", "Source MAC address")
+	flags.StringVar(&commandRouteTestRequest.SourceHostname, "source-hostname", "", "Source hostname")
+	flags.StringVar(&commandRouteTestRequest.InboundInterface, "inbound-interface", "", "Inbound interface")
+	flags.StringVarP(&commandRouteTestRequest.Domain, "domain", "d", "", "Sniffed domain")
+	flags.StringVar(&commandRouteTestRequest.Protocol, "protocol", "", "Sniffed protocol")
+	flags.StringSliceVar(&commandRouteTestRequest.ALPN, "alpn", nil, "Sniffed TLS ALPN")
+	flags.StringVar(&commandRouteTestRequest.JA3, "ja3", "", "Sniffed TLS JA3 hash")
+	flags.StringVar(&commandRouteTestRequest.JA4, "ja4", "", "Sniffed TLS JA4 fingerprint")
+	flags.StringVar(&commandRouteTestRequest.HTTPMethod, "http-method", "", "Sniffed HTTP method")
+	flags.StringVar(&commandRouteTestRequest.HTTPPath, "http-path", "", "Sniffed HTTP path")
+	flags.StringVar(&commandRouteTestRequest.UserAgent, "user-agent", "", "Sniffed HTTP User-Agent")
+	flags.StringVar(&commandRouteTestRequest.ProcessPath, "process", "", "Process path or name")
+	flags.StringVar(&commandRouteTestRequest.PackageName, "package", "", "Android package name")
+	flags.StringVarP(&commandRouteTestRequest.User, "user", "u", "", "Inbound user")
+	flags.StringVar(&commandRouteTestRequest.WIFISSID, "wifi-ssid", "", "WIFI SSID")
+	flags.StringVar(&commandRouteTestRequest.WIFIBSSID, "wifi-bssid", "", "WIFI BSSID")
+	flags.BoolVarP(&commandRouteTestFlagJSON, "json", "j", false, "Print as JSON")
+	commandRoute.AddCommand(commandRouteTest)
+}
+
+func routeTest(request adapter.RouteExplainRequest) error {
+	instance, err := createPreStartedClient()
+	if err != nil {
+		return err
+	}
+	defer instance.Close()
+	explanation, err := instance.Router().Explain(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	if commandRouteTestFlagJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanation)
+	}
+	for _, step := range explanation.Steps {
+		os.Stdout.WriteString(step)
+		os.Stdout.WriteString("\n")
+	}
+	for _, rule := range explanation.Rules {
+		writeRuleExplanation(rule, F.ToString("[", rule.Index, "] "), 0)
+	}
+	if explanation.Final {
+		os.Stdout.WriteString("no rule matched, use final outbound\n")
+	}
+	if len(explanation.Hops) > 0 {
+		os.Stdout.WriteString("chain: ")
+		os.Stdout.WriteString(strings.Join(explanation.Hops, " -> "))
+		os.Stdout.WriteString("\n")
+	}
+	os.Stdout.WriteString("outbound: ")
+	os.Stdout.WriteString(strings.Join(explanation.Chain, " -> "))
+	os.Stdout.WriteString("\n")
+	return nil
+}
+
+func writeRuleExplanation(rule adapter.RuleExplanation, prefix string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	os.Stdout.WriteString(indent + prefix + rule.Rule)
+	if rule.Outbound != "" {
+		os.Stdout.WriteString(" => ")
+		for _, hop := range rule.Hops {
+			os.Stdout.WriteString(hop + " -> ")
+		}
+		os.Stdout.WriteString(rule.Outbound)
+	}
+	if rule.Matched {
+		os.Stdout.WriteString(": matched\n")
+	} else {
+		os.Stdout.WriteString(": not matched\n")
+	}
+	if rule.Error != "" {
+		os.Stdout.WriteString(indent + "  error: " + rule.Error + "\n")
+	}
+	for _, item := range rule.Items {
+		if item.Matched {
+			os.Stdout.WriteString(indent + "  + " + item.Item + "\n")
+		} else {
+			os.Stdout.WriteString(indent + "  - " + item.Item + "\n")
+		}
+	}
+	for _, subRule := range rule.Rules {
+		writeRuleExplanation(subRule, rule.Mode+" ", depth+1)
+	}
+}
@@ -1,6 +1,17 @@
 package main
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sagernet/sing-box/common/convertor"
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+
 	"github.com/spf13/cobra"
 )
 
@@ -12,3 +23,50 @@ var commandRuleSet = &cobra.Command{
 func init() {
 	mainCommand.AddCommand(commandRuleSet)
 }
+
+// readRuleSet reads a rule-set in any supported format,
+// the format is detected by the file extension if empty.
+func readRuleSet(path string, format string, recovery bool) (option.PlainRuleSet, error) {
+	if format == "" {
+		if filepath.Ext(path) == ".srs" {
+			format = C.RuleSetFormatBinary
+		} else {
+			format = C.RuleSetFormatSource
+		}
+	}
+	var (
+		reader io.Reader
+		err    error
+	)
+	if path == "stdin" {
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		defer file.Close()
+		reader = file
+	}
+	switch {
+	case format == C.RuleSetFormatSource:
+		decoder := json.NewDecoder(json.NewCommentFilter(reader))
+		decoder.DisallowUnknownFields()
+		var compat option.PlainRuleSetCompat
+		err = decoder.Decode(&compat)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		return compat.Upgrade(), nil
+	case format == C.RuleSetFormatBinary:
+		return srs.Read(reader, recovery)
+	case convertor.IsConvertibleFormat(format):
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		return convertor.Convert(content, format)
+	default:
+		return option.PlainRuleSet{}, E.New("unknown rule set format: ", format)
+	}
+}
@@ -3,23 +3,30 @@ package main
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/sagernet/sing-box/common/convertor"
 	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 
 	"github.com/spf13/cobra"
 )
 
-var flagRuleSetCompileOutput string
+var (
+	flagRuleSetCompileOutput string
+	flagRuleSetCompileFormat string
+)
 
 const flagRuleSetCompileDefaultOutput = "<file_name>.srs"
 
 var commandRuleSetCompile = &cobra.Command{
 	Use:   "compile [source-path]",
-	Short: "Compile rule-set json to binary",
+	Short: "Compile rule-set json or third-party rule list to binary",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		err := compileRuleSet(args[0])
@@ -32,6 +39,7 @@ var commandRuleSetCompile = &cobra.Command{
 func init() {
 	commandRuleSet.AddCommand(commandRuleSetCompile)
 	commandRuleSetCompile.Flags().StringVarP(&flagRuleSetCompileOutput, "output", "o", flagRuleSetCompileDefaultOutput, "Output file")
+	commandRuleSetCompile.Flags().StringVarP(&flagRuleSetCompileFormat, "format", "f", C.RuleSetFormatSource, "Source format: source, clash, adguard, dnsmasq, hosts")
 }
 
 func compileRuleSet(sourcePath string) error {
@@ -47,18 +55,35 @@ func compileRuleSet(sourcePath string) error {
 			return err
 		}
 	}
-	decoder := json.NewDecoder(json.NewCommentFilter(reader))
-	decoder.DisallowUnknownFields()
-	var plainRuleSet option.PlainRuleSetCompat
-	err = decoder.Decode(&plainRuleSet)
-	if err != nil {
-		return err
+	var ruleSet option.PlainRuleSet
+	switch {
+	case flagRuleSetCompileFormat == C.RuleSetFormatSource:
+		decoder := json.NewDecoder(json.NewCommentFilter(reader))
+		decoder.DisallowUnknownFields()
+		var plainRuleSet option.PlainRuleSetCompat
+		err = decoder.Decode(&plainRuleSet)
+		if err != nil {
+			return err
+		}
+		ruleSet = plainRuleSet.Upgrade()
+	case convertor.IsConvertibleFormat(flagRuleSetCompileFormat):
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		ruleSet, err = convertor.Convert(content, flagRuleSetCompileFormat)
+		if err != nil {
+			return err
+		}
+	default:
+		return E.New("unknown rule set format: ", flagRuleSetCompileFormat)
 	}
-	ruleSet := plainRuleSet.Upgrade()
 	var outputPath string
 	if flagRuleSetCompileOutput == flagRuleSetCompileDefaultOutput {
 		if strings.HasSuffix(sourcePath, ".json") {
 			outputPath = sourcePath[:len(sourcePath)-5] + ".srs"
+		} else if flagRuleSetCompileFormat != C.RuleSetFormatSource && filepath.Ext(sourcePath) != "" {
+			outputPath = strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath)) + ".srs"
 		} else {
 			outputPath = sourcePath + ".srs"
 		}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/rw"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRuleSetDecompileOutput string
+
+const flagRuleSetDecompileDefaultOutput = "<file_name>.json"
+
+var commandRuleSetDecompile = &cobra.Command{
+	Use:   "decompile [binary-path]",
+	Short: "Decompile rule-set binary to json",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := decompileRuleSet(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetDecompile)
+	commandRuleSetDecompile.Flags().StringVarP(&flagRuleSetDecompileOutput, "output", "o", flagRuleSetDecompileDefaultOutput, "Output file")
+}
+
+func decompileRuleSet(sourcePath string) error {
+	ruleSet, err := readRuleSet(sourcePath, C.RuleSetFormatBinary, true)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(option.PlainRuleSetCompat{
+		Version: int(srs.MinimalVersion(ruleSet)),
+		Options: ruleSet,
+	})
+	if err != nil {
+		return E.Cause(err, "encode rule-set")
+	}
+	var outputPath string
+	if flagRuleSetDecompileOutput == flagRuleSetDecompileDefaultOutput {
+		if sourcePath == "stdin" {
+			os.Stdout.Write(buffer.Bytes())
+			return nil
+		}
+		outputPath = strings.TrimSuffix(sourcePath, ".srs") + ".json"
+	} else {
+		outputPath = flagRuleSetDecompileOutput
+	}
+	return rw.WriteFile(outputPath, buffer.Bytes())
+}
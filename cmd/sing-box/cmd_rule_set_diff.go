@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRuleSetDiffFormat string
+
+var commandRuleSetDiff = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare items of two rule-sets",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := ruleSetDiff(args[0], args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSetDiff.Flags().StringVarP(&flagRuleSetDiffFormat, "format", "f", "", "Rule-set format, detected by the file extension if empty")
+	commandRuleSet.AddCommand(commandRuleSetDiff)
+}
+
+func ruleSetDiff(pathA string, pathB string) error {
+	ruleSetA, err := readRuleSet(pathA, flagRuleSetDiffFormat, true)
+	if err != nil {
+		return err
+	}
+	ruleSetB, err := readRuleSet(pathB, flagRuleSetDiffFormat, true)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(ruleSetA.Rules) || i < len(ruleSetB.Rules); i++ {
+		prefix := F.ToString("rules.[", i, "]")
+		switch {
+		case i >= len(ruleSetB.Rules):
+			writeRuleDiff("-", prefix, ruleSetA.Rules[i])
+		case i >= len(ruleSetA.Rules):
+			writeRuleDiff("+", prefix, ruleSetB.Rules[i])
+		case ruleSetA.Rules[i].Type == C.RuleTypeDefault && ruleSetB.Rules[i].Type == C.RuleTypeDefault:
+			diffDefaultRule(prefix, ruleSetA.Rules[i].DefaultOptions, ruleSetB.Rules[i].DefaultOptions)
+		default:
+			contentA, _ := json.Marshal(ruleSetA.Rules[i])
+			contentB, _ := json.Marshal(ruleSetB.Rules[i])
+			if string(contentA) != string(contentB) {
+				writeRuleDiff("-", prefix, ruleSetA.Rules[i])
+				writeRuleDiff("+", prefix, ruleSetB.Rules[i])
+			}
+		}
+	}
+	return nil
+}
+
+func writeRuleDiff(operation string, prefix string, rule option.HeadlessRule) {
+	content, _ := json.Marshal(rule)
+	os.Stdout.WriteString(operation + " " + prefix + ": " + string(content) + "\n")
+}
+
+// diffDefaultRule compares each item of two default rules as unordered sets.
+func diffDefaultRule(prefix string, ruleA option.DefaultHeadlessRule, ruleB option.DefaultHeadlessRule) {
+	valueA := reflect.ValueOf(ruleA)
+	valueB := reflect.ValueOf(ruleB)
+	ruleType := valueA.Type()
+	for i := 0; i < ruleType.NumField(); i++ {
+		name, _, _ := strings.Cut(ruleType.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldA := valueA.Field(i)
+		fieldB := valueB.Field(i)
+		if fieldA.Kind() != reflect.Slice {
+			if fieldA.Interface() != fieldB.Interface() {
+				os.Stdout.WriteString(F.ToString("- ", prefix, ".", name, ": ", fieldA.Interface(), "\n"))
+				os.Stdout.WriteString(F.ToString("+ ", prefix, ".", name, ": ", fieldB.Interface(), "\n"))
+			}
+			continue
+		}
+		itemsA := sliceToStrings(fieldA)
+		itemsB := sliceToStrings(fieldB)
+		itemMapA := make(map[string]bool, len(itemsA))
+		for _, item := range itemsA {
+			itemMapA[item] = true
+		}
+		itemMapB := make(map[string]bool, len(itemsB))
+		for _, item := range itemsB {
+			itemMapB[item] = true
+		}
+		for _, item := range itemsA {
+			if !itemMapB[item] {
+				os.Stdout.WriteString("- " + prefix + "." + name + ": " + item + "\n")
+			}
+		}
+		for _, item := range itemsB {
+			if !itemMapA[item] {
+				os.Stdout.WriteString("+ " + prefix + "." + name + ": " + item + "\n")
+			}
+		}
+	}
+}
+
+func sliceToStrings(value reflect.Value) []string {
+	items := make([]string, value.Len())
+	for i := range items {
+		items[i] = F.ToString(value.Index(i).Interface())
+	}
+	return items
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/netip"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/route"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRuleSetMatchFormat string
+
+var commandRuleSetMatch = &cobra.Command{
+	Use:   "match <rule-set-path> <domain|ip>",
+	Short: "Check if a domain or an IP address matches the rule-set",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := ruleSetMatch(args[0], args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSetMatch.Flags().StringVarP(&flagRuleSetMatchFormat, "format", "f", "", "Rule-set format, detected by the file extension if empty")
+	commandRuleSet.AddCommand(commandRuleSetMatch)
+}
+
+func ruleSetMatch(sourcePath string, target string) error {
+	ruleSet, err := readRuleSet(sourcePath, flagRuleSetMatchFormat, false)
+	if err != nil {
+		return err
+	}
+	var metadata adapter.InboundContext
+	if address, err := netip.ParseAddr(target); err == nil {
+		metadata.Destination = M.SocksaddrFrom(address, 0)
+	} else {
+		metadata.Domain = target
+		metadata.Destination = M.Socksaddr{Fqdn: target}
+	}
+	var matched bool
+	for i, ruleOptions := range ruleSet.Rules {
+		rule, err := route.NewHeadlessRule(nil, ruleOptions)
+		if err != nil {
+			return E.Cause(err, "parse rules.[", i, "]")
+		}
+		metadata.ResetRuleCache()
+		if rule.Match(&metadata) {
+			matched = true
+			os.Stdout.WriteString(F.ToString("match rules.[", i, "]: ", rule, "\n"))
+		}
+	}
+	if !matched {
+		os.Stdout.WriteString("not matched\n")
+	}
+	return nil
+}
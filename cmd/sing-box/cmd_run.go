@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/common/jsonschema"
+	"github.com/sagernet/sing-box/common/substitute"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
@@ -42,9 +44,10 @@ type OptionsEntry struct {
 	content []byte
 	path    string
 	options option.Options
+	secrets *substitute.Secrets
 }
 
-func readConfigAt(path string) (*OptionsEntry, error) {
+func readConfigAt(path string, schema *jsonschema.Schema) (*OptionsEntry, error) {
 	var (
 		configContent []byte
 		err           error
@@ -57,21 +60,37 @@ func readConfigAt(path string) (*OptionsEntry, error) {
 	if err != nil {
 		return nil, E.Cause(err, "read config at ", path)
 	}
-	options, err := json.UnmarshalExtended[option.Options](configContent)
+	expandedContent, secrets, err := substitute.Expand(configContent)
 	if err != nil {
-		return nil, E.Cause(err, "decode config at ", path)
+		return nil, E.Cause(err, "substitute config at ", path)
+	}
+	if schema != nil {
+		err = validateConfig(schema, expandedContent)
+		if err != nil {
+			return nil, E.Cause(secrets.MaskError(err), "check config at ", path)
+		}
+	}
+	options, err := json.UnmarshalExtended[option.Options](expandedContent)
+	if err != nil {
+		return nil, E.Cause(secrets.MaskError(err), "decode config at ", path)
 	}
 	return &OptionsEntry{
 		content: configContent,
 		path:    path,
 		options: options,
+		secrets: secrets,
 	}, nil
 }
 
 func readConfig() ([]*OptionsEntry, error) {
+	return readConfigWithSchema(nil)
+}
+
+// readConfigWithSchema reads all configurations, validating them against the schema if not nil.
+func readConfigWithSchema(schema *jsonschema.Schema) ([]*OptionsEntry, error) {
 	var optionsList []*OptionsEntry
 	for _, path := range configPaths {
-		optionsEntry, err := readConfigAt(path)
+		optionsEntry, err := readConfigAt(path, schema)
 		if err != nil {
 			return nil, err
 		}
@@ -86,7 +105,7 @@ func readConfig() ([]*OptionsEntry, error) {
 			if !strings.HasSuffix(entry.Name(), ".json") || entry.IsDir() {
 				continue
 			}
-			optionsEntry, err := readConfigAt(filepath.Join(directory, entry.Name()))
+			optionsEntry, err := readConfigAt(filepath.Join(directory, entry.Name()), schema)
 			if err != nil {
 				return nil, err
 			}
@@ -104,10 +123,17 @@ func readConfigAndMerge() (option.Options, error) {
 	if err != nil {
 		return option.Options{}, err
 	}
+	return mergeConfig(optionsList)
+}
+
+func mergeConfig(optionsList []*OptionsEntry) (option.Options, error) {
 	if len(optionsList) == 1 {
 		return optionsList[0].options, nil
 	}
-	var mergedMessage json.RawMessage
+	var (
+		mergedMessage json.RawMessage
+		err           error
+	)
 	for _, options := range optionsList {
 		mergedMessage, err = badjson.MergeJSON(options.options.RawMessage, mergedMessage)
 		if err != nil {
@@ -117,13 +143,25 @@ func readConfigAndMerge() (option.Options, error) {
 	var mergedOptions option.Options
 	err = mergedOptions.UnmarshalJSON(mergedMessage)
 	if err != nil {
-		return option.Options{}, E.Cause(err, "unmarshal merged config")
+		return option.Options{}, E.Cause(configSecrets(optionsList).MaskError(err), "unmarshal merged config")
 	}
 	return mergedOptions, nil
 }
 
+func configSecrets(optionsList []*OptionsEntry) *substitute.Secrets {
+	var secrets substitute.Secrets
+	for _, options := range optionsList {
+		secrets.Append(options.secrets)
+	}
+	return &secrets
+}
+
 func create() (*box.Box, context.CancelFunc, error) {
-	options, err := readConfigAndMerge()
+	optionsList, err := readConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	options, err := mergeConfig(optionsList)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -140,7 +178,7 @@ func create() (*box.Box, context.CancelFunc, error) {
 	})
 	if err != nil {
 		cancel()
-		return nil, nil, E.Cause(err, "create service")
+		return nil, nil, E.Cause(configSecrets(optionsList).MaskError(err), "create service")
 	}
 
 	osSignals := make(chan os.Signal, 1)
@@ -161,7 +199,7 @@ func create() (*box.Box, context.CancelFunc, error) {
 	finishStart()
 	if err != nil {
 		cancel()
-		return nil, nil, E.Cause(err, "start service")
+		return nil, nil, E.Cause(configSecrets(optionsList).MaskError(err), "start service")
 	}
 	return instance, cancel, nil
 }
@@ -0,0 +1,361 @@
+package capture
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+const (
+	DefaultMaxSize  = 10 * 1024 * 1024
+	DefaultDuration = 5 * time.Minute
+	// Directory holds all capture files, relative to the working directory.
+	Directory = "captures"
+)
+
+type Options struct {
+	Output      string                  `json:"output,omitempty"`
+	Inbound     option.Listable[string] `json:"inbound,omitempty"`
+	Domain      option.Listable[string] `json:"domain,omitempty"`
+	Destination option.Listable[string] `json:"destination,omitempty"`
+	User        option.Listable[string] `json:"user,omitempty"`
+	MaxSize     option.MemoryBytes      `json:"max_size,omitempty"`
+	Duration    option.Duration         `json:"duration,omitempty"`
+}
+
+type Status struct {
+	Running    bool      `json:"running"`
+	Output     string    `json:"output,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	Packets    int64     `json:"packets"`
+	Size       int64     `json:"size"`
+	StopReason string    `json:"stop_reason,omitempty"`
+}
+
+// Manager runs at most one capture at a time.
+type Manager struct {
+	ctx         context.Context
+	logger      logger.Logger
+	access      sync.Mutex
+	current     atomic.Pointer[Capture]
+	last        *Capture
+	rawInbounds sync.Map
+}
+
+func NewManager(ctx context.Context, logger logger.Logger) *Manager {
+	return &Manager{
+		ctx:    ctx,
+		logger: logger,
+	}
+}
+
+// Active returns the running capture, or nil.
+func (m *Manager) Active() *Capture {
+	if m == nil {
+		return nil
+	}
+	return m.current.Load()
+}
+
+func (m *Manager) StartCapture(options Options) (*Status, error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	if m.current.Load() != nil {
+		return nil, E.New("capture already running")
+	}
+	capture, err := newCapture(m, options)
+	if err != nil {
+		return nil, err
+	}
+	m.current.Store(capture)
+	m.last = capture
+	m.logger.Info("capture started, writing to ", capture.path)
+	return capture.Status(), nil
+}
+
+func (m *Manager) StopCapture() (*Status, error) {
+	capture := m.current.Load()
+	if capture == nil {
+		return nil, E.New("capture not running")
+	}
+	capture.close("stopped")
+	return capture.Status(), nil
+}
+
+func (m *Manager) Status() *Status {
+	m.access.Lock()
+	last := m.last
+	m.access.Unlock()
+	if last == nil {
+		return &Status{}
+	}
+	return last.Status()
+}
+
+func (m *Manager) Start() error {
+	return nil
+}
+
+func (m *Manager) Close() error {
+	capture := m.current.Load()
+	if capture != nil {
+		capture.close("service closed")
+	}
+	return nil
+}
+
+func (m *Manager) isRaw(inbound string) bool {
+	_, loaded := m.rawInbounds.Load(inbound)
+	return loaded
+}
+
+type Capture struct {
+	manager     *Manager
+	path        string
+	inbound     []string
+	domain      []string
+	destination []netip.Prefix
+	user        []string
+	raw         bool
+	maxSize     int64
+	startedAt   time.Time
+	timer       *time.Timer
+	access      sync.Mutex
+	file        *os.File
+	writer      *pcapngWriter
+	packets     int64
+	stopReason  string
+}
+
+func newCapture(manager *Manager, options Options) (*Capture, error) {
+	capture := &Capture{
+		manager:   manager,
+		inbound:   options.Inbound,
+		user:      options.User,
+		maxSize:   int64(options.MaxSize),
+		startedAt: time.Now(),
+	}
+	for _, domain := range options.Domain {
+		capture.domain = append(capture.domain, strings.ToLower(strings.TrimSuffix(domain, ".")))
+	}
+	for _, destination := range options.Destination {
+		prefix, err := netip.ParsePrefix(destination)
+		if err != nil {
+			address, addrErr := netip.ParseAddr(destination)
+			if addrErr != nil {
+				return nil, E.New("invalid destination: ", destination)
+			}
+			prefix = netip.PrefixFrom(address, address.BitLen())
+		}
+		capture.destination = append(capture.destination, prefix.Masked())
+	}
+	// domain and user are unknown at the packet level, such captures record synthesized packets for every inbound
+	capture.raw = len(capture.domain) == 0 && len(capture.user) == 0
+	if capture.maxSize == 0 {
+		capture.maxSize = DefaultMaxSize
+	}
+	duration := time.Duration(options.Duration)
+	if duration == 0 {
+		duration = DefaultDuration
+	}
+	output := options.Output
+	if output == "" {
+		output = "capture-" + capture.startedAt.Format("20060102-150405") + ".pcapng"
+	} else if output == "." || output == ".." || filepath.IsAbs(output) || filepath.Base(output) != output {
+		return nil, E.New("invalid output: ", output, ": must be a file name")
+	}
+	directory := filemanager.BasePath(manager.ctx, Directory)
+	err := filemanager.MkdirAll(manager.ctx, directory, 0o755)
+	if err != nil {
+		return nil, E.Cause(err, "create capture directory")
+	}
+	capture.path = filepath.Join(directory, output)
+	file, err := filemanager.OpenFile(manager.ctx, capture.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, E.New("capture file already exists: ", capture.path)
+		}
+		return nil, E.Cause(err, "create capture file")
+	}
+	capture.file = file
+	capture.writer, err = newPCAPNGWriter(file)
+	if err != nil {
+		file.Close()
+		return nil, E.Cause(err, "write capture file")
+	}
+	capture.timer = time.AfterFunc(duration, func() {
+		capture.close("duration limit reached")
+	})
+	return capture, nil
+}
+
+func (c *Capture) Status() *Status {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return &Status{
+		Running:    c.file != nil,
+		Output:     c.path,
+		StartedAt:  c.startedAt,
+		Packets:    c.packets,
+		Size:       c.writer.written,
+		StopReason: c.stopReason,
+	}
+}
+
+// MatchConnection reports whether a routed connection should be recorded as synthesized packets.
+func (c *Capture) MatchConnection(metadata *adapter.InboundContext) bool {
+	if c == nil {
+		return false
+	}
+	// tun inbounds are captured as raw packets unless the filter needs routing metadata
+	if c.raw && c.manager.isRaw(metadata.Inbound) {
+		return false
+	}
+	if len(c.inbound) > 0 && !common.Contains(c.inbound, metadata.Inbound) {
+		return false
+	}
+	if len(c.user) > 0 && !common.Contains(c.user, metadata.User) {
+		return false
+	}
+	if len(c.domain) > 0 {
+		domain := metadata.Domain
+		if domain == "" {
+			domain = metadata.Destination.Fqdn
+		}
+		if !c.matchDomain(domain) {
+			return false
+		}
+	}
+	if len(c.destination) > 0 {
+		addresses := metadata.DestinationAddresses
+		if metadata.Destination.IsIP() {
+			addresses = append([]netip.Addr{metadata.Destination.Addr}, addresses...)
+		}
+		var matched bool
+		for _, address := range addresses {
+			if c.matchAddress(address) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Capture) matchRaw(inbound string, packet []byte) bool {
+	if !c.raw {
+		return false
+	}
+	if len(c.inbound) > 0 && !common.Contains(c.inbound, inbound) {
+		return false
+	}
+	if len(c.destination) > 0 {
+		source, destination, loaded := packetAddresses(packet)
+		if !loaded || !c.matchAddress(source) && !c.matchAddress(destination) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Capture) matchDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" {
+		return false
+	}
+	for _, suffix := range c.domain {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capture) matchAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	for _, prefix := range c.destination {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capture) writePacket(name string, packet []byte, comment string) {
+	c.access.Lock()
+	if c.file == nil {
+		c.access.Unlock()
+		return
+	}
+	if c.writer.written+c.writer.blockSize(name, packet, comment) > c.maxSize {
+		c.access.Unlock()
+		c.close("size limit reached")
+		return
+	}
+	err := c.writer.writePacket(name, time.Now(), packet, comment)
+	if err == nil {
+		c.packets++
+	}
+	c.access.Unlock()
+	if err != nil {
+		c.close("write failed: " + err.Error())
+	}
+}
+
+func (c *Capture) close(reason string) {
+	c.access.Lock()
+	if c.file == nil {
+		c.access.Unlock()
+		return
+	}
+	c.timer.Stop()
+	err := c.file.Close()
+	c.file = nil
+	c.stopReason = reason
+	packets := c.packets
+	c.access.Unlock()
+	c.manager.current.CompareAndSwap(c, nil)
+	if err != nil {
+		c.manager.logger.Error(E.Cause(err, "close capture file"))
+	}
+	c.manager.logger.Info("capture stopped (", reason, "), ", packets, " packets written to ", c.path)
+}
+
+func packetAddresses(packet []byte) (source netip.Addr, destination netip.Addr, loaded bool) {
+	if len(packet) == 0 {
+		return
+	}
+	switch packet[0] >> 4 {
+	case 4:
+		if len(packet) < 20 {
+			return
+		}
+		source = netip.AddrFrom4([4]byte(packet[12:16]))
+		destination = netip.AddrFrom4([4]byte(packet[16:20]))
+	case 6:
+		if len(packet) < 40 {
+			return
+		}
+		source = netip.AddrFrom16([16]byte(packet[8:24]))
+		destination = netip.AddrFrom16([16]byte(packet[24:40]))
+	default:
+		return
+	}
+	loaded = true
+	return
+}
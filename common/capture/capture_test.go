@@ -0,0 +1,197 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPacket(t *testing.T) {
+	t.Parallel()
+	for _, addresses := range [][2]string{
+		{"10.0.0.1:1234", "1.1.1.1:443"},
+		{"[fd00::1]:1234", "[2606:4700::1111]:443"},
+	} {
+		source, destination := netip.MustParseAddrPort(addresses[0]), netip.MustParseAddrPort(addresses[1])
+		for _, packet := range [][]byte{
+			buildTCP(source, destination, 1, 2, tcpFlagPSH|tcpFlagACK, []byte("hello")),
+			buildUDP(source, destination, []byte("hello!")),
+		} {
+			headerLength := 40
+			if source.Addr().Is4() {
+				headerLength = 20
+				require.Equal(t, uint16(0xFFFF), fold(checksumAdd(0, packet[:20])))
+				require.Equal(t, len(packet), int(binary.BigEndian.Uint16(packet[2:])))
+			}
+			var protocol uint8
+			if source.Addr().Is4() {
+				protocol = packet[9]
+			} else {
+				protocol = packet[6]
+			}
+			require.Zero(t, transportChecksum(source.Addr(), destination.Addr(), protocol, packet[headerLength:]))
+		}
+	}
+}
+
+func readBlocks(t *testing.T, path string) []uint32 {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var blockTypes []uint32
+	for len(content) > 0 {
+		require.GreaterOrEqual(t, len(content), 12)
+		length := binary.LittleEndian.Uint32(content[4:])
+		require.Zero(t, length%4)
+		require.Equal(t, length, binary.LittleEndian.Uint32(content[length-4:]))
+		blockTypes = append(blockTypes, binary.LittleEndian.Uint32(content))
+		content = content[length:]
+	}
+	return blockTypes
+}
+
+func TestCaptureConn(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(newTestContext(t), logger.NOP())
+	status, err := manager.StartCapture(Options{
+		Output: "capture.pcapng",
+		Domain: []string{"example.com"},
+	})
+	require.NoError(t, err)
+	output := status.Output
+	_, err = manager.StartCapture(Options{})
+	require.Error(t, err)
+	metadata := adapter.InboundContext{
+		Inbound:     "mixed-in",
+		InboundType: "mixed",
+		Source:      M.ParseSocksaddr("127.0.0.1:40000"),
+		Destination: M.ParseSocksaddr("www.example.com:80"),
+	}
+	require.True(t, manager.Active().MatchConnection(&metadata))
+	otherMetadata := metadata
+	otherMetadata.Destination = M.ParseSocksaddr("example.org:80")
+	require.False(t, manager.Active().MatchConnection(&otherMetadata))
+	client, server := net.Pipe()
+	conn := manager.Active().NewConn(server, metadata, "direct")
+	go func() {
+		client.Write([]byte("request"))
+		buffer := make([]byte, 8)
+		client.Read(buffer)
+		client.Close()
+	}()
+	buffer := make([]byte, 7)
+	_, err = conn.Read(buffer)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("response"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	status, err = manager.StopCapture()
+	require.NoError(t, err)
+	require.False(t, status.Running)
+	require.Equal(t, "stopped", status.StopReason)
+	// handshake, request, response and both FINs
+	require.Equal(t, int64(7), status.Packets)
+	require.Nil(t, manager.Active())
+	blockTypes := readBlocks(t, output)
+	require.Equal(t, []uint32{blockTypeSectionHeader, blockTypeInterfaceDescription}, blockTypes[:2])
+	require.Len(t, blockTypes, 9)
+}
+
+func TestCaptureSizeLimit(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(newTestContext(t), logger.NOP())
+	status, err := manager.StartCapture(Options{
+		Output:      "capture.pcapng",
+		Destination: []string{"1.1.1.0/24"},
+		MaxSize:     512,
+	})
+	require.NoError(t, err)
+	output := status.Output
+	capture := manager.Active()
+	packet := buildUDP(netip.MustParseAddrPort("10.0.0.1:1234"), netip.MustParseAddrPort("1.1.1.1:53"), make([]byte, 100))
+	require.True(t, capture.matchRaw("tun-in", packet))
+	require.False(t, capture.matchRaw("tun-in", buildUDP(netip.MustParseAddrPort("10.0.0.1:1234"), netip.MustParseAddrPort("8.8.8.8:53"), nil)))
+	for i := 0; i < 10; i++ {
+		capture.writePacket("tun[tun-in]", packet, "")
+	}
+	require.Nil(t, manager.Active())
+	status = manager.Status()
+	require.Equal(t, "size limit reached", status.StopReason)
+	require.LessOrEqual(t, status.Size, int64(512))
+	require.Len(t, readBlocks(t, output), 2+int(status.Packets))
+}
+
+func newTestContext(t *testing.T) context.Context {
+	return filemanager.WithDefault(context.Background(), t.TempDir(), "", os.Getuid(), os.Getgid())
+}
+
+func TestCaptureOutput(t *testing.T) {
+	t.Parallel()
+	ctx := newTestContext(t)
+	manager := NewManager(ctx, logger.NOP())
+	for _, output := range []string{"/etc/passwd", "../capture.pcapng", "captures/../../capture.pcapng", "a/capture.pcapng", "..", "."} {
+		_, err := manager.StartCapture(Options{Output: output})
+		require.Error(t, err, output)
+	}
+	status, err := manager.StartCapture(Options{Output: "capture.pcapng"})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(filemanager.BasePath(ctx, Directory), "capture.pcapng"), status.Output)
+	_, err = manager.StopCapture()
+	require.NoError(t, err)
+	content, err := os.ReadFile(status.Output)
+	require.NoError(t, err)
+	_, err = manager.StartCapture(Options{Output: "capture.pcapng"})
+	require.ErrorContains(t, err, "already exists")
+	newContent, err := os.ReadFile(status.Output)
+	require.NoError(t, err)
+	require.Equal(t, content, newContent)
+}
+
+type testTun struct {
+	tun.Tun
+}
+
+func (t *testTun) Close() error {
+	return nil
+}
+
+func TestCaptureTunRaw(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(newTestContext(t), logger.NOP())
+	tunInterface := manager.NewTun("tun-in", &testTun{})
+	_, isCaptureTun := tunInterface.(*captureTun)
+	require.True(t, isCaptureTun)
+	require.True(t, manager.isRaw("tun-in"))
+	metadata := adapter.InboundContext{Inbound: "tun-in", User: "alice"}
+	packet := buildUDP(netip.MustParseAddrPort("10.0.0.1:1234"), netip.MustParseAddrPort("1.1.1.1:53"), nil)
+
+	_, err := manager.StartCapture(Options{Output: "raw.pcapng"})
+	require.NoError(t, err)
+	capture := manager.Active()
+	require.True(t, capture.matchRaw("tun-in", packet))
+	require.False(t, capture.MatchConnection(&metadata))
+	_, err = manager.StopCapture()
+	require.NoError(t, err)
+
+	_, err = manager.StartCapture(Options{Output: "user.pcapng", User: []string{"alice"}})
+	require.NoError(t, err)
+	capture = manager.Active()
+	require.False(t, capture.matchRaw("tun-in", packet))
+	require.True(t, capture.MatchConnection(&metadata))
+	_, err = manager.StopCapture()
+	require.NoError(t, err)
+
+	require.NoError(t, tunInterface.Close())
+	require.False(t, manager.isRaw("tun-in"))
+}
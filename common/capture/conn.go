@@ -0,0 +1,199 @@
+package capture
+
+import (
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/buf"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// NewConn records the plaintext of a routed stream as a synthesized TCP flow.
+func (c *Capture) NewConn(conn net.Conn, metadata adapter.InboundContext, outbound string) net.Conn {
+	client, server := flowAddresses(metadata.Source, destinationAddress(metadata))
+	captureConn := &captureConn{
+		Conn:      conn,
+		capture:   c,
+		name:      interfaceName(metadata),
+		client:    client,
+		server:    server,
+		clientSeq: rand.Uint32(),
+		serverSeq: rand.Uint32(),
+	}
+	captureConn.handshake(flowComment(metadata, outbound))
+	return captureConn
+}
+
+// NewPacketConn records the plaintext of a routed packet connection as synthesized UDP datagrams.
+func (c *Capture) NewPacketConn(conn N.PacketConn, metadata adapter.InboundContext, outbound string) N.PacketConn {
+	return &capturePacketConn{
+		PacketConn: conn,
+		capture:    c,
+		name:       interfaceName(metadata),
+		source:     metadata.Source,
+		comment:    flowComment(metadata, outbound),
+	}
+}
+
+type captureConn struct {
+	net.Conn
+	capture   *Capture
+	name      string
+	client    netip.AddrPort
+	server    netip.AddrPort
+	access    sync.Mutex
+	clientSeq uint32
+	serverSeq uint32
+	closed    bool
+}
+
+func (c *captureConn) handshake(comment string) {
+	c.capture.writePacket(c.name, buildTCP(c.client, c.server, c.clientSeq, 0, tcpFlagSYN, nil), comment)
+	c.clientSeq++
+	c.capture.writePacket(c.name, buildTCP(c.server, c.client, c.serverSeq, c.clientSeq, tcpFlagSYN|tcpFlagACK, nil), "")
+	c.serverSeq++
+	c.capture.writePacket(c.name, buildTCP(c.client, c.server, c.clientSeq, c.serverSeq, tcpFlagACK, nil), "")
+}
+
+func (c *captureConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 {
+		c.record(true, p[:n])
+	}
+	return
+}
+
+func (c *captureConn) Write(p []byte) (n int, err error) {
+	n, err = c.Conn.Write(p)
+	if n > 0 {
+		c.record(false, p[:n])
+	}
+	return
+}
+
+func (c *captureConn) record(fromClient bool, payload []byte) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	for len(payload) > 0 {
+		segment := payload
+		if len(segment) > maxSegmentSize {
+			segment = segment[:maxSegmentSize]
+		}
+		payload = payload[len(segment):]
+		if fromClient {
+			c.capture.writePacket(c.name, buildTCP(c.client, c.server, c.clientSeq, c.serverSeq, tcpFlagPSH|tcpFlagACK, segment), "")
+			c.clientSeq += uint32(len(segment))
+		} else {
+			c.capture.writePacket(c.name, buildTCP(c.server, c.client, c.serverSeq, c.clientSeq, tcpFlagPSH|tcpFlagACK, segment), "")
+			c.serverSeq += uint32(len(segment))
+		}
+	}
+}
+
+func (c *captureConn) Close() error {
+	c.access.Lock()
+	if !c.closed {
+		c.closed = true
+		c.capture.writePacket(c.name, buildTCP(c.client, c.server, c.clientSeq, c.serverSeq, tcpFlagFIN|tcpFlagACK, nil), "")
+		c.clientSeq++
+		c.capture.writePacket(c.name, buildTCP(c.server, c.client, c.serverSeq, c.clientSeq, tcpFlagFIN|tcpFlagACK, nil), "")
+	}
+	c.access.Unlock()
+	return c.Conn.Close()
+}
+
+func (c *captureConn) Upstream() any {
+	return c.Conn
+}
+
+type capturePacketConn struct {
+	N.PacketConn
+	capture *Capture
+	name    string
+	source  M.Socksaddr
+	access  sync.Mutex
+	comment string
+}
+
+func (c *capturePacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err == nil {
+		client, server := flowAddresses(c.source, destination)
+		c.capture.writePacket(c.name, buildUDP(client, server, buffer.Bytes()), c.takeComment())
+	}
+	return
+}
+
+func (c *capturePacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	client, server := flowAddresses(c.source, destination)
+	c.capture.writePacket(c.name, buildUDP(server, client, buffer.Bytes()), c.takeComment())
+	return c.PacketConn.WritePacket(buffer, destination)
+}
+
+func (c *capturePacketConn) takeComment() string {
+	c.access.Lock()
+	defer c.access.Unlock()
+	comment := c.comment
+	c.comment = ""
+	return comment
+}
+
+func (c *capturePacketConn) Upstream() any {
+	return c.PacketConn
+}
+
+func interfaceName(metadata adapter.InboundContext) string {
+	if metadata.Inbound == "" {
+		return metadata.InboundType
+	}
+	return F.ToString(metadata.InboundType, "[", metadata.Inbound, "]")
+}
+
+func flowComment(metadata adapter.InboundContext, outbound string) string {
+	var comment []string
+	comment = append(comment, "inbound: "+interfaceName(metadata))
+	if metadata.User != "" {
+		comment = append(comment, "user: "+metadata.User)
+	}
+	comment = append(comment, "source: "+metadata.Source.String())
+	comment = append(comment, "destination: "+metadata.Destination.String())
+	if metadata.Domain != "" && metadata.Domain != metadata.Destination.Fqdn {
+		comment = append(comment, "domain: "+metadata.Domain)
+	}
+	comment = append(comment, "outbound: "+outbound)
+	return strings.Join(comment, ", ")
+}
+
+func destinationAddress(metadata adapter.InboundContext) M.Socksaddr {
+	if !metadata.Destination.IsIP() && len(metadata.DestinationAddresses) > 0 {
+		return M.SocksaddrFrom(metadata.DestinationAddresses[0], metadata.Destination.Port)
+	}
+	return metadata.Destination
+}
+
+// flowAddresses converts both endpoints to addresses of the same family,
+// using an unspecified address for endpoints without one.
+func flowAddresses(source M.Socksaddr, destination M.Socksaddr) (netip.AddrPort, netip.AddrPort) {
+	sourceAddr, destinationAddr := source.Addr.Unmap(), destination.Addr.Unmap()
+	if !sourceAddr.IsValid() {
+		sourceAddr = netip.IPv4Unspecified()
+	}
+	if !destinationAddr.IsValid() {
+		if sourceAddr.Is6() {
+			destinationAddr = netip.IPv6Unspecified()
+		} else {
+			destinationAddr = netip.IPv4Unspecified()
+		}
+	}
+	if sourceAddr.Is4() != destinationAddr.Is4() {
+		sourceAddr = netip.AddrFrom16(sourceAddr.As16())
+		destinationAddr = netip.AddrFrom16(destinationAddr.As16())
+	}
+	return netip.AddrPortFrom(sourceAddr, source.Port), netip.AddrPortFrom(destinationAddr, destination.Port)
+}
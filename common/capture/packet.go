@@ -0,0 +1,114 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	protocolTCP = 6
+	protocolUDP = 17
+
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagPSH = 0x08
+	tcpFlagACK = 0x10
+
+	// keep synthesized packets within the IPv4 total length limit
+	maxSegmentSize = 65535 - 60 - 20
+)
+
+// buildTCP synthesizes an IP packet carrying a TCP segment.
+func buildTCP(source netip.AddrPort, destination netip.AddrPort, seq uint32, ack uint32, flags uint8, payload []byte) []byte {
+	segment := make([]byte, 20+len(payload))
+	binary.BigEndian.PutUint16(segment, source.Port())
+	binary.BigEndian.PutUint16(segment[2:], destination.Port())
+	binary.BigEndian.PutUint32(segment[4:], seq)
+	binary.BigEndian.PutUint32(segment[8:], ack)
+	segment[12] = 5 << 4
+	segment[13] = flags
+	binary.BigEndian.PutUint16(segment[14:], 65535)
+	copy(segment[20:], payload)
+	binary.BigEndian.PutUint16(segment[16:], transportChecksum(source.Addr(), destination.Addr(), protocolTCP, segment))
+	return buildIP(source.Addr(), destination.Addr(), protocolTCP, segment)
+}
+
+// buildUDP synthesizes an IP packet carrying a UDP datagram.
+func buildUDP(source netip.AddrPort, destination netip.AddrPort, payload []byte) []byte {
+	if len(payload) > maxSegmentSize {
+		payload = payload[:maxSegmentSize]
+	}
+	datagram := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint16(datagram, source.Port())
+	binary.BigEndian.PutUint16(datagram[2:], destination.Port())
+	binary.BigEndian.PutUint16(datagram[4:], uint16(len(datagram)))
+	copy(datagram[8:], payload)
+	checksum := transportChecksum(source.Addr(), destination.Addr(), protocolUDP, datagram)
+	if checksum == 0 {
+		checksum = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(datagram[6:], checksum)
+	return buildIP(source.Addr(), destination.Addr(), protocolUDP, datagram)
+}
+
+func buildIP(source netip.Addr, destination netip.Addr, protocol uint8, payload []byte) []byte {
+	if source.Is4() {
+		packet := make([]byte, 20+len(payload))
+		packet[0] = 4<<4 | 5
+		binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+		// don't fragment
+		packet[6] = 0x40
+		packet[8] = 64
+		packet[9] = protocol
+		source4, destination4 := source.As4(), destination.As4()
+		copy(packet[12:], source4[:])
+		copy(packet[16:], destination4[:])
+		binary.BigEndian.PutUint16(packet[10:], ^uint16(checksumAdd(0, packet[:20])))
+		copy(packet[20:], payload)
+		return packet
+	}
+	packet := make([]byte, 40+len(payload))
+	packet[0] = 6 << 4
+	binary.BigEndian.PutUint16(packet[4:], uint16(len(payload)))
+	packet[6] = protocol
+	packet[7] = 64
+	source16, destination16 := source.As16(), destination.As16()
+	copy(packet[8:], source16[:])
+	copy(packet[24:], destination16[:])
+	copy(packet[40:], payload)
+	return packet
+}
+
+func transportChecksum(source netip.Addr, destination netip.Addr, protocol uint8, payload []byte) uint16 {
+	var sum uint32
+	if source.Is4() {
+		source4, destination4 := source.As4(), destination.As4()
+		sum = checksumAdd(sum, source4[:])
+		sum = checksumAdd(sum, destination4[:])
+	} else {
+		source16, destination16 := source.As16(), destination.As16()
+		sum = checksumAdd(sum, source16[:])
+		sum = checksumAdd(sum, destination16[:])
+	}
+	sum += uint32(protocol) + uint32(len(payload))
+	sum = checksumAdd(sum, payload)
+	return ^fold(sum)
+}
+
+func checksumAdd(sum uint32, data []byte) uint32 {
+	for len(data) >= 2 {
+		sum += uint32(binary.BigEndian.Uint16(data))
+		data = data[2:]
+	}
+	if len(data) == 1 {
+		sum += uint32(data[0]) << 8
+	}
+	return uint32(fold(sum))
+}
+
+func fold(sum uint32) uint16 {
+	for sum>>16 != 0 {
+		sum = sum&0xFFFF + sum>>16
+	}
+	return uint16(sum)
+}
@@ -0,0 +1,106 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// pcapng block types and options, see https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html
+const (
+	blockTypeSectionHeader        = 0x0A0D0D0A
+	blockTypeInterfaceDescription = 0x00000001
+	blockTypeEnhancedPacket       = 0x00000006
+	byteOrderMagic                = 0x1A2B3C4D
+	linkTypeRaw                   = 101
+
+	optionEndOfOpt = 0
+	optionComment  = 1
+	optionIfName   = 2
+)
+
+// pcapngWriter writes raw IP packets, with one interface per name.
+type pcapngWriter struct {
+	writer     io.Writer
+	interfaces map[string]uint32
+	written    int64
+}
+
+func newPCAPNGWriter(writer io.Writer) (*pcapngWriter, error) {
+	w := &pcapngWriter{
+		writer:     writer,
+		interfaces: make(map[string]uint32),
+	}
+	body := make([]byte, 16)
+	binary.LittleEndian.PutUint32(body, byteOrderMagic)
+	binary.LittleEndian.PutUint16(body[4:], 1)
+	binary.LittleEndian.PutUint16(body[6:], 0)
+	// unknown section length
+	binary.LittleEndian.PutUint64(body[8:], 0xFFFFFFFFFFFFFFFF)
+	return w, w.writeBlock(blockTypeSectionHeader, body)
+}
+
+// blockSize returns the size of a packet block, to check size limits before writing.
+func (w *pcapngWriter) blockSize(name string, packet []byte, comment string) int64 {
+	size := 32 + pad(len(packet))
+	if comment != "" {
+		size += 4 + pad(len(comment)) + 4
+	}
+	if _, loaded := w.interfaces[name]; !loaded {
+		size += 20 + 4 + pad(len(name)) + 4
+	}
+	return int64(size)
+}
+
+func (w *pcapngWriter) writePacket(name string, timestamp time.Time, packet []byte, comment string) error {
+	interfaceID, loaded := w.interfaces[name]
+	if !loaded {
+		interfaceID = uint32(len(w.interfaces))
+		body := make([]byte, 8)
+		binary.LittleEndian.PutUint16(body, linkTypeRaw)
+		body = appendOption(body, optionIfName, name)
+		body = binary.LittleEndian.AppendUint32(body, optionEndOfOpt)
+		err := w.writeBlock(blockTypeInterfaceDescription, body)
+		if err != nil {
+			return err
+		}
+		w.interfaces[name] = interfaceID
+	}
+	microseconds := uint64(timestamp.UnixMicro())
+	body := make([]byte, 20, 20+pad(len(packet)))
+	binary.LittleEndian.PutUint32(body, interfaceID)
+	binary.LittleEndian.PutUint32(body[4:], uint32(microseconds>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(microseconds))
+	binary.LittleEndian.PutUint32(body[12:], uint32(len(packet)))
+	binary.LittleEndian.PutUint32(body[16:], uint32(len(packet)))
+	body = append(body, packet...)
+	body = append(body, make([]byte, pad(len(packet))-len(packet))...)
+	if comment != "" {
+		body = appendOption(body, optionComment, comment)
+		body = binary.LittleEndian.AppendUint32(body, optionEndOfOpt)
+	}
+	return w.writeBlock(blockTypeEnhancedPacket, body)
+}
+
+func (w *pcapngWriter) writeBlock(blockType uint32, body []byte) error {
+	length := uint32(12 + len(body))
+	block := make([]byte, 0, length)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, length)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, length)
+	n, err := w.writer.Write(block)
+	w.written += int64(n)
+	return err
+}
+
+func appendOption(body []byte, code uint16, value string) []byte {
+	body = binary.LittleEndian.AppendUint16(body, code)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(value)))
+	body = append(body, value...)
+	return append(body, make([]byte, pad(len(value))-len(value))...)
+}
+
+func pad(length int) int {
+	return (length + 3) &^ 3
+}
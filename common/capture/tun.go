@@ -0,0 +1,106 @@
+package capture
+
+import (
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// NewTun wraps a tun interface so that running captures without domain or user filters
+// record its raw packets, the wrapper does nothing while no capture is running.
+// The gvisor stack requires the original interface and is not supported.
+func (m *Manager) NewTun(tag string, tunInterface tun.Tun) tun.Tun {
+	m.rawInbounds.Store(tag, true)
+	captureTun := &captureTun{
+		Tun:     tunInterface,
+		writer:  bufio.NewVectorisedWriter(tunInterface),
+		manager: m,
+		tag:     tag,
+		name:    "tun[" + tag + "]",
+	}
+	if winTun, isWinTun := tunInterface.(tun.WinTun); isWinTun {
+		return &captureWinTun{captureTun, winTun}
+	}
+	return captureTun
+}
+
+type captureTun struct {
+	tun.Tun
+	writer  N.VectorisedWriter
+	manager *Manager
+	tag     string
+	name    string
+}
+
+func (t *captureTun) record(packet []byte) {
+	capture := t.manager.Active()
+	if capture == nil || !capture.matchRaw(t.tag, packet) {
+		return
+	}
+	capture.writePacket(t.name, packet, "")
+}
+
+func (t *captureTun) Read(p []byte) (n int, err error) {
+	n, err = t.Tun.Read(p)
+	if n > tun.PacketOffset {
+		t.record(p[tun.PacketOffset:n])
+	}
+	return
+}
+
+func (t *captureTun) Write(p []byte) (n int, err error) {
+	offset := t.Tun.FrontHeadroom() + tun.PacketOffset
+	if len(p) > offset {
+		t.record(p[offset:])
+	}
+	return t.Tun.Write(p)
+}
+
+func (t *captureTun) WriteVectorised(buffers []*buf.Buffer) error {
+	if t.manager.Active() != nil {
+		packet := make([]byte, buf.LenMulti(buffers))
+		buf.CopyMulti(packet, buffers)
+		t.record(packet)
+	}
+	return t.writer.WriteVectorised(buffers)
+}
+
+func (t *captureTun) BatchSize() int {
+	if batchTUN, isBatchTUN := t.Tun.(tun.BatchTUN); isBatchTUN {
+		return batchTUN.BatchSize()
+	}
+	return 1
+}
+
+func (t *captureTun) BatchRead(buffers [][]byte, readN []int) (n int, err error) {
+	n, err = t.Tun.(tun.BatchTUN).BatchRead(buffers, readN)
+	for i := 0; i < n; i++ {
+		if readN[i] > tun.PacketOffset {
+			t.record(buffers[i][tun.PacketOffset:readN[i]])
+		}
+	}
+	return
+}
+
+func (t *captureTun) Close() error {
+	t.manager.rawInbounds.Delete(t.tag)
+	return t.Tun.Close()
+}
+
+func (t *captureTun) Upstream() any {
+	return t.Tun
+}
+
+type captureWinTun struct {
+	*captureTun
+	winTun tun.WinTun
+}
+
+func (t *captureWinTun) ReadPacket() ([]byte, func(), error) {
+	packet, release, err := t.winTun.ReadPacket()
+	if err == nil {
+		t.record(packet)
+	}
+	return packet, release, err
+}
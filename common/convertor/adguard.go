@@ -0,0 +1,129 @@
+package convertor
+
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// convertAdGuard accepts the network rules of AdGuard and Adblock Plus filters that can be
+// applied to a domain, cosmetic rules and rules with unsupported modifiers are skipped.
+// Exception rules are excluded from the blocking rules.
+func convertAdGuard(content []byte) ([]option.HeadlessRule, error) {
+	var blockBuilder, exceptionBuilder ruleBuilder
+	for _, line := range readLines(content, "!", "[") {
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "##") {
+			continue
+		}
+		builder := &blockBuilder
+		if strings.HasPrefix(line, "@@") {
+			builder = &exceptionBuilder
+			line = line[2:]
+		}
+		parseAdGuardRule(builder, line)
+	}
+	blockRules := blockBuilder.build(false)
+	exceptionRules := exceptionBuilder.build(true)
+	if len(blockRules) == 0 || len(exceptionRules) == 0 {
+		return blockRules, nil
+	}
+	return []option.HeadlessRule{{
+		Type: C.RuleTypeLogical,
+		LogicalOptions: option.LogicalHeadlessRule{
+			Mode:  C.LogicalTypeAnd,
+			Rules: append(blockRules, exceptionRules...),
+		},
+	}}, nil
+}
+
+func parseAdGuardRule(builder *ruleBuilder, line string) {
+	for _, marker := range []string{"##", "#@#", "#?#", "#$#", "#%#", "$$", "$@$"} {
+		if strings.Contains(line, marker) {
+			return
+		}
+	}
+	if strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") && len(line) > 2 {
+		pattern := line[1 : len(line)-1]
+		if _, err := regexp.Compile(pattern); err == nil {
+			builder.domainRegex = append(builder.domainRegex, pattern)
+		}
+		return
+	}
+	if pattern, modifiers, hasModifiers := strings.Cut(line, "$"); hasModifiers {
+		for _, modifier := range strings.Split(modifiers, ",") {
+			switch modifier {
+			case "important", "all", "document", "doc", "first-party", "third-party", "3p", "~third-party":
+			default:
+				return
+			}
+		}
+		line = pattern
+	}
+	if fields := strings.Fields(line); len(fields) > 1 {
+		// hosts syntax
+		if _, err := netip.ParseAddr(fields[0]); err == nil {
+			for _, domain := range fields[1:] {
+				if strings.HasPrefix(domain, "#") {
+					break
+				}
+				builder.domain = append(builder.domain, domain)
+			}
+		}
+		return
+	}
+	var isSuffix, hasStart, hasEnd bool
+	if strings.HasPrefix(line, "||") {
+		line = line[2:]
+		isSuffix = true
+	} else if strings.HasPrefix(line, "|") {
+		line = line[1:]
+		hasStart = true
+	}
+	if strings.HasSuffix(line, "|") {
+		line = line[:len(line)-1]
+		hasEnd = true
+	}
+	if strings.HasSuffix(line, "^") {
+		line = line[:len(line)-1]
+		hasEnd = true
+	}
+	line = strings.TrimPrefix(line, "*.")
+	if line == "" || strings.ContainsAny(line, "/:?=&^|") {
+		// url rules cannot be applied to domains
+		return
+	}
+	line = strings.ToLower(line)
+	switch {
+	case strings.Contains(line, "*"):
+		pattern := wildcardToRegex(line, ".*")
+		if isSuffix {
+			pattern = `^(.*\.)?` + pattern[1:]
+		} else {
+			if !hasStart {
+				pattern = strings.TrimPrefix(pattern, "^")
+			}
+			if !hasEnd {
+				pattern = strings.TrimSuffix(pattern, "$")
+			}
+		}
+		builder.domainRegex = append(builder.domainRegex, pattern)
+	case isSuffix:
+		builder.domainSuffix = append(builder.domainSuffix, line)
+	case hasStart && hasEnd:
+		builder.domain = append(builder.domain, line)
+	case hasStart || hasEnd:
+		pattern := wildcardToRegex(line, "")
+		if !hasStart {
+			pattern = pattern[1:]
+		}
+		if !hasEnd {
+			pattern = pattern[:len(pattern)-1]
+		}
+		builder.domainRegex = append(builder.domainRegex, pattern)
+	default:
+		builder.domainKeyword = append(builder.domainKeyword, line)
+	}
+}
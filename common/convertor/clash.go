@@ -0,0 +1,139 @@
+package convertor
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	N "github.com/sagernet/sing/common/network"
+
+	"gopkg.in/yaml.v3"
+)
+
+type clashRuleProvider struct {
+	Payload []string `yaml:"payload"`
+}
+
+// convertClash accepts both yaml and text rule providers of domain, ipcidr and classical behaviors.
+// A provider with any comma separated entry is classical, other providers are detected for each entry.
+func convertClash(content []byte) ([]option.HeadlessRule, error) {
+	var (
+		provider clashRuleProvider
+		entries  []string
+	)
+	err := yaml.Unmarshal(content, &provider)
+	if err == nil && len(provider.Payload) > 0 {
+		entries = provider.Payload
+	} else {
+		entries = readLines(content, "#", "//", "payload:")
+		for i, entry := range entries {
+			entries[i] = strings.Trim(strings.TrimPrefix(entry, "- "), `'"`)
+		}
+	}
+	classical := common.Any(entries, func(it string) bool {
+		return strings.Contains(it, ",")
+	})
+	var builder ruleBuilder
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if classical {
+			// entries without a value like MATCH are skipped instead of being taken as domains
+			if strings.Contains(entry, ",") {
+				parseClashClassical(&builder, entry)
+			}
+		} else if prefix, err := netip.ParsePrefix(entry); err == nil {
+			builder.ipCIDR = append(builder.ipCIDR, prefix.String())
+		} else if address, err := netip.ParseAddr(entry); err == nil {
+			builder.ipCIDR = append(builder.ipCIDR, address.String())
+		} else {
+			parseClashDomain(&builder, entry)
+		}
+	}
+	return builder.build(false), nil
+}
+
+func parseClashDomain(builder *ruleBuilder, domain string) {
+	switch {
+	case strings.HasPrefix(domain, "+."):
+		builder.domainSuffix = append(builder.domainSuffix, domain[2:])
+	case strings.HasPrefix(domain, "."):
+		builder.domainSuffix = append(builder.domainSuffix, domain)
+	case strings.Contains(domain, "*"):
+		builder.domainRegex = append(builder.domainRegex, wildcardToRegex(domain, "[^.]+"))
+	default:
+		builder.domain = append(builder.domain, domain)
+	}
+}
+
+func parseClashClassical(builder *ruleBuilder, entry string) {
+	params := strings.Split(entry, ",")
+	ruleType := strings.ToUpper(strings.TrimSpace(params[0]))
+	value := strings.TrimSpace(params[1])
+	if value == "" {
+		return
+	}
+	switch ruleType {
+	case "DOMAIN":
+		builder.domain = append(builder.domain, value)
+	case "DOMAIN-SUFFIX":
+		builder.domainSuffix = append(builder.domainSuffix, value)
+	case "DOMAIN-KEYWORD":
+		builder.domainKeyword = append(builder.domainKeyword, value)
+	case "DOMAIN-REGEX":
+		builder.domainRegex = append(builder.domainRegex, value)
+	case "IP-CIDR", "IP-CIDR6":
+		if prefix, isPrefix := parseClashCIDR(value); isPrefix {
+			builder.ipCIDR = append(builder.ipCIDR, prefix)
+		}
+	case "SRC-IP-CIDR":
+		if prefix, isPrefix := parseClashCIDR(value); isPrefix {
+			builder.sourceIPCIDR = append(builder.sourceIPCIDR, prefix)
+		}
+	case "DST-PORT":
+		builder.port, builder.portRange = appendClashPort(builder.port, builder.portRange, value)
+	case "SRC-PORT":
+		builder.sourcePort, builder.sourcePortRange = appendClashPort(builder.sourcePort, builder.sourcePortRange, value)
+	case "PROCESS-NAME":
+		builder.processName = append(builder.processName, value)
+	case "PROCESS-PATH":
+		builder.processPath = append(builder.processPath, value)
+	case "NETWORK":
+		switch network := strings.ToLower(value); network {
+		case N.NetworkTCP, N.NetworkUDP:
+			builder.network = append(builder.network, network)
+		}
+	}
+}
+
+func parseClashCIDR(value string) (string, bool) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.String(), true
+	}
+	if address, err := netip.ParseAddr(value); err == nil {
+		return address.String(), true
+	}
+	return "", false
+}
+
+func appendClashPort(ports []uint16, portRanges []string, value string) ([]uint16, []string) {
+	for _, item := range strings.Split(value, "/") {
+		if from, to, isRange := strings.Cut(item, "-"); isRange {
+			if isClashPort(from) && isClashPort(to) {
+				portRanges = append(portRanges, from+":"+to)
+			}
+		} else if port, err := strconv.ParseUint(item, 10, 16); err == nil {
+			ports = append(ports, uint16(port))
+		}
+	}
+	return ports, portRanges
+}
+
+func isClashPort(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 16)
+	return err == nil
+}
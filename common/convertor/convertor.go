@@ -0,0 +1,146 @@
+package convertor
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+func IsConvertibleFormat(format string) bool {
+	switch format {
+	case C.RuleSetFormatClash, C.RuleSetFormatAdGuard, C.RuleSetFormatDnsmasq, C.RuleSetFormatHosts:
+		return true
+	default:
+		return false
+	}
+}
+
+// Convert parses a third-party rule list into headless rules,
+// unsupported entries are skipped.
+func Convert(content []byte, format string) (option.PlainRuleSet, error) {
+	var (
+		rules []option.HeadlessRule
+		err   error
+	)
+	switch format {
+	case C.RuleSetFormatClash:
+		rules, err = convertClash(content)
+	case C.RuleSetFormatAdGuard:
+		rules, err = convertAdGuard(content)
+	case C.RuleSetFormatDnsmasq:
+		rules, err = convertDnsmasq(content)
+	case C.RuleSetFormatHosts:
+		rules, err = convertHosts(content)
+	default:
+		return option.PlainRuleSet{}, E.New("unknown rule set format: ", format)
+	}
+	if err != nil {
+		return option.PlainRuleSet{}, err
+	}
+	return option.PlainRuleSet{Rules: rules}, nil
+}
+
+func readLines(content []byte, commentPrefixes ...string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var isComment bool
+		for _, prefix := range commentPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				isComment = true
+				break
+			}
+		}
+		if !isComment {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+type ruleBuilder struct {
+	domain          []string
+	domainSuffix    []string
+	domainKeyword   []string
+	domainRegex     []string
+	ipCIDR          []string
+	sourceIPCIDR    []string
+	port            []uint16
+	portRange       []string
+	sourcePort      []uint16
+	sourcePortRange []string
+	processName     []string
+	processPath     []string
+	network         []string
+}
+
+// build returns a rule for each kind of item, since items of different kinds
+// in a single rule are matched together instead of separately.
+func (b *ruleBuilder) build(invert bool) []option.HeadlessRule {
+	var rules []option.HeadlessRule
+	appendRule := func(rule option.DefaultHeadlessRule) {
+		rule.Invert = invert
+		rules = append(rules, option.HeadlessRule{
+			Type:           C.RuleTypeDefault,
+			DefaultOptions: rule,
+		})
+	}
+	if len(b.domain) > 0 || len(b.domainSuffix) > 0 || len(b.domainKeyword) > 0 || len(b.domainRegex) > 0 {
+		appendRule(option.DefaultHeadlessRule{
+			Domain:        b.domain,
+			DomainSuffix:  b.domainSuffix,
+			DomainKeyword: b.domainKeyword,
+			DomainRegex:   b.domainRegex,
+		})
+	}
+	if len(b.ipCIDR) > 0 {
+		appendRule(option.DefaultHeadlessRule{IPCIDR: b.ipCIDR})
+	}
+	if len(b.sourceIPCIDR) > 0 {
+		appendRule(option.DefaultHeadlessRule{SourceIPCIDR: b.sourceIPCIDR})
+	}
+	if len(b.port) > 0 || len(b.portRange) > 0 {
+		appendRule(option.DefaultHeadlessRule{Port: b.port, PortRange: b.portRange})
+	}
+	if len(b.sourcePort) > 0 || len(b.sourcePortRange) > 0 {
+		appendRule(option.DefaultHeadlessRule{SourcePort: b.sourcePort, SourcePortRange: b.sourcePortRange})
+	}
+	if len(b.processName) > 0 {
+		appendRule(option.DefaultHeadlessRule{ProcessName: b.processName})
+	}
+	if len(b.processPath) > 0 {
+		appendRule(option.DefaultHeadlessRule{ProcessPath: b.processPath})
+	}
+	if len(b.network) > 0 {
+		appendRule(option.DefaultHeadlessRule{Network: b.network})
+	}
+	return rules
+}
+
+// wildcardToRegex converts a domain pattern with `*` wildcards to a domain regex.
+func wildcardToRegex(pattern string, wildcard string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for _, char := range pattern {
+		switch char {
+		case '*':
+			builder.WriteString(wildcard)
+		case '.', '+', '?', '(', ')', '[', ']', '{', '}', '|', '^', '$', '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(char)
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
@@ -0,0 +1,233 @@
+package convertor
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func defaultRule(rule option.DefaultHeadlessRule) option.HeadlessRule {
+	return option.HeadlessRule{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: rule,
+	}
+}
+
+type convertTestCase struct {
+	name    string
+	content string
+	rules   []option.HeadlessRule
+}
+
+func testConvert(t *testing.T, format string, testCases []convertTestCase) {
+	t.Helper()
+	for _, testCase := range testCases {
+		ruleSet, err := Convert([]byte(testCase.content), format)
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, testCase.rules, ruleSet.Rules, testCase.name)
+	}
+}
+
+func TestConvertClash(t *testing.T) {
+	t.Parallel()
+	testConvert(t, C.RuleSetFormatClash, []convertTestCase{
+		{
+			name: "domain yaml",
+			content: `payload:
+  - '+.google.com'
+  - '.example.com'
+  - 'a.*.b.com'
+  - 'plain.com'
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				Domain:       []string{"plain.com"},
+				DomainSuffix: []string{"google.com", ".example.com"},
+				DomainRegex:  []string{`^a\.[^.]+\.b\.com$`},
+			})},
+		},
+		{
+			name: "ipcidr text",
+			content: `# comment
+1.1.1.0/24
+2001:db8::/32
+8.8.8.8
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				IPCIDR: []string{"1.1.1.0/24", "2001:db8::/32", "8.8.8.8"},
+			})},
+		},
+		{
+			name: "classical yaml",
+			content: `payload:
+  - DOMAIN,a.com
+  - DOMAIN-SUFFIX,b.com
+  - DOMAIN-KEYWORD,goo
+  - IP-CIDR,10.0.0.0/8,no-resolve
+  - IP-CIDR6,::1/128
+  - SRC-IP-CIDR,192.168.1.1
+  - DST-PORT,80/443/1000-2000
+  - SRC-PORT,53
+  - PROCESS-NAME,curl
+  - NETWORK,TCP
+  - MATCH
+  - plain.com
+`,
+			rules: []option.HeadlessRule{
+				defaultRule(option.DefaultHeadlessRule{
+					Domain:        []string{"a.com"},
+					DomainSuffix:  []string{"b.com"},
+					DomainKeyword: []string{"goo"},
+				}),
+				defaultRule(option.DefaultHeadlessRule{IPCIDR: []string{"10.0.0.0/8", "::1/128"}}),
+				defaultRule(option.DefaultHeadlessRule{SourceIPCIDR: []string{"192.168.1.1"}}),
+				defaultRule(option.DefaultHeadlessRule{Port: []uint16{80, 443}, PortRange: []string{"1000:2000"}}),
+				defaultRule(option.DefaultHeadlessRule{SourcePort: []uint16{53}}),
+				defaultRule(option.DefaultHeadlessRule{ProcessName: []string{"curl"}}),
+				defaultRule(option.DefaultHeadlessRule{Network: []string{"tcp"}}),
+			},
+		},
+		{
+			name: "classical malformed",
+			content: `DOMAIN,
+IP-CIDR,not-an-ip
+DST-PORT,a-b/70000
+UNKNOWN,value
+NETWORK,icmp
+MATCH
+DOMAIN,ok.com
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				Domain: []string{"ok.com"},
+			})},
+		},
+		{
+			name:    "empty",
+			content: "payload:\n# comment\n",
+		},
+	})
+}
+
+func TestConvertAdGuard(t *testing.T) {
+	t.Parallel()
+	testConvert(t, C.RuleSetFormatAdGuard, []convertTestCase{
+		{
+			name: "block",
+			content: `! comment
+[Adblock Plus 2.0]
+||example.com^
+||y.com^$important
+||Upper.COM^
+|exact.com|
+keyword
+||ads.*.com^
+/^ad[0-9]+\.net$/
+|start.com
+0.0.0.0 tracker.com tracker2.com # comment
+example.org##.banner
+||x.com^$script
+https://x.com/path
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				Domain:        []string{"exact.com", "tracker.com", "tracker2.com"},
+				DomainSuffix:  []string{"example.com", "y.com", "upper.com"},
+				DomainKeyword: []string{"keyword"},
+				DomainRegex:   []string{`^(.*\.)?ads\..*\.com$`, `^ad[0-9]+\.net$`, `^start\.com`},
+			})},
+		},
+		{
+			name: "exception",
+			content: `||a.com^
+@@||b.a.com^
+`,
+			rules: []option.HeadlessRule{{
+				Type: C.RuleTypeLogical,
+				LogicalOptions: option.LogicalHeadlessRule{
+					Mode: C.LogicalTypeAnd,
+					Rules: []option.HeadlessRule{
+						defaultRule(option.DefaultHeadlessRule{DomainSuffix: []string{"a.com"}}),
+						defaultRule(option.DefaultHeadlessRule{DomainSuffix: []string{"b.a.com"}, Invert: true}),
+					},
+				},
+			}},
+		},
+		{
+			name:    "exception only",
+			content: "@@||b.a.com^\n",
+		},
+		{
+			name: "malformed",
+			content: `||^
+@@
+|
+/[/
+$$script
+||a.com^$domain=b.com
+`,
+		},
+	})
+}
+
+func TestConvertDnsmasq(t *testing.T) {
+	t.Parallel()
+	testConvert(t, C.RuleSetFormatDnsmasq, []convertTestCase{
+		{
+			name: "domains",
+			content: `# comment
+server=/example.com/1.1.1.1
+address=/.ads.com/sub.ads.com/0.0.0.0
+ipset=/a.com/setname
+nftset=/b.com/4#inet#t#s
+local=/lan/
+address=/#/0.0.0.0
+server=1.1.1.1
+conf-file=/etc/dnsmasq.d/x.conf
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				DomainSuffix: []string{"example.com", "ads.com", "sub.ads.com", "a.com", "b.com", "lan"},
+			})},
+		},
+		{
+			name: "malformed",
+			content: `bogus-line
+server=/
+=/x.com/
+address=
+`,
+		},
+	})
+}
+
+func TestConvertHosts(t *testing.T) {
+	t.Parallel()
+	testConvert(t, C.RuleSetFormatHosts, []convertTestCase{
+		{
+			name: "hosts",
+			content: `# comment
+127.0.0.1 localhost
+::1 ip6-localhost ip6-loopback
+0.0.0.0 Ads.Example.com tracker.com # trailing comment
+1.2.3.4 host.lan
+`,
+			rules: []option.HeadlessRule{defaultRule(option.DefaultHeadlessRule{
+				Domain: []string{"ads.example.com", "tracker.com", "host.lan"},
+			})},
+		},
+		{
+			name: "malformed",
+			content: `0.0.0.0 0.0.0.0
+not-an-ip example.com
+10.0.0.1
+10.0.0.1 # example.com
+`,
+		},
+	})
+}
+
+func TestConvertUnknownFormat(t *testing.T) {
+	t.Parallel()
+	_, err := Convert(nil, "unknown")
+	require.Error(t, err)
+}
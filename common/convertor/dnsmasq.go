@@ -0,0 +1,37 @@
+package convertor
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+// convertDnsmasq accepts the domains of server, address, ipset and nftset options,
+// which match the domain and all its subdomains.
+func convertDnsmasq(content []byte) ([]option.HeadlessRule, error) {
+	var builder ruleBuilder
+	for _, line := range readLines(content, "#") {
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "server", "local", "address", "ipset", "nftset":
+		default:
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, "/") {
+			continue
+		}
+		domains := strings.Split(value[1:], "/")
+		for _, domain := range domains[:len(domains)-1] {
+			domain = strings.TrimPrefix(domain, ".")
+			if domain == "" || domain == "#" {
+				continue
+			}
+			builder.domainSuffix = append(builder.domainSuffix, domain)
+		}
+	}
+	return builder.build(false), nil
+}
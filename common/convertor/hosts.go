@@ -0,0 +1,42 @@
+package convertor
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+// convertHosts accepts the host names of a hosts file, local names are skipped.
+func convertHosts(content []byte) ([]option.HeadlessRule, error) {
+	var builder ruleBuilder
+	for _, line := range readLines(content, "#") {
+		if index := strings.IndexByte(line, '#'); index != -1 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if _, err := netip.ParseAddr(fields[0]); err != nil {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if isLocalHostName(name) {
+				continue
+			}
+			builder.domain = append(builder.domain, strings.ToLower(name))
+		}
+	}
+	return builder.build(false), nil
+}
+
+func isLocalHostName(name string) bool {
+	switch strings.ToLower(name) {
+	case "localhost", "localhost.localdomain", "local", "broadcasthost", "ip6-localhost", "ip6-loopback",
+		"ip6-localnet", "ip6-mcastprefix", "ip6-allnodes", "ip6-allrouters", "ip6-allhosts", "0.0.0.0":
+		return true
+	}
+	_, err := netip.ParseAddr(name)
+	return err == nil
+}
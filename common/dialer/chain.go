@@ -0,0 +1,59 @@
+package dialer
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type chainKey struct{}
+
+// ContextWithChain sets the outbound chain of a connection.
+// The last hop is dialed by the next outbound dialer seen, through the rest of the chain.
+func ContextWithChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, (*chainKey)(nil), chain)
+}
+
+func ChainFromContext(ctx context.Context) []string {
+	chain, _ := ctx.Value((*chainKey)(nil)).([]string)
+	return chain
+}
+
+type ChainDialer struct {
+	router adapter.Router
+	dialer N.Dialer
+}
+
+func NewChain(router adapter.Router, dialer N.Dialer) N.Dialer {
+	return &ChainDialer{router: router, dialer: dialer}
+}
+
+func (d *ChainDialer) next(ctx context.Context) (context.Context, N.Dialer) {
+	chain := ChainFromContext(ctx)
+	if len(chain) == 0 {
+		return ctx, d.dialer
+	}
+	hop := chain[len(chain)-1]
+	chain = chain[:len(chain)-1]
+	if len(chain) == 0 {
+		chain = nil
+	}
+	return ContextWithChain(ctx, chain), NewDetour(d.router, hop)
+}
+
+func (d *ChainDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	ctx, dialer := d.next(ctx)
+	return dialer.DialContext(ctx, network, destination)
+}
+
+func (d *ChainDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	ctx, dialer := d.next(ctx)
+	return dialer.ListenPacket(ctx, destination)
+}
+
+func (d *ChainDialer) Upstream() any {
+	return d.dialer
+}
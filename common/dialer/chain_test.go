@@ -0,0 +1,84 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-dns"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRouter struct {
+	adapter.Router
+	outbounds     map[string]adapter.Outbound
+	dnsTransports map[string]dns.Transport
+}
+
+func (r *testRouter) Outbound(tag string) (adapter.Outbound, bool) {
+	outbound, loaded := r.outbounds[tag]
+	return outbound, loaded
+}
+
+func (r *testRouter) DNSTransport(tag string) (dns.Transport, bool) {
+	transport, loaded := r.dnsTransports[tag]
+	return transport, loaded
+}
+
+// testOutbound records the chain left when it is dialed.
+type testOutbound struct {
+	adapter.Outbound
+	chain chan []string
+}
+
+func (o *testOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	o.chain <- ChainFromContext(ctx)
+	client, _ := net.Pipe()
+	return client, nil
+}
+
+// testDialer is the dialer of the outbound the chain applies to.
+type testDialer struct {
+	N.Dialer
+	dialed bool
+}
+
+func (d *testDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	d.dialed = true
+	client, _ := net.Pipe()
+	return client, nil
+}
+
+func TestChainDialer(t *testing.T) {
+	t.Parallel()
+	first := &testOutbound{chain: make(chan []string, 1)}
+	second := &testOutbound{chain: make(chan []string, 1)}
+	router := &testRouter{outbounds: map[string]adapter.Outbound{
+		"first":  first,
+		"second": second,
+	}}
+	destination := M.ParseSocksaddr("example.com:443")
+
+	upstream := &testDialer{}
+	_, err := NewChain(router, upstream).DialContext(context.Background(), N.NetworkTCP, destination)
+	require.NoError(t, err)
+	require.True(t, upstream.dialed)
+
+	upstream = &testDialer{}
+	ctx := ContextWithChain(context.Background(), []string{"first", "second"})
+	_, err = NewChain(router, upstream).DialContext(ctx, N.NetworkTCP, destination)
+	require.NoError(t, err)
+	require.False(t, upstream.dialed)
+	require.Equal(t, []string{"first"}, <-second.chain)
+
+	upstream = &testDialer{}
+	ctx = ContextWithChain(context.Background(), []string{"first"})
+	_, err = NewChain(router, upstream).DialContext(ctx, N.NetworkTCP, destination)
+	require.NoError(t, err)
+	require.False(t, upstream.dialed)
+	require.Nil(t, <-first.chain)
+}
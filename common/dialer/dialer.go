@@ -7,6 +7,7 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-dns"
 	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
 	N "github.com/sagernet/sing/common/network"
 )
 
@@ -28,13 +29,32 @@ func New(router adapter.Router, options option.DialerOptions) (N.Dialer, error)
 		dialer = NewDetour(router, options.Detour)
 	}
 	domainStrategy := dns.DomainStrategy(options.DomainStrategy)
-	if domainStrategy != dns.DomainStrategyAsIS || options.Detour == "" {
+	var resolver *adapter.DNSQueryOptions
+	if options.DomainResolver != nil {
+		if options.DomainResolver.Server == "" {
+			return nil, E.New("missing domain resolver server")
+		}
+		// dialers are created after the DNS servers, so an unknown server is rejected here instead of on every connection
+		if _, loaded := router.DNSTransport(options.DomainResolver.Server); !loaded {
+			return nil, E.New("domain resolver server not found: ", options.DomainResolver.Server)
+		}
+		if options.DomainResolver.Strategy != option.DomainStrategy(dns.DomainStrategyAsIS) {
+			domainStrategy = dns.DomainStrategy(options.DomainResolver.Strategy)
+		}
+		resolver = &adapter.DNSQueryOptions{
+			Transport:    options.DomainResolver.Server,
+			Strategy:     domainStrategy,
+			DisableCache: options.DomainResolver.DisableCache,
+		}
+	}
+	if domainStrategy != dns.DomainStrategyAsIS || options.Detour == "" || resolver != nil {
 		dialer = NewResolveDialer(
 			router,
 			dialer,
 			options.Detour == "" && !options.TCPFastOpen,
 			domainStrategy,
+			resolver,
 			time.Duration(options.FallbackDelay))
 	}
-	return dialer, nil
+	return NewChain(router, dialer), nil
 }
@@ -0,0 +1,33 @@
+package dialer
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDomainResolver(t *testing.T) {
+	t.Parallel()
+	router := &testRouter{dnsTransports: map[string]dns.Transport{
+		"local": dns.NewLocalTransport("local", N.SystemDialer),
+	}}
+	_, err := New(router, option.DialerOptions{
+		Detour:         "proxy",
+		DomainResolver: &option.DomainResolveOptions{Server: "local"},
+	})
+	require.NoError(t, err)
+	_, err = New(router, option.DialerOptions{
+		Detour:         "proxy",
+		DomainResolver: &option.DomainResolveOptions{Server: "missing"},
+	})
+	require.ErrorContains(t, err, "domain resolver server not found: missing")
+	_, err = New(router, option.DialerOptions{
+		Detour:         "proxy",
+		DomainResolver: &option.DomainResolveOptions{},
+	})
+	require.ErrorContains(t, err, "missing domain resolver server")
+}
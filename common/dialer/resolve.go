@@ -19,19 +19,31 @@ type ResolveDialer struct {
 	parallel      bool
 	router        adapter.Router
 	strategy      dns.DomainStrategy
+	resolver      *adapter.DNSQueryOptions
 	fallbackDelay time.Duration
 }
 
-func NewResolveDialer(router adapter.Router, dialer N.Dialer, parallel bool, strategy dns.DomainStrategy, fallbackDelay time.Duration) *ResolveDialer {
+func NewResolveDialer(router adapter.Router, dialer N.Dialer, parallel bool, strategy dns.DomainStrategy, resolver *adapter.DNSQueryOptions, fallbackDelay time.Duration) *ResolveDialer {
 	return &ResolveDialer{
 		dialer,
 		parallel,
 		router,
 		strategy,
+		resolver,
 		fallbackDelay,
 	}
 }
 
+func (d *ResolveDialer) lookup(ctx context.Context, domain string) ([]netip.Addr, error) {
+	if d.resolver != nil {
+		return d.router.LookupWithOptions(ctx, domain, *d.resolver)
+	} else if d.strategy == dns.DomainStrategyAsIS {
+		return d.router.LookupDefault(ctx, domain)
+	} else {
+		return d.router.Lookup(ctx, domain, d.strategy)
+	}
+}
+
 func (d *ResolveDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	if !destination.IsFqdn() {
 		return d.dialer.DialContext(ctx, network, destination)
@@ -40,13 +52,7 @@ func (d *ResolveDialer) DialContext(ctx context.Context, network string, destina
 	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
 	metadata.Destination = destination
 	metadata.Domain = ""
-	var addresses []netip.Addr
-	var err error
-	if d.strategy == dns.DomainStrategyAsIS {
-		addresses, err = d.router.LookupDefault(ctx, destination.Fqdn)
-	} else {
-		addresses, err = d.router.Lookup(ctx, destination.Fqdn, d.strategy)
-	}
+	addresses, err := d.lookup(ctx, destination.Fqdn)
 	if err != nil {
 		return nil, err
 	}
@@ -65,13 +71,7 @@ func (d *ResolveDialer) ListenPacket(ctx context.Context, destination M.Socksadd
 	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
 	metadata.Destination = destination
 	metadata.Domain = ""
-	var addresses []netip.Addr
-	var err error
-	if d.strategy == dns.DomainStrategyAsIS {
-		addresses, err = d.router.LookupDefault(ctx, destination.Fqdn)
-	} else {
-		addresses, err = d.router.Lookup(ctx, destination.Fqdn, d.strategy)
-	}
+	addresses, err := d.lookup(ctx, destination.Fqdn)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,213 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Union describes an object whose fields depend on the value of a key, such as "type".
+// Fields of the union type itself not tagged with `json:"-"` are shared by all variants.
+type Union struct {
+	Key string
+	// Default is the variant used when the key is missing, the key is required if empty.
+	Default  string
+	Variants []Variant
+}
+
+type Variant struct {
+	Value string
+	// Options is the zero value of the variant options struct, or nil if the variant has no options.
+	Options any
+}
+
+type Reflector struct {
+	// Mapper returns the schema of types with custom JSON encoding, or nil to reflect the type.
+	Mapper func(r *Reflector, t reflect.Type) *Schema
+	Unions map[reflect.Type]Union
+
+	definitions map[string]*Schema
+	names       map[reflect.Type]string
+}
+
+func (r *Reflector) Reflect(t reflect.Type) *Schema {
+	r.definitions = make(map[string]*Schema)
+	r.names = make(map[reflect.Type]string)
+	schema := r.Type(t)
+	return &Schema{
+		Schema:      Draft07,
+		Ref:         schema.Ref,
+		Definitions: r.definitions,
+	}
+}
+
+// Type returns the schema of a type, named types are referenced from definitions.
+func (r *Reflector) Type(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if name, loaded := r.names[t]; loaded {
+		return &Schema{Ref: "#/definitions/" + name}
+	}
+	if t.Name() != "" && t.Kind() == reflect.Struct && !strings.Contains(t.Name(), "[") {
+		name := r.definitionName(t)
+		r.names[t] = name
+		r.definitions[name] = &Schema{}
+		*r.definitions[name] = *r.typeSchema(t)
+		return &Schema{Ref: "#/definitions/" + name}
+	}
+	return r.typeSchema(t)
+}
+
+func (r *Reflector) definitionName(t reflect.Type) string {
+	name := t.Name()
+	if _, loaded := r.definitions[name]; loaded {
+		packagePath := t.PkgPath()
+		name = packagePath[strings.LastIndex(packagePath, "/")+1:] + "." + name
+	}
+	return name
+}
+
+func (r *Reflector) typeSchema(t reflect.Type) *Schema {
+	if r.Mapper != nil {
+		if schema := r.Mapper(r, t); schema != nil {
+			return schema
+		}
+	}
+	if union, isUnion := r.Unions[t]; isUnion {
+		return r.union(t, union)
+	}
+	pointerType := reflect.PointerTo(t)
+	if !pointerType.Implements(jsonUnmarshalerType) && pointerType.Implements(textUnmarshalerType) {
+		return String()
+	}
+	if t.Kind() != reflect.Struct && pointerType.Implements(jsonUnmarshalerType) {
+		// unknown custom encoding
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int64:
+		return Integer()
+	case reflect.Int8:
+		return integerRange(math.MinInt8, math.MaxInt8)
+	case reflect.Int16:
+		return integerRange(math.MinInt16, math.MaxInt16)
+	case reflect.Int32:
+		return integerRange(math.MinInt32, math.MaxInt32)
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		minimum := float64(0)
+		return &Schema{Type: TypeInteger, Minimum: &minimum}
+	case reflect.Uint8:
+		return integerRange(0, math.MaxUint8)
+	case reflect.Uint16:
+		return integerRange(0, math.MaxUint16)
+	case reflect.Uint32:
+		return integerRange(0, math.MaxUint32)
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return String()
+		}
+		return ArrayOf(r.Type(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: r.Type(t.Elem())}
+	case reflect.Struct:
+		return r.Object(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// Object returns the object schema of struct fields, ignoring custom encoding of the struct itself.
+func (r *Reflector) Object(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:                 TypeObject,
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	r.fields(t, schema.Properties)
+	return schema
+}
+
+func (r *Reflector) fields(t reflect.Type, properties map[string]*Schema) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			// encoding/json matches names case-insensitively, use the lowercase form as in documents
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = r.Type(field.Type)
+	}
+	// fields of embedded structs are shadowed by outer fields
+	for _, embeddedType := range embedded {
+		embeddedProperties := make(map[string]*Schema)
+		r.fields(embeddedType, embeddedProperties)
+		for name, property := range embeddedProperties {
+			if _, loaded := properties[name]; !loaded {
+				properties[name] = property
+			}
+		}
+	}
+}
+
+func (r *Reflector) union(t reflect.Type, union Union) *Schema {
+	base := r.Object(t)
+	delete(base.Properties, union.Key)
+	schema := &Schema{}
+	for _, variant := range union.Variants {
+		variantSchema := &Schema{
+			Type:                 TypeObject,
+			Properties:           make(map[string]*Schema),
+			AdditionalProperties: false,
+		}
+		for name, property := range base.Properties {
+			variantSchema.Properties[name] = property
+		}
+		if variant.Options != nil {
+			r.fields(reflect.TypeOf(variant.Options), variantSchema.Properties)
+		}
+		if variant.Value == union.Default {
+			variantSchema.Properties[union.Key] = Enum("", variant.Value)
+		} else {
+			variantSchema.Properties[union.Key] = &Schema{Const: variant.Value}
+			variantSchema.Required = []string{union.Key}
+		}
+		schema.OneOf = append(schema.OneOf, variantSchema)
+	}
+	return schema
+}
+
+func integerRange(minimum float64, maximum float64) *Schema {
+	return &Schema{Type: TypeInteger, Minimum: &minimum, Maximum: &maximum}
+}
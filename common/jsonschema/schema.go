@@ -0,0 +1,68 @@
+// Package jsonschema generates JSON Schema (draft-07) documents from Go types and validates JSON values against them.
+package jsonschema
+
+const Draft07 = "http://json-schema.org/draft-07/schema#"
+
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// AnyOf returns a schema matching one of the given forms, such as a single value or a list of values.
+func AnyOf(schemas ...*Schema) *Schema {
+	return &Schema{AnyOf: schemas}
+}
+
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: TypeArray, Items: items}
+}
+
+func Enum(values ...any) *Schema {
+	return &Schema{Enum: values}
+}
+
+func String() *Schema {
+	return &Schema{Type: TypeString}
+}
+
+func Integer() *Schema {
+	return &Schema{Type: TypeInteger}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: TypeBoolean}
+}
+
+func (s *Schema) additionalPropertiesSchema() (*Schema, bool) {
+	switch additionalProperties := s.AdditionalProperties.(type) {
+	case *Schema:
+		return additionalProperties, true
+	case bool:
+		return nil, additionalProperties
+	default:
+		return nil, true
+	}
+}
@@ -0,0 +1,102 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOptions struct {
+	Port     uint16       `json:"port,omitempty"`
+	Servers  []testServer `json:"servers,omitempty"`
+	Ignored  string       `json:"-"`
+	Metadata map[string]string
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Detour string `json:"detour,omitempty"`
+}
+
+type _testServer struct {
+	Type        string           `json:"type"`
+	Tag         string           `json:"tag,omitempty"`
+	HTTPOptions testHTTPOptions  `json:"-"`
+	Rules       []testServerRule `json:"rules,omitempty"`
+}
+
+type testServer _testServer
+
+type testHTTPOptions struct {
+	Path string `json:"path,omitempty"`
+}
+
+type testServerRule struct {
+	Domain []string `json:"domain,omitempty"`
+	Rules  []testServerRule
+}
+
+func testSchema() *Schema {
+	reflector := &Reflector{
+		Unions: map[reflect.Type]Union{
+			reflect.TypeOf(testServer{}): {
+				Key: "type",
+				Variants: []Variant{
+					{Value: "http", Options: testHTTPOptions{}},
+					{Value: "block"},
+				},
+			},
+		},
+	}
+	return reflector.Reflect(reflect.TypeOf(testOptions{}))
+}
+
+func validateContent(t *testing.T, content string) []string {
+	var value any
+	require.NoError(t, json.Unmarshal([]byte(content), &value))
+	var messages []string
+	for _, err := range Validate(testSchema(), value) {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func TestReflect(t *testing.T) {
+	t.Parallel()
+	schema := testSchema()
+	require.Equal(t, "#/definitions/testOptions", schema.Ref)
+	options := schema.Definitions["testOptions"]
+	require.Contains(t, options.Properties, "detour")
+	require.Contains(t, options.Properties, "metadata")
+	require.NotContains(t, options.Properties, "Ignored")
+	server := schema.Definitions["testServer"]
+	require.Len(t, server.OneOf, 2)
+	require.Contains(t, server.OneOf[0].Properties, "path")
+	require.Contains(t, server.OneOf[0].Properties, "tag")
+	require.NotContains(t, server.OneOf[1].Properties, "path")
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	require.Empty(t, validateContent(t, `{"port": 80, "detour": "d", "Metadata": {"a": "b"}, "servers": [{"type": "http", "path": "/", "rules": [{"Rules": [{"domain": ["x"]}]}]}, {"type": "block", "tag": null}]}`))
+	require.Equal(t, []string{
+		"/port: value 65536 is greater than 65535",
+		"/servers/0/path: unknown field",
+		"/servers/1/rules/0/Rules/0/domain/0: expected string, got integer",
+		"/servers/2/type: unknown type \"tcp\", expected one of: \"http\", \"block\"",
+		"/servers/3: missing type",
+		"/servers~1all: unknown field",
+	}, validateContent(t, `{"port": 65536, "servers": [{"type": "block", "path": "/"}, {"type": "http", "rules": [{"Rules": [{"domain": [1]}]}]}, {"type": "tcp"}, {}], "servers/all": 1}`))
+}
+
+func TestValidateCaseInsensitive(t *testing.T) {
+	t.Parallel()
+	require.Empty(t, validateContent(t, `{"Port": 80, "DETOUR": "d", "metadata": {}, "servers": [{"Type": "http", "Path": "/"}, {"type": "block", "RULES": [{"rules": []}]}]}`))
+	require.Equal(t, []string{
+		"/servers/0/Path: unknown field",
+	}, validateContent(t, `{"servers": [{"TYPE": "block", "Path": "/"}]}`))
+}
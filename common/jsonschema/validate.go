@@ -0,0 +1,310 @@
+package jsonschema
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	F "github.com/sagernet/sing/common/format"
+)
+
+type ValidationError struct {
+	// Path is the JSON pointer (RFC 6901) of the invalid value.
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// Validate checks a value decoded by encoding/json against the root schema.
+// Only keywords generated by Reflector are supported. null is accepted everywhere,
+// and object keys match properties case-insensitively, as encoding/json does.
+func Validate(root *Schema, value any) []*ValidationError {
+	v := &validator{root: root}
+	v.validate(root, "", value)
+	return v.errors
+}
+
+type validator struct {
+	root   *Schema
+	errors []*ValidationError
+}
+
+func (v *validator) report(path string, message ...any) {
+	v.errors = append(v.errors, &ValidationError{Path: path, Message: F.ToString(message...)})
+}
+
+func (v *validator) resolve(schema *Schema) *Schema {
+	for schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/definitions/")
+		definition, loaded := v.root.Definitions[name]
+		if !loaded {
+			return &Schema{}
+		}
+		schema = definition
+	}
+	return schema
+}
+
+// check validates without reporting.
+func (v *validator) check(schema *Schema, value any) []*ValidationError {
+	inner := &validator{root: v.root}
+	inner.validate(schema, "", value)
+	return inner.errors
+}
+
+func (v *validator) validate(schema *Schema, path string, value any) {
+	schema = v.resolve(schema)
+	if value == nil {
+		return
+	}
+	if len(schema.OneOf) > 0 {
+		v.validateUnion(schema, path, value)
+		return
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateAnyOf(schema, path, value)
+		return
+	}
+	if schema.Type != "" && !matchType(schema.Type, value) {
+		v.report(path, "expected ", schema.Type, ", got ", typeName(value))
+		return
+	}
+	if schema.Const != nil && schema.Const != value {
+		v.report(path, "expected ", formatValue(schema.Const), ", got ", formatValue(value))
+		return
+	}
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		v.report(path, "unknown value ", formatValue(value), ", expected one of: ", formatEnum(schema.Enum))
+		return
+	}
+	switch typedValue := value.(type) {
+	case float64:
+		if schema.Minimum != nil && typedValue < *schema.Minimum {
+			v.report(path, "value ", formatValue(value), " is less than ", formatValue(*schema.Minimum))
+		}
+		if schema.Maximum != nil && typedValue > *schema.Maximum {
+			v.report(path, "value ", formatValue(value), " is greater than ", formatValue(*schema.Maximum))
+		}
+	case []any:
+		if schema.Items != nil {
+			for index, item := range typedValue {
+				v.validate(schema.Items, path+"/"+strconv.Itoa(index), item)
+			}
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, loaded := lookupValue(typedValue, name); !loaded {
+				v.report(path, "missing required field: ", name)
+			}
+		}
+		additionalProperties, allowAdditional := schema.additionalPropertiesSchema()
+		for _, name := range sortedKeys(typedValue) {
+			fieldPath := path + "/" + escapePointer(name)
+			if property, loaded := lookupProperty(schema.Properties, name); loaded {
+				v.validate(property, fieldPath, typedValue[name])
+			} else if !allowAdditional {
+				v.report(fieldPath, "unknown field")
+			} else if additionalProperties != nil {
+				v.validate(additionalProperties, fieldPath, typedValue[name])
+			}
+		}
+	}
+}
+
+func (v *validator) validateAnyOf(schema *Schema, path string, value any) {
+	var matched []*Schema
+	for _, candidate := range schema.AnyOf {
+		if len(v.check(candidate, value)) == 0 {
+			return
+		}
+		resolved := v.resolve(candidate)
+		if resolved.Type == "" || matchType(resolved.Type, value) {
+			matched = append(matched, candidate)
+		}
+	}
+	if len(matched) == 1 {
+		v.validate(matched[0], path, value)
+		return
+	}
+	var forms []string
+	for _, candidate := range schema.AnyOf {
+		resolved := v.resolve(candidate)
+		if resolved.Type != "" {
+			forms = append(forms, resolved.Type)
+		}
+	}
+	v.report(path, "unexpected ", typeName(value), ", expected ", strings.Join(forms, " or "))
+}
+
+// validateUnion selects the variant by its discriminator key, so that errors are reported inside the variant.
+func (v *validator) validateUnion(schema *Schema, path string, value any) {
+	object, isObject := value.(map[string]any)
+	key := unionKey(schema)
+	if !isObject || key == "" {
+		for _, candidate := range schema.OneOf {
+			if len(v.check(candidate, value)) == 0 {
+				return
+			}
+		}
+		v.report(path, "value does not match any allowed form")
+		return
+	}
+	keyValue, hasKey := lookupValue(object, key)
+	var values []any
+	for _, candidate := range schema.OneOf {
+		candidate = v.resolve(candidate)
+		property, loaded := candidate.Properties[key]
+		if !loaded {
+			continue
+		}
+		property = v.resolve(property)
+		if property.Const != nil {
+			values = append(values, property.Const)
+		} else if len(property.Enum) > 0 {
+			values = append(values, property.Enum[len(property.Enum)-1])
+		}
+		if !hasKey && !containsString(candidate.Required, key) || hasKey && (property.Const == keyValue || containsValue(property.Enum, keyValue)) {
+			v.validate(candidate, path, value)
+			return
+		}
+	}
+	if !hasKey {
+		v.report(path, "missing ", key)
+	} else {
+		v.report(path+"/"+escapePointer(key), "unknown ", key, " ", formatValue(keyValue), ", expected one of: ", formatEnum(values))
+	}
+}
+
+// lookupProperty prefers an exact match to a case-insensitive one, like encoding/json.
+func lookupProperty(properties map[string]*Schema, key string) (*Schema, bool) {
+	if property, loaded := properties[key]; loaded {
+		return property, true
+	}
+	for name, property := range properties {
+		if strings.EqualFold(name, key) {
+			return property, true
+		}
+	}
+	return nil, false
+}
+
+func lookupValue(object map[string]any, name string) (any, bool) {
+	if value, loaded := object[name]; loaded {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func unionKey(schema *Schema) string {
+	for _, candidate := range schema.OneOf {
+		for name, property := range candidate.Properties {
+			if property.Const != nil {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchType(schemaType string, value any) bool {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		return schemaType == TypeObject
+	case []any:
+		return schemaType == TypeArray
+	case string:
+		return schemaType == TypeString
+	case bool:
+		return schemaType == TypeBoolean
+	case float64:
+		return schemaType == TypeNumber || schemaType == TypeInteger && typedValue == math.Trunc(typedValue)
+	default:
+		return false
+	}
+}
+
+func typeName(value any) string {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		return TypeObject
+	case []any:
+		return TypeArray
+	case string:
+		return TypeString
+	case bool:
+		return TypeBoolean
+	case float64:
+		if typedValue == math.Trunc(typedValue) {
+			return TypeInteger
+		}
+		return TypeNumber
+	default:
+		return "null"
+	}
+}
+
+func containsValue(values []any, value any) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value any) string {
+	switch typedValue := value.(type) {
+	case string:
+		return strconv.Quote(typedValue)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	default:
+		return F.ToString(value)
+	}
+}
+
+func formatEnum(values []any) string {
+	var formatted []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		formatted = append(formatted, formatValue(value))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,79 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+type leaseTable struct {
+	byAddr         map[netip.Addr]string
+	byHardwareAddr map[string]string
+}
+
+func newLeaseTable() *leaseTable {
+	return &leaseTable{
+		byAddr:         make(map[netip.Addr]string),
+		byHardwareAddr: make(map[string]string),
+	}
+}
+
+func (t *leaseTable) add(addrString string, hardwareAddrString string, hostname string) {
+	if hostname == "" || hostname == "*" {
+		return
+	}
+	if addr, err := netip.ParseAddr(addrString); err == nil {
+		t.byAddr[addr.Unmap()] = hostname
+	}
+	if hardwareAddr, err := net.ParseMAC(hardwareAddrString); err == nil {
+		t.byHardwareAddr[hardwareAddr.String()] = hostname
+	}
+}
+
+// parse reads leases written by dnsmasq (also used by OpenWrt) or ISC dhcpd.
+func (t *leaseTable) parse(content string) {
+	if strings.Contains(content, "lease ") && strings.Contains(content, "{") {
+		t.parseISC(content)
+	} else {
+		t.parseDnsmasq(content)
+	}
+}
+
+// parseDnsmasq reads lines of `<expiry> <mac> <ip> <hostname> <client id>`.
+func (t *leaseTable) parseDnsmasq(content string) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		t.add(fields[2], fields[1], fields[3])
+	}
+}
+
+// parseISC reads `lease <ip> { ... }` blocks, later blocks override earlier ones as dhcpd appends to the file.
+func (t *leaseTable) parseISC(content string) {
+	var (
+		inLease      bool
+		addr         string
+		hardwareAddr string
+		hostname     string
+	)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "lease ") && strings.HasSuffix(line, "{"):
+			inLease = true
+			addr = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "lease "), "{"))
+			hardwareAddr = ""
+			hostname = ""
+		case !inLease:
+		case line == "}":
+			inLease = false
+			t.add(addr, hardwareAddr, hostname)
+		case strings.HasPrefix(line, "hardware ethernet "):
+			hardwareAddr = strings.TrimSuffix(strings.TrimPrefix(line, "hardware ethernet "), ";")
+		case strings.HasPrefix(line, "client-hostname "):
+			hostname = strings.Trim(strings.TrimSuffix(strings.TrimPrefix(line, "client-hostname "), ";"), "\"")
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package neighbor
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseDnsmasq(t *testing.T) {
+	t.Parallel()
+	leases := newLeaseTable()
+	leases.parse(`1700000000 aa:bb:cc:dd:ee:01 192.168.1.10 iPhone 01:aa:bb:cc:dd:ee:01
+1700000000 aa:bb:cc:dd:ee:02 192.168.1.11 * *
+`)
+	require.Equal(t, "iPhone", leases.byAddr[netip.MustParseAddr("192.168.1.10")])
+	require.Equal(t, "iPhone", leases.byHardwareAddr["aa:bb:cc:dd:ee:01"])
+	require.NotContains(t, leases.byAddr, netip.MustParseAddr("192.168.1.11"))
+}
+
+func TestLeaseISC(t *testing.T) {
+	t.Parallel()
+	leases := newLeaseTable()
+	leases.parse(`# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.20 {
+  starts 4 2023/12/14 08:00:00;
+  hardware ethernet AA:BB:CC:DD:EE:03;
+  client-hostname "nas";
+}
+`)
+	require.Equal(t, "nas", leases.byAddr[netip.MustParseAddr("192.168.1.20")])
+	require.Equal(t, "nas", leases.byHardwareAddr["aa:bb:cc:dd:ee:03"])
+}
@@ -0,0 +1,27 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/sagernet/netlink"
+)
+
+func readNeighbors() (map[netip.Addr]net.HardwareAddr, error) {
+	neighborList, err := netlink.NeighList(0, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, err
+	}
+	neighbors := make(map[netip.Addr]net.HardwareAddr)
+	for _, neighbor := range neighborList {
+		if neighbor.State&(netlink.NUD_INCOMPLETE|netlink.NUD_FAILED|netlink.NUD_NOARP) != 0 || len(neighbor.HardwareAddr) == 0 {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(neighbor.IP)
+		if !ok {
+			continue
+		}
+		neighbors[addr.Unmap()] = neighbor.HardwareAddr
+	}
+	return neighbors, nil
+}
@@ -0,0 +1,299 @@
+package neighbor
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	updateInterval      = 5 * time.Second
+	missUpdateInterval  = time.Second
+	hostnameCacheTTL    = 10 * time.Minute
+	hostnameNegativeTTL = time.Minute
+	// hostnameLookupTimeout is how long a connection waits for the reverse DNS lookup,
+	// a slower lookup goes on in the background and its result is cached for later connections.
+	hostnameLookupTimeout = 200 * time.Millisecond
+)
+
+var (
+	ErrNotFound          = E.New("neighbor not found")
+	ErrInterfaceNotFound = E.New("interface not found")
+)
+
+// DefaultLeaseFiles are read when no lease file is configured, missing files are ignored.
+var DefaultLeaseFiles = []string{
+	"/tmp/dhcp.leases",
+	"/var/lib/misc/dnsmasq.leases",
+	"/var/lib/dhcp/dhcpd.leases",
+}
+
+type PTRLookupFunc = func(ctx context.Context, addr netip.Addr) (string, error)
+
+type Resolver struct {
+	leaseFiles []string
+	lookupPTR  PTRLookupFunc
+
+	access              sync.Mutex
+	neighbors           map[netip.Addr]net.HardwareAddr
+	neighborsUpdatedAt  time.Time
+	interfaces          []interfaceAddresses
+	interfacesUpdatedAt time.Time
+	leases              *leaseTable
+	leasesUpdatedAt     time.Time
+	leaseModTimes       map[string]time.Time
+	hostnames           map[netip.Addr]hostnameCacheEntry
+	hostnameLookups     map[netip.Addr]*hostnameLookup
+}
+
+type interfaceAddresses struct {
+	name     string
+	prefixes []netip.Prefix
+}
+
+type hostnameCacheEntry struct {
+	hostname string
+	expire   time.Time
+}
+
+type hostnameLookup struct {
+	done     chan struct{}
+	hostname string
+	err      error
+}
+
+// NewResolver creates a resolver of LAN clients,
+// the reverse DNS lookup is used for hostnames not found in DHCP leases if lookupPTR is not nil.
+func NewResolver(leaseFiles []string, lookupPTR PTRLookupFunc) *Resolver {
+	return &Resolver{
+		leaseFiles:      leaseFiles,
+		lookupPTR:       lookupPTR,
+		leaseModTimes:   make(map[string]time.Time),
+		hostnames:       make(map[netip.Addr]hostnameCacheEntry),
+		hostnameLookups: make(map[netip.Addr]*hostnameLookup),
+	}
+}
+
+func (r *Resolver) LookupHardwareAddr(addr netip.Addr) (net.HardwareAddr, error) {
+	addr = addr.Unmap()
+	r.access.Lock()
+	defer r.access.Unlock()
+	if time.Since(r.neighborsUpdatedAt) > updateInterval {
+		err := r.updateNeighbors()
+		if err != nil {
+			return nil, err
+		}
+	}
+	hardwareAddr, loaded := r.neighbors[addr]
+	if !loaded && time.Since(r.neighborsUpdatedAt) > missUpdateInterval {
+		err := r.updateNeighbors()
+		if err != nil {
+			return nil, err
+		}
+		hardwareAddr, loaded = r.neighbors[addr]
+	}
+	if !loaded {
+		return nil, ErrNotFound
+	}
+	return hardwareAddr, nil
+}
+
+func (r *Resolver) updateNeighbors() error {
+	neighbors, err := readNeighbors()
+	if err != nil {
+		return E.Cause(err, "read neighbors")
+	}
+	r.neighbors = neighbors
+	r.neighborsUpdatedAt = time.Now()
+	return nil
+}
+
+// LookupHostname finds the hostname by DHCP leases, then by the reverse DNS lookup.
+func (r *Resolver) LookupHostname(ctx context.Context, addr netip.Addr, hardwareAddr net.HardwareAddr) (string, error) {
+	addr = addr.Unmap()
+	r.access.Lock()
+	if time.Since(r.leasesUpdatedAt) > updateInterval {
+		r.updateLeases()
+	}
+	var hostname string
+	if r.leases != nil {
+		if hardwareAddr != nil {
+			hostname = r.leases.byHardwareAddr[hardwareAddr.String()]
+		}
+		if hostname == "" {
+			hostname = r.leases.byAddr[addr]
+		}
+	}
+	if hostname != "" {
+		r.access.Unlock()
+		return hostname, nil
+	}
+	cacheEntry, cached := r.hostnames[addr]
+	if cached && time.Now().Before(cacheEntry.expire) {
+		r.access.Unlock()
+		if cacheEntry.hostname == "" {
+			return "", ErrNotFound
+		}
+		return cacheEntry.hostname, nil
+	}
+	if r.lookupPTR == nil {
+		r.access.Unlock()
+		return "", ErrNotFound
+	}
+	lookup, loaded := r.hostnameLookups[addr]
+	if !loaded {
+		lookup = &hostnameLookup{done: make(chan struct{})}
+		r.hostnameLookups[addr] = lookup
+		go r.lookupHostname(addr, lookup)
+	}
+	r.access.Unlock()
+	timer := time.NewTimer(hostnameLookupTimeout)
+	defer timer.Stop()
+	select {
+	case <-lookup.done:
+	case <-timer.C:
+		return "", E.New("reverse lookup timed out, continuing in background")
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if lookup.err != nil {
+		return "", E.Cause(lookup.err, "reverse lookup")
+	}
+	if lookup.hostname == "" {
+		return "", ErrNotFound
+	}
+	return lookup.hostname, nil
+}
+
+// lookupHostname runs a reverse DNS lookup shared by all connections from the address.
+func (r *Resolver) lookupHostname(addr netip.Addr, lookup *hostnameLookup) {
+	hostname, err := r.lookupPTR(context.Background(), addr)
+	hostname = strings.TrimSuffix(hostname, ".")
+	r.access.Lock()
+	if hostname != "" {
+		r.hostnames[addr] = hostnameCacheEntry{hostname, time.Now().Add(hostnameCacheTTL)}
+	} else {
+		r.hostnames[addr] = hostnameCacheEntry{"", time.Now().Add(hostnameNegativeTTL)}
+	}
+	delete(r.hostnameLookups, addr)
+	r.access.Unlock()
+	lookup.hostname = hostname
+	lookup.err = err
+	close(lookup.done)
+}
+
+func (r *Resolver) updateLeases() {
+	r.leasesUpdatedAt = time.Now()
+	leaseFiles := r.leaseFiles
+	if len(leaseFiles) == 0 {
+		leaseFiles = DefaultLeaseFiles
+	}
+	var changed bool
+	for _, path := range leaseFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			if _, loaded := r.leaseModTimes[path]; loaded {
+				delete(r.leaseModTimes, path)
+				changed = true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(r.leaseModTimes[path]) {
+			r.leaseModTimes[path] = info.ModTime()
+			changed = true
+		}
+	}
+	if !changed && r.leases != nil {
+		return
+	}
+	leases := newLeaseTable()
+	for _, path := range leaseFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		leases.parse(string(content))
+	}
+	r.leases = leases
+}
+
+// LookupInterface finds the name of the interface on the same network with the address.
+func (r *Resolver) LookupInterface(addr netip.Addr) (string, error) {
+	addr = addr.Unmap()
+	r.access.Lock()
+	defer r.access.Unlock()
+	if time.Since(r.interfacesUpdatedAt) > updateInterval {
+		err := r.updateInterfaces()
+		if err != nil {
+			return "", err
+		}
+	}
+	interfaceName := r.findInterface(addr)
+	if interfaceName == "" && time.Since(r.interfacesUpdatedAt) > missUpdateInterval {
+		err := r.updateInterfaces()
+		if err != nil {
+			return "", err
+		}
+		interfaceName = r.findInterface(addr)
+	}
+	if interfaceName == "" {
+		return "", ErrInterfaceNotFound
+	}
+	return interfaceName, nil
+}
+
+func (r *Resolver) findInterface(addr netip.Addr) string {
+	var (
+		interfaceName string
+		bestBits      = -1
+	)
+	for _, netInterface := range r.interfaces {
+		for _, prefix := range netInterface.prefixes {
+			if prefix.Contains(addr) && prefix.Bits() > bestBits {
+				interfaceName = netInterface.name
+				bestBits = prefix.Bits()
+			}
+		}
+	}
+	return interfaceName
+}
+
+func (r *Resolver) updateInterfaces() error {
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return E.Cause(err, "read interfaces")
+	}
+	interfaces := make([]interfaceAddresses, 0, len(netInterfaces))
+	for _, netInterface := range netInterfaces {
+		if netInterface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := netInterface.Addrs()
+		if err != nil {
+			continue
+		}
+		var prefixes []netip.Prefix
+		for _, addr := range addrs {
+			ipNet, isIPNet := addr.(*net.IPNet)
+			if !isIPNet {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipNet.IP)
+			if !ok {
+				continue
+			}
+			bits, _ := ipNet.Mask.Size()
+			prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), bits).Masked())
+		}
+		interfaces = append(interfaces, interfaceAddresses{netInterface.Name, prefixes})
+	}
+	r.interfaces = interfaces
+	r.interfacesUpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,56 @@
+package neighbor
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupHostnamePTR(t *testing.T) {
+	t.Parallel()
+	var lookups atomic.Int32
+	resolver := NewResolver([]string{filepath.Join(t.TempDir(), "dhcp.leases")}, func(ctx context.Context, addr netip.Addr) (string, error) {
+		lookups.Add(1)
+		if addr == netip.MustParseAddr("192.168.1.10") {
+			return "nas.lan.", nil
+		}
+		return "", nil
+	})
+	for i := 0; i < 2; i++ {
+		hostname, err := resolver.LookupHostname(context.Background(), netip.MustParseAddr("192.168.1.10"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "nas.lan", hostname)
+		_, err = resolver.LookupHostname(context.Background(), netip.MustParseAddr("192.168.1.11"), nil)
+		require.ErrorIs(t, err, ErrNotFound)
+	}
+	require.Equal(t, int32(2), lookups.Load())
+}
+
+func TestLookupHostnameSlowPTR(t *testing.T) {
+	t.Parallel()
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	resolver := NewResolver([]string{filepath.Join(t.TempDir(), "dhcp.leases")}, func(ctx context.Context, addr netip.Addr) (string, error) {
+		lookups.Add(1)
+		<-release
+		return "nas.lan.", nil
+	})
+	addr := netip.MustParseAddr("192.168.1.10")
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		_, err := resolver.LookupHostname(context.Background(), addr, nil)
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 5*hostnameLookupTimeout)
+	}
+	close(release)
+	require.Eventually(t, func() bool {
+		hostname, err := resolver.LookupHostname(context.Background(), addr, nil)
+		return err == nil && hostname == "nas.lan"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(1), lookups.Load())
+}
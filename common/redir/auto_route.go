@@ -0,0 +1,186 @@
+package redir
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const (
+	DefaultAutoRouteTableIndex = 2023
+	DefaultAutoRouteRuleIndex  = 8999
+)
+
+var (
+	inet4BypassAddress = []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+	}
+	inet6BypassAddress = []string{
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	}
+)
+
+type AutoRouteOptions struct {
+	Tag            string
+	TProxy         bool
+	Network        []string
+	Address        netip.Addr
+	Port           uint16
+	Inet6          bool
+	DefaultMark    uint32
+	TableIndex     int
+	RuleIndex      int
+	ExcludeAddress []netip.Prefix
+}
+
+func (o AutoRouteOptions) tableName() string {
+	var builder strings.Builder
+	builder.WriteString("sing_box")
+	if o.Tag != "" {
+		builder.WriteString("_")
+		for _, r := range o.Tag {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				builder.WriteRune(r)
+			} else {
+				builder.WriteRune('_')
+			}
+		}
+	} else if o.TProxy {
+		builder.WriteString("_tproxy")
+	} else {
+		builder.WriteString("_redirect")
+	}
+	return builder.String()
+}
+
+func (o AutoRouteOptions) tableIndex() int {
+	if o.TableIndex == 0 {
+		return DefaultAutoRouteTableIndex
+	}
+	return o.TableIndex
+}
+
+func (o AutoRouteOptions) ruleIndex() int {
+	if o.RuleIndex == 0 {
+		return DefaultAutoRouteRuleIndex
+	}
+	return o.RuleIndex
+}
+
+// The packet mark used to route local traffic into the tproxy listener, same as the route table index.
+func (o AutoRouteOptions) mark() uint32 {
+	return uint32(o.tableIndex())
+}
+
+func (o AutoRouteOptions) deleteScript() string {
+	tableName := o.tableName()
+	return F.ToString("table inet ", tableName, "\ndelete table inet ", tableName, "\n")
+}
+
+func (o AutoRouteOptions) script() string {
+	var builder strings.Builder
+	builder.WriteString(o.deleteScript())
+	builder.WriteString(F.ToString("table inet ", o.tableName(), " {\n"))
+	inet4Bypass := append([]string{}, inet4BypassAddress...)
+	inet6Bypass := append([]string{}, inet6BypassAddress...)
+	for _, prefix := range o.ExcludeAddress {
+		if prefix.Addr().Is4() {
+			inet4Bypass = append(inet4Bypass, prefix.String())
+		} else {
+			inet6Bypass = append(inet6Bypass, prefix.String())
+		}
+	}
+	writeSet(&builder, "inet4_bypass", "ipv4_addr", inet4Bypass)
+	if o.Inet6 {
+		writeSet(&builder, "inet6_bypass", "ipv6_addr", inet6Bypass)
+	}
+	var l4proto string
+	if common.Contains(o.Network, N.NetworkTCP) && common.Contains(o.Network, N.NetworkUDP) {
+		l4proto = "{ tcp, udp }"
+	} else if common.Contains(o.Network, N.NetworkUDP) {
+		l4proto = "udp"
+	} else {
+		l4proto = "tcp"
+	}
+	if o.TProxy {
+		builder.WriteString("\tchain prerouting {\n")
+		builder.WriteString("\t\ttype filter hook prerouting priority -150; policy accept;\n")
+		o.writeBypass(&builder)
+		builder.WriteString(F.ToString("\t\tmeta nfproto ipv4 meta l4proto ", l4proto, " tproxy ip to ", o.target(true), " meta mark set ", o.mark(), " accept\n"))
+		if o.Inet6 {
+			builder.WriteString(F.ToString("\t\tmeta nfproto ipv6 meta l4proto ", l4proto, " tproxy ip6 to ", o.target(false), " meta mark set ", o.mark(), " accept\n"))
+		}
+		builder.WriteString("\t}\n")
+		if o.DefaultMark != 0 {
+			builder.WriteString("\tchain output {\n")
+			builder.WriteString("\t\ttype route hook output priority -150; policy accept;\n")
+			builder.WriteString(F.ToString("\t\tmeta mark ", o.DefaultMark, " return\n"))
+			o.writeBypass(&builder)
+			builder.WriteString(F.ToString("\t\tmeta l4proto ", l4proto, " meta mark set ", o.mark(), "\n"))
+			builder.WriteString("\t}\n")
+		}
+	} else {
+		builder.WriteString("\tchain prerouting {\n")
+		builder.WriteString("\t\ttype nat hook prerouting priority -100; policy accept;\n")
+		o.writeBypass(&builder)
+		builder.WriteString(F.ToString("\t\tmeta l4proto tcp redirect to :", o.Port, "\n"))
+		builder.WriteString("\t}\n")
+		if o.DefaultMark != 0 {
+			builder.WriteString("\tchain output {\n")
+			builder.WriteString("\t\ttype nat hook output priority -100; policy accept;\n")
+			builder.WriteString(F.ToString("\t\tmeta mark ", o.DefaultMark, " return\n"))
+			o.writeBypass(&builder)
+			builder.WriteString(F.ToString("\t\tmeta l4proto tcp redirect to :", o.Port, "\n"))
+			builder.WriteString("\t}\n")
+		}
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+func (o AutoRouteOptions) target(inet4 bool) string {
+	if inet4 && o.Address.Is4() || !inet4 && o.Address.Is6() && !o.Address.Is4In6() {
+		if !o.Address.IsUnspecified() {
+			if inet4 {
+				return F.ToString(o.Address, ":", o.Port)
+			} else {
+				return F.ToString("[", o.Address, "]:", o.Port)
+			}
+		}
+	}
+	return F.ToString(":", o.Port)
+}
+
+func (o AutoRouteOptions) writeBypass(builder *strings.Builder) {
+	builder.WriteString("\t\tfib daddr type local return\n")
+	builder.WriteString("\t\tip daddr @inet4_bypass return\n")
+	if o.Inet6 {
+		builder.WriteString("\t\tip6 daddr @inet6_bypass return\n")
+	} else {
+		builder.WriteString("\t\tmeta nfproto ipv6 return\n")
+	}
+}
+
+func writeSet(builder *strings.Builder, name string, addressType string, elements []string) {
+	builder.WriteString(F.ToString("\tset ", name, " {\n"))
+	builder.WriteString(F.ToString("\t\ttype ", addressType, "\n"))
+	builder.WriteString("\t\tflags interval\n")
+	builder.WriteString("\t\tauto-merge\n")
+	builder.WriteString(F.ToString("\t\telements = { ", strings.Join(elements, ", "), " }\n"))
+	builder.WriteString("\t}\n")
+}
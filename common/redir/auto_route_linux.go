@@ -0,0 +1,136 @@
+package redir
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/sagernet/netlink"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/sys/unix"
+)
+
+type AutoRoute struct {
+	options AutoRouteOptions
+}
+
+func NewAutoRoute(options AutoRouteOptions) (*AutoRoute, error) {
+	if options.TProxy && options.DefaultMark == options.mark() {
+		return nil, E.New("route.default_mark conflicts with the tproxy mark ", options.mark(), ", set another iproute2_table_index")
+	}
+	_, err := exec.LookPath("nft")
+	if err != nil {
+		return nil, E.Cause(err, "auto_route requires nft")
+	}
+	return &AutoRoute{options}, nil
+}
+
+func (r *AutoRoute) Start() error {
+	// clean up rules left by a crashed instance
+	r.cleanup()
+	err := runNFT(r.options.script())
+	if err != nil {
+		return err
+	}
+	if r.options.TProxy {
+		err = r.setupPolicyRoute()
+		if err != nil {
+			r.cleanup()
+			return E.Cause(err, "setup policy route")
+		}
+	}
+	return nil
+}
+
+func (r *AutoRoute) Close() error {
+	return r.cleanup()
+}
+
+func (r *AutoRoute) cleanup() error {
+	err := runNFT(r.options.deleteScript())
+	if r.options.TProxy {
+		for _, rule := range r.rules() {
+			for {
+				if netlink.RuleDel(rule) != nil {
+					break
+				}
+			}
+		}
+		for _, route := range r.routes() {
+			_ = netlink.RouteDel(route)
+		}
+	}
+	return err
+}
+
+func (r *AutoRoute) setupPolicyRoute() error {
+	for _, rule := range r.rules() {
+		err := netlink.RuleAdd(rule)
+		if err != nil {
+			return err
+		}
+	}
+	for _, route := range r.routes() {
+		err := netlink.RouteReplace(route)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AutoRoute) families() []int {
+	if r.options.Inet6 {
+		return []int{unix.AF_INET, unix.AF_INET6}
+	}
+	return []int{unix.AF_INET}
+}
+
+func (r *AutoRoute) rules() []*netlink.Rule {
+	var rules []*netlink.Rule
+	for _, family := range r.families() {
+		rule := netlink.NewRule()
+		rule.Priority = r.options.ruleIndex()
+		rule.Family = family
+		rule.Mark = int(r.options.mark())
+		rule.Table = r.options.tableIndex()
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r *AutoRoute) routes() []*netlink.Route {
+	loopback, err := net.InterfaceByName("lo")
+	if err != nil {
+		return nil
+	}
+	var routes []*netlink.Route
+	for _, family := range r.families() {
+		var destination *net.IPNet
+		if family == unix.AF_INET {
+			destination = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+		} else {
+			destination = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+		}
+		routes = append(routes, &netlink.Route{
+			LinkIndex: loopback.Index,
+			Dst:       destination,
+			Family:    family,
+			Table:     r.options.tableIndex(),
+			Type:      unix.RTN_LOCAL,
+			Scope:     netlink.SCOPE_HOST,
+		})
+	}
+	return routes
+}
+
+func runNFT(script string) error {
+	command := exec.Command("nft", "-f", "-")
+	command.Stdin = strings.NewReader(script)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return E.Cause(err, "nft: ", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
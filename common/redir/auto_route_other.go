@@ -0,0 +1,21 @@
+//go:build !linux
+
+package redir
+
+import (
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type AutoRoute struct{}
+
+func NewAutoRoute(options AutoRouteOptions) (*AutoRoute, error) {
+	return nil, E.New("auto_route is only supported on Linux")
+}
+
+func (r *AutoRoute) Start() error {
+	return nil
+}
+
+func (r *AutoRoute) Close() error {
+	return nil
+}
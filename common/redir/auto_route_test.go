@@ -0,0 +1,46 @@
+package redir
+
+import (
+	"net/netip"
+	"testing"
+
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoRouteRedirectScript(t *testing.T) {
+	t.Parallel()
+	script := AutoRouteOptions{
+		Tag:         "redirect-in",
+		Network:     []string{N.NetworkTCP},
+		Port:        7892,
+		DefaultMark: 233,
+	}.script()
+	require.Contains(t, script, "delete table inet sing_box_redirect_in\n")
+	require.Contains(t, script, "type nat hook prerouting priority -100;")
+	require.Contains(t, script, "type nat hook output priority -100;")
+	require.Contains(t, script, "meta mark 233 return")
+	require.Contains(t, script, "meta nfproto ipv6 return")
+	require.Contains(t, script, "meta l4proto tcp redirect to :7892")
+	require.NotContains(t, script, "inet6_bypass")
+}
+
+func TestAutoRouteTProxyScript(t *testing.T) {
+	t.Parallel()
+	options := AutoRouteOptions{
+		TProxy:         true,
+		Network:        []string{N.NetworkTCP, N.NetworkUDP},
+		Address:        netip.MustParseAddr("127.0.0.1"),
+		Port:           7893,
+		Inet6:          true,
+		ExcludeAddress: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24"), netip.MustParsePrefix("2001:db8::/32")},
+	}
+	script := options.script()
+	require.Contains(t, script, "table inet sing_box_tproxy {")
+	require.Contains(t, script, "240.0.0.0/4, 203.0.113.0/24 }")
+	require.Contains(t, script, "ff00::/8, 2001:db8::/32 }")
+	require.Contains(t, script, "meta nfproto ipv4 meta l4proto { tcp, udp } tproxy ip to 127.0.0.1:7893 meta mark set 2023 accept")
+	require.Contains(t, script, "meta nfproto ipv6 meta l4proto { tcp, udp } tproxy ip6 to :7893 meta mark set 2023 accept")
+	require.NotContains(t, script, "chain output")
+}
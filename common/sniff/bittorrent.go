@@ -0,0 +1,87 @@
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+const bitTorrentProtocolName = "BitTorrent protocol"
+
+// BitTorrent detects the BitTorrent peer wire handshake.
+func BitTorrent(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	var header [1 + len(bitTorrentProtocolName)]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != byte(len(bitTorrentProtocolName)) || string(header[1:]) != bitTorrentProtocolName {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
+
+const (
+	utpTypeSYN      = 4
+	utpVersion      = 1
+	utpHeaderLength = 20
+	// utpExtensionMax is the highest extension in use, 1 is selective ACK and 2 is extension bits.
+	utpExtensionMax = 2
+)
+
+// UTP detects the SYN packet that opens a connection of the Micro Transport Protocol (BEP 29).
+// Other packet types share their header layout with unrelated protocols, such as the WireGuard handshake,
+// so only the SYN shape is accepted: no payload, and no timestamp difference since nothing was received yet.
+func UTP(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	if len(packet) < utpHeaderLength {
+		return nil, os.ErrInvalid
+	}
+	if packet[0]>>4 != utpTypeSYN || packet[0]&0x0F != utpVersion {
+		return nil, os.ErrInvalid
+	}
+	timestamp := binary.BigEndian.Uint32(packet[4:])
+	timestampDifference := binary.BigEndian.Uint32(packet[8:])
+	if timestamp == 0 || timestampDifference != 0 {
+		return nil, os.ErrInvalid
+	}
+	// validate the extension chain, each extension is (next extension, length, payload).
+	extension := packet[1]
+	offset := utpHeaderLength
+	for extension != 0 {
+		if extension > utpExtensionMax || len(packet) < offset+2 {
+			return nil, os.ErrInvalid
+		}
+		extension = packet[offset]
+		length := int(packet[offset+1])
+		if length == 0 {
+			return nil, os.ErrInvalid
+		}
+		offset += 2 + length
+		if len(packet) < offset {
+			return nil, os.ErrInvalid
+		}
+	}
+	if len(packet) != offset {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
+
+// DHT detects KRPC messages of the BitTorrent DHT (BEP 5), which are bencoded dictionaries.
+func DHT(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	if len(packet) < 12 || packet[0] != 'd' || packet[len(packet)-1] != 'e' {
+		return nil, os.ErrInvalid
+	}
+	if !bytes.Contains(packet, []byte("1:y1:q")) && !bytes.Contains(packet, []byte("1:y1:r")) && !bytes.Contains(packet, []byte("1:y1:e")) {
+		return nil, os.ErrInvalid
+	}
+	if !bytes.Contains(packet, []byte("1:t")) {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
@@ -0,0 +1,90 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffBitTorrent(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("13426974546f7272656e742070726f746f636f6c0000000000100005aa8e2b7d1a8e3c3f0e4a5e2a7d6b8f9e0a1b2c3d2d5452333030302d6b34736e6a3668793673306a")
+	require.NoError(t, err)
+	metadata, err := sniff.BitTorrent(context.Background(), bytes.NewReader(packet))
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolBitTorrent)
+}
+
+func TestSniffUTP(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("4100a6c31b5e3c0f0000000000100000c13e0000")
+	require.NoError(t, err)
+	metadata, err := sniff.UTP(context.Background(), packet)
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolBitTorrent)
+}
+
+func TestSniffUTPExtension(t *testing.T) {
+	t.Parallel()
+	// SYN carrying the extension bits extension
+	packet, err := hex.DecodeString("4102a6c31b5e3c0f0000000000100000c13e0000" + "00080000000000000000")
+	require.NoError(t, err)
+	metadata, err := sniff.UTP(context.Background(), packet)
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolBitTorrent)
+}
+
+func TestSniffUTPInvalid(t *testing.T) {
+	t.Parallel()
+	wireGuardInitiation := make([]byte, 148)
+	wireGuardInitiation[0] = 1
+	for i := 4; i < len(wireGuardInitiation); i++ {
+		wireGuardInitiation[i] = byte(i * 7)
+	}
+	for name, packet := range map[string]string{
+		"dtls":                 "16feff0000000000000000004e010000420000000000000042fefd000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f00000004c02bc02f0100001400000010000e00000b6578616d706c652e636f6d",
+		"utp data":             "0100a6c41b5e3c0f000012340010000000010000" + "68656c6c6f",
+		"syn with payload":     "4100a6c31b5e3c0f0000000000100000c13e0000" + "68656c6c6f",
+		"syn with reply":       "4100a6c31b5e3c0f0000123400100000c13e0000",
+		"syn without time":     "4100a6c3000000000000000000100000c13e0000",
+		"unknown extension":    "4103a6c31b5e3c0f0000000000100000c13e0000" + "00040000000000",
+		"truncated extension":  "4102a6c31b5e3c0f0000000000100000c13e0000" + "0008000000",
+		"empty extension":      "4102a6c31b5e3c0f0000000000100000c13e0000" + "0000",
+		"version 2":            "4200a6c31b5e3c0f0000000000100000c13e0000",
+		"short":                "4100a6c31b5e3c0f",
+		"wireguard initiation": hex.EncodeToString(wireGuardInitiation),
+	} {
+		content, err := hex.DecodeString(packet)
+		require.NoError(t, err, name)
+		_, err = sniff.UTP(context.Background(), content)
+		require.Error(t, err, name)
+	}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		packet := make([]byte, 20+random.Intn(1400))
+		random.Read(packet)
+		_, err := sniff.UTP(context.Background(), packet)
+		require.Error(t, err)
+	}
+}
+
+func TestSniffDHT(t *testing.T) {
+	t.Parallel()
+	packet := []byte("d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:qe")
+	metadata, err := sniff.DHT(context.Background(), packet)
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolBitTorrent)
+}
+
+func FuzzSniffUTP(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sniff.UTP(context.Background(), data)
+	})
+}
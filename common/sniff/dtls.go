@@ -0,0 +1,113 @@
+package sniff
+
+import (
+	"context"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	dtlsVersion10 = 0xfeff
+	dtlsVersion12 = 0xfefd
+	dtlsVersion13 = 0xfefc
+)
+
+// DTLSClientHello detects DTLS ClientHello records and extracts the server name.
+func DTLSClientHello(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	var (
+		contentType uint8
+		version     uint16
+		fragment    cryptobyte.String
+	)
+	record := cryptobyte.String(packet)
+	// content type, version, epoch, sequence number, fragment
+	if !record.ReadUint8(&contentType) || contentType != 0x16 ||
+		!record.ReadUint16(&version) ||
+		!record.Skip(2+6) ||
+		!record.ReadUint16LengthPrefixed(&fragment) {
+		return nil, os.ErrInvalid
+	}
+	if version != dtlsVersion10 && version != dtlsVersion12 && version != dtlsVersion13 {
+		return nil, os.ErrInvalid
+	}
+	var (
+		handshakeType  uint8
+		length         uint32
+		fragmentOffset uint32
+		fragmentLength uint32
+	)
+	// handshake type, length, message sequence, fragment offset and length
+	if !fragment.ReadUint8(&handshakeType) || handshakeType != 1 ||
+		!fragment.ReadUint24(&length) ||
+		!fragment.Skip(2) ||
+		!fragment.ReadUint24(&fragmentOffset) ||
+		!fragment.ReadUint24(&fragmentLength) {
+		return nil, os.ErrInvalid
+	}
+	if fragmentOffset != 0 || fragmentLength != length || len(fragment) < int(length) {
+		return &adapter.InboundContext{Protocol: C.ProtocolDTLS}, E.New("fragmented client hello")
+	}
+	serverName, err := readDTLSServerName(fragment[:length])
+	if err != nil {
+		return &adapter.InboundContext{Protocol: C.ProtocolDTLS}, err
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolDTLS, Domain: serverName}, nil
+}
+
+func readDTLSServerName(body cryptobyte.String) (string, error) {
+	var (
+		sessionID    cryptobyte.String
+		cookie       cryptobyte.String
+		cipherSuites cryptobyte.String
+		compression  cryptobyte.String
+		extensions   cryptobyte.String
+	)
+	// version, random, session id, cookie, cipher suites, compression methods
+	if !body.Skip(2+32) ||
+		!body.ReadUint8LengthPrefixed(&sessionID) ||
+		!body.ReadUint8LengthPrefixed(&cookie) ||
+		!body.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!body.ReadUint8LengthPrefixed(&compression) {
+		return "", E.New("bad client hello")
+	}
+	if body.Empty() {
+		return "", nil
+	}
+	if !body.ReadUint16LengthPrefixed(&extensions) {
+		return "", E.New("bad extensions")
+	}
+	for !extensions.Empty() {
+		var (
+			extension uint16
+			data      cryptobyte.String
+		)
+		if !extensions.ReadUint16(&extension) || !extensions.ReadUint16LengthPrefixed(&data) {
+			return "", E.New("bad extension")
+		}
+		if extension != extensionServerName {
+			continue
+		}
+		var serverNameList cryptobyte.String
+		if !data.ReadUint16LengthPrefixed(&serverNameList) {
+			return "", E.New("bad server name extension")
+		}
+		for !serverNameList.Empty() {
+			var (
+				nameType   uint8
+				serverName cryptobyte.String
+			)
+			if !serverNameList.ReadUint8(&nameType) || !serverNameList.ReadUint16LengthPrefixed(&serverName) {
+				return "", E.New("bad server name extension")
+			}
+			if nameType == 0 {
+				return string(serverName), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,28 @@
+package sniff_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffDTLS(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("16feff0000000000000000004e010000420000000000000042fefd000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f00000004c02bc02f0100001400000010000e00000b6578616d706c652e636f6d")
+	require.NoError(t, err)
+	metadata, err := sniff.DTLSClientHello(context.Background(), packet)
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolDTLS)
+	require.Equal(t, metadata.Domain, "example.com")
+}
+
+func FuzzSniffDTLS(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sniff.DTLSClientHello(context.Background(), data)
+	})
+}
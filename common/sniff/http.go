@@ -16,5 +16,11 @@ func HTTPHost(ctx context.Context, reader io.Reader) (*adapter.InboundContext, e
 	if err != nil {
 		return nil, err
 	}
-	return &adapter.InboundContext{Protocol: C.ProtocolHTTP, Domain: M.ParseSocksaddr(request.Host).AddrString()}, nil
+	return &adapter.InboundContext{
+		Protocol:   C.ProtocolHTTP,
+		Domain:     M.ParseSocksaddr(request.Host).AddrString(),
+		HTTPMethod: request.Method,
+		HTTPPath:   request.URL.Path,
+		UserAgent:  request.UserAgent(),
+	}, nil
 }
@@ -25,3 +25,13 @@ func TestSniffHTTP1WithPort(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "www.gov.cn")
 }
+
+func TestSniffHTTP1Request(t *testing.T) {
+	t.Parallel()
+	pkt := "POST /api/v1/upload?id=1 HTTP/1.1\r\nHost: www.google.com\r\nUser-Agent: curl/8.0.1\r\n\r\n"
+	metadata, err := sniff.HTTPHost(context.Background(), strings.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, metadata.HTTPMethod, "POST")
+	require.Equal(t, metadata.HTTPPath, "/api/v1/upload")
+	require.Equal(t, metadata.UserAgent, "curl/8.0.1")
+}
@@ -0,0 +1,36 @@
+package sniff
+
+import (
+	"context"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+const (
+	ntpPacketLength = 48
+	ntpModeClient   = 3
+)
+
+// NTP detects NTP client requests.
+func NTP(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	// a request may be followed by extension fields or a MAC.
+	if len(packet) < ntpPacketLength {
+		return nil, os.ErrInvalid
+	}
+	version := packet[0] >> 3 & 0x07
+	mode := packet[0] & 0x07
+	if version < 1 || version > 4 || mode != ntpModeClient {
+		return nil, os.ErrInvalid
+	}
+	// stratum
+	if packet[1] > 16 {
+		return nil, os.ErrInvalid
+	}
+	// poll interval in log2 seconds
+	if packet[2] > 17 {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolNTP}, nil
+}
@@ -17,6 +17,8 @@ func TestSniffQUICv1(t *testing.T) {
 	metadata, err := sniff.QUICClientHello(context.Background(), pkt)
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "cloudflare-quic.com")
+	require.Equal(t, metadata.ALPN, []string{"h3"})
+	require.Regexp(t, `^q13d\d{4}h3_`, metadata.JA4)
 }
 
 func TestSniffQUICFragment(t *testing.T) {
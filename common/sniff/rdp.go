@@ -0,0 +1,34 @@
+package sniff
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+// RDP detects the X.224 Connection Request wrapped in TPKT sent by RDP clients.
+func RDP(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	var header [7]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return nil, err
+	}
+	// TPKT: version 3, reserved 0, total length.
+	if header[0] != 3 || header[1] != 0 {
+		return nil, os.ErrInvalid
+	}
+	length := binary.BigEndian.Uint16(header[2:4])
+	// X.224: length indicator covers the rest of the packet, then the Connection Request code.
+	if length < 11 || int(header[4]) != int(length)-5 || header[5] != 0xE0 {
+		return nil, os.ErrInvalid
+	}
+	// DST-REF must be zero in a Connection Request.
+	if header[6] != 0 {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolRDP}, nil
+}
@@ -0,0 +1,22 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffRDP(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("030000130ee00000000000010008000b000000")
+	require.NoError(t, err)
+	metadata, err := sniff.RDP(context.Background(), bytes.NewReader(packet))
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolRDP)
+}
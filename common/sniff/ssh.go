@@ -0,0 +1,24 @@
+package sniff
+
+import (
+	std_bufio "bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+// SSH detects the SSH protocol version exchange of the client.
+func SSH(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	banner, err := std_bufio.NewReaderSize(reader, 256).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(banner, "SSH-2.0-") && !strings.HasPrefix(banner, "SSH-1.99-") {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolSSH}, nil
+}
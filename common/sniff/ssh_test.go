@@ -0,0 +1,27 @@
+package sniff_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffSSH(t *testing.T) {
+	t.Parallel()
+	pkt := "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n"
+	metadata, err := sniff.SSH(context.Background(), strings.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, metadata.Protocol, C.ProtocolSSH)
+}
+
+func TestSniffSSHInvalid(t *testing.T) {
+	t.Parallel()
+	pkt := "GET / HTTP/1.1\r\nHost: www.google.com\r\n\r\n"
+	_, err := sniff.SSH(context.Background(), strings.NewReader(pkt))
+	require.Error(t, err)
+}
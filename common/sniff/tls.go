@@ -1,6 +1,7 @@
 package sniff
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"io"
@@ -11,15 +12,32 @@ import (
 )
 
 func TLSClientHello(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
-	var clientHello *tls.ClientHelloInfo
-	err := tls.Server(bufio.NewReadOnlyConn(reader), &tls.Config{
+	return tlsClientHello(ctx, reader, false)
+}
+
+func tlsClientHello(ctx context.Context, reader io.Reader, quic bool) (*adapter.InboundContext, error) {
+	var (
+		clientHello *tls.ClientHelloInfo
+		content     bytes.Buffer
+	)
+	err := tls.Server(bufio.NewReadOnlyConn(io.TeeReader(reader, &content)), &tls.Config{
 		GetConfigForClient: func(argHello *tls.ClientHelloInfo) (*tls.Config, error) {
 			clientHello = argHello
 			return nil, nil
 		},
 	}).HandshakeContext(ctx)
 	if clientHello != nil {
-		return &adapter.InboundContext{Protocol: C.ProtocolTLS, Domain: clientHello.ServerName}, nil
+		metadata := &adapter.InboundContext{
+			Protocol: C.ProtocolTLS,
+			Domain:   clientHello.ServerName,
+			ALPN:     clientHello.SupportedProtos,
+		}
+		fingerprint, err := parseClientHelloRecords(content.Bytes())
+		if err == nil {
+			metadata.JA3 = fingerprint.JA3()
+			metadata.JA4 = fingerprint.JA4(quic)
+		}
+		return metadata, nil
 	}
 	return nil, err
 }
@@ -0,0 +1,305 @@
+package sniff
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	extensionServerName          uint16 = 0x0000
+	extensionSupportedGroups     uint16 = 0x000a
+	extensionECPointFormats      uint16 = 0x000b
+	extensionSignatureAlgorithms uint16 = 0x000d
+	extensionALPN                uint16 = 0x0010
+	extensionSupportedVersions   uint16 = 0x002b
+)
+
+type clientHelloFingerprint struct {
+	version             uint16
+	cipherSuites        []uint16
+	extensions          []uint16
+	supportedGroups     []uint16
+	pointFormats        []uint8
+	signatureAlgorithms []uint16
+	supportedVersions   []uint16
+	alpnProtocols       []string
+	hasServerName       bool
+}
+
+// parseClientHelloRecords parses the ClientHello message carried by TLS handshake records.
+func parseClientHelloRecords(content []byte) (*clientHelloFingerprint, error) {
+	var message []byte
+	records := cryptobyte.String(content)
+	for {
+		var (
+			contentType uint8
+			version     uint16
+			fragment    cryptobyte.String
+		)
+		if !records.ReadUint8(&contentType) || !records.ReadUint16(&version) || !records.ReadUint16LengthPrefixed(&fragment) {
+			return nil, E.New("bad TLS record")
+		}
+		if contentType != 0x16 {
+			return nil, E.New("not a TLS handshake record")
+		}
+		message = append(message, fragment...)
+		if len(message) >= 4 && len(message) >= 4+(int(message[1])<<16|int(message[2])<<8|int(message[3])) {
+			break
+		}
+	}
+	return parseClientHello(message)
+}
+
+func parseClientHello(message []byte) (*clientHelloFingerprint, error) {
+	var (
+		hello         clientHelloFingerprint
+		handshakeType uint8
+		body          cryptobyte.String
+	)
+	reader := cryptobyte.String(message)
+	if !reader.ReadUint8(&handshakeType) || handshakeType != 1 || !reader.ReadUint24LengthPrefixed(&body) {
+		return nil, E.New("not a ClientHello message")
+	}
+	var (
+		sessionID    cryptobyte.String
+		cipherSuites cryptobyte.String
+		compression  cryptobyte.String
+	)
+	if !body.ReadUint16(&hello.version) || !body.Skip(32) ||
+		!body.ReadUint8LengthPrefixed(&sessionID) ||
+		!body.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!body.ReadUint8LengthPrefixed(&compression) {
+		return nil, E.New("bad ClientHello")
+	}
+	for !cipherSuites.Empty() {
+		var cipherSuite uint16
+		if !cipherSuites.ReadUint16(&cipherSuite) {
+			return nil, E.New("bad cipher suites")
+		}
+		hello.cipherSuites = append(hello.cipherSuites, cipherSuite)
+	}
+	if body.Empty() {
+		return &hello, nil
+	}
+	var extensions cryptobyte.String
+	if !body.ReadUint16LengthPrefixed(&extensions) {
+		return nil, E.New("bad extensions")
+	}
+	for !extensions.Empty() {
+		var (
+			extension uint16
+			data      cryptobyte.String
+		)
+		if !extensions.ReadUint16(&extension) || !extensions.ReadUint16LengthPrefixed(&data) {
+			return nil, E.New("bad extension")
+		}
+		hello.extensions = append(hello.extensions, extension)
+		var ok bool
+		switch extension {
+		case extensionServerName:
+			hello.hasServerName = true
+			ok = true
+		case extensionSupportedGroups:
+			hello.supportedGroups, ok = readUint16List(data)
+		case extensionECPointFormats:
+			var pointFormats cryptobyte.String
+			ok = data.ReadUint8LengthPrefixed(&pointFormats)
+			hello.pointFormats = pointFormats
+		case extensionSignatureAlgorithms:
+			hello.signatureAlgorithms, ok = readUint16List(data)
+		case extensionALPN:
+			var protocols cryptobyte.String
+			ok = data.ReadUint16LengthPrefixed(&protocols)
+			for ok && !protocols.Empty() {
+				var protocol cryptobyte.String
+				ok = protocols.ReadUint8LengthPrefixed(&protocol)
+				hello.alpnProtocols = append(hello.alpnProtocols, string(protocol))
+			}
+		case extensionSupportedVersions:
+			var versions cryptobyte.String
+			ok = data.ReadUint8LengthPrefixed(&versions)
+			for ok && !versions.Empty() {
+				var version uint16
+				ok = versions.ReadUint16(&version)
+				hello.supportedVersions = append(hello.supportedVersions, version)
+			}
+		default:
+			ok = true
+		}
+		if !ok {
+			return nil, E.New("bad extension ", extension)
+		}
+	}
+	return &hello, nil
+}
+
+func readUint16List(data cryptobyte.String) ([]uint16, bool) {
+	var list cryptobyte.String
+	if !data.ReadUint16LengthPrefixed(&list) {
+		return nil, false
+	}
+	var values []uint16
+	for !list.Empty() {
+		var value uint16
+		if !list.ReadUint16(&value) {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// isGREASE reports whether the value is reserved by RFC 8701 and must be ignored in fingerprints.
+func isGREASE(value uint16) bool {
+	return value&0x0f0f == 0x0a0a && value>>8 == value&0xff
+}
+
+func filterGREASE(values []uint16) []uint16 {
+	var filtered []uint16
+	for _, value := range values {
+		if !isGREASE(value) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+func joinUint16(values []uint16, format func(uint16) string) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, format(value))
+	}
+	return strings.Join(formatted, "-")
+}
+
+func decimalUint16(value uint16) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
+
+func hexUint16(value uint16) string {
+	return hex.EncodeToString([]byte{byte(value >> 8), byte(value)})
+}
+
+// JA3 returns the MD5 hash of the JA3 fingerprint string.
+func (h *clientHelloFingerprint) JA3() string {
+	pointFormats := make([]string, 0, len(h.pointFormats))
+	for _, pointFormat := range h.pointFormats {
+		pointFormats = append(pointFormats, strconv.FormatUint(uint64(pointFormat), 10))
+	}
+	fingerprint := strings.Join([]string{
+		decimalUint16(h.version),
+		joinUint16(filterGREASE(h.cipherSuites), decimalUint16),
+		joinUint16(filterGREASE(h.extensions), decimalUint16),
+		joinUint16(filterGREASE(h.supportedGroups), decimalUint16),
+		strings.Join(pointFormats, "-"),
+	}, ",")
+	hash := md5.Sum([]byte(fingerprint))
+	return hex.EncodeToString(hash[:])
+}
+
+// JA4 returns the JA4 fingerprint, in the form of a_b_c.
+func (h *clientHelloFingerprint) JA4(quic bool) string {
+	var builder strings.Builder
+	if quic {
+		builder.WriteByte('q')
+	} else {
+		builder.WriteByte('t')
+	}
+	version := h.version
+	for _, supportedVersion := range filterGREASE(h.supportedVersions) {
+		if supportedVersion > version {
+			version = supportedVersion
+		}
+	}
+	switch version {
+	case 0x0304:
+		builder.WriteString("13")
+	case 0x0303:
+		builder.WriteString("12")
+	case 0x0302:
+		builder.WriteString("11")
+	case 0x0301:
+		builder.WriteString("10")
+	case 0x0300:
+		builder.WriteString("s3")
+	default:
+		builder.WriteString("00")
+	}
+	if h.hasServerName {
+		builder.WriteByte('d')
+	} else {
+		builder.WriteByte('i')
+	}
+	cipherSuites := filterGREASE(h.cipherSuites)
+	extensions := filterGREASE(h.extensions)
+	builder.WriteString(twoDigits(len(cipherSuites)))
+	builder.WriteString(twoDigits(len(extensions)))
+	builder.WriteString(alpnCharacters(h.alpnProtocols))
+	builder.WriteByte('_')
+
+	sortedCipherSuites := append([]uint16(nil), cipherSuites...)
+	sort.Slice(sortedCipherSuites, func(i, j int) bool {
+		return sortedCipherSuites[i] < sortedCipherSuites[j]
+	})
+	builder.WriteString(truncatedHash(strings.ReplaceAll(joinUint16(sortedCipherSuites, hexUint16), "-", ",")))
+	builder.WriteByte('_')
+
+	var sortedExtensions []uint16
+	for _, extension := range extensions {
+		if extension != extensionServerName && extension != extensionALPN {
+			sortedExtensions = append(sortedExtensions, extension)
+		}
+	}
+	sort.Slice(sortedExtensions, func(i, j int) bool {
+		return sortedExtensions[i] < sortedExtensions[j]
+	})
+	extensionString := strings.ReplaceAll(joinUint16(sortedExtensions, hexUint16), "-", ",")
+	if len(h.signatureAlgorithms) > 0 {
+		extensionString += "_" + strings.ReplaceAll(joinUint16(filterGREASE(h.signatureAlgorithms), hexUint16), "-", ",")
+	}
+	builder.WriteString(truncatedHash(extensionString))
+	return builder.String()
+}
+
+func twoDigits(count int) string {
+	if count > 99 {
+		count = 99
+	}
+	if count < 10 {
+		return "0" + strconv.Itoa(count)
+	}
+	return strconv.Itoa(count)
+}
+
+func alpnCharacters(protocols []string) string {
+	if len(protocols) == 0 || protocols[0] == "" {
+		return "00"
+	}
+	protocol := protocols[0]
+	first, last := protocol[0], protocol[len(protocol)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	encoded := hex.EncodeToString([]byte(protocol))
+	return string([]byte{encoded[0], encoded[len(encoded)-1]})
+}
+
+func isAlphanumeric(char byte) bool {
+	return char >= '0' && char <= '9' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+}
+
+func truncatedHash(content string) string {
+	if content == "" {
+		return "000000000000"
+	}
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])[:12]
+}
@@ -0,0 +1,95 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/common/sniff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffTLSClientHello(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		defer clientConn.Close()
+		clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+		tls.Client(clientConn, &tls.Config{
+			ServerName: "www.google.com",
+			NextProtos: []string{"h2", "http/1.1"},
+		}).Handshake()
+	}()
+	serverConn.SetDeadline(time.Now().Add(5 * time.Second))
+	var content bytes.Buffer
+	buffer := make([]byte, 4096)
+	for {
+		n, err := serverConn.Read(buffer)
+		content.Write(buffer[:n])
+		require.NoError(t, err)
+		metadata, err := sniff.TLSClientHello(context.Background(), bytes.NewReader(content.Bytes()))
+		if err == nil {
+			require.Equal(t, metadata.Domain, "www.google.com")
+			require.Equal(t, metadata.ALPN, []string{"h2", "http/1.1"})
+			require.Len(t, metadata.JA3, 32)
+			require.Regexp(t, `^t13d\d{4}h2_[0-9a-f]{12}_[0-9a-f]{12}$`, metadata.JA4)
+			return
+		}
+	}
+}
+
+func TestSniffTLSClientHelloMultipleRecords(t *testing.T) {
+	t.Parallel()
+	var record []byte
+	// find a ClientHello whose length makes a premature end detectable
+	for i := 1; ; i++ {
+		record = readClientHelloRecord(t, strings.Repeat("a", i)+".com")
+		if record[8]&4 != 0 {
+			break
+		}
+	}
+	metadata, err := sniff.TLSClientHello(context.Background(), bytes.NewReader(record))
+	require.NoError(t, err)
+	require.NotEmpty(t, metadata.JA3)
+	message := record[5:]
+	var content []byte
+	for _, fragment := range [][]byte{message[:len(message)-1], message[len(message)-1:]} {
+		content = append(content, record[0], record[1], record[2], byte(len(fragment)>>8), byte(len(fragment)))
+		content = append(content, fragment...)
+	}
+	splitMetadata, err := sniff.TLSClientHello(context.Background(), bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, metadata.Domain, splitMetadata.Domain)
+	require.Equal(t, metadata.JA3, splitMetadata.JA3)
+	require.Equal(t, metadata.JA4, splitMetadata.JA4)
+}
+
+func readClientHelloRecord(t *testing.T, serverName string) []byte {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		defer clientConn.Close()
+		clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+		tls.Client(clientConn, &tls.Config{
+			ServerName: serverName,
+			NextProtos: []string{"h2", "http/1.1"},
+		}).Handshake()
+	}()
+	serverConn.SetDeadline(time.Now().Add(5 * time.Second))
+	header := make([]byte, 5)
+	_, err := io.ReadFull(serverConn, header)
+	require.NoError(t, err)
+	record := make([]byte, 5+int(binary.BigEndian.Uint16(header[3:])))
+	copy(record, header)
+	_, err = io.ReadFull(serverConn, record[5:])
+	require.NoError(t, err)
+	return record
+}
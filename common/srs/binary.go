@@ -35,9 +35,54 @@ const (
 	ruleItemPackageName
 	ruleItemWIFISSID
 	ruleItemWIFIBSSID
+	ruleItemDomainSuffix
+	ruleItemSourceIPIsPrivate
+	ruleItemIPIsPrivate
+	ruleItemNetworkType
+	ruleItemAuthUser
+	ruleItemUser
+	ruleItemUserID
+	ruleItemProtocol
+	ruleItemClashMode
 	ruleItemFinal uint8 = 0xFF
 )
 
+// MinimalVersion returns the lowest rule-set version able to encode all items of the rule-set.
+func MinimalVersion(ruleSet option.PlainRuleSet) uint8 {
+	version := uint8(C.RuleSetVersion1)
+	for _, rule := range ruleSet.Rules {
+		if ruleVersion := minimalRuleVersion(rule); ruleVersion > version {
+			version = ruleVersion
+		}
+	}
+	return version
+}
+
+func minimalRuleVersion(rule option.HeadlessRule) uint8 {
+	switch rule.Type {
+	case C.RuleTypeDefault:
+		options := rule.DefaultOptions
+		if options.SourceIPIsPrivate || options.IPIsPrivate ||
+			len(options.NetworkType) > 0 ||
+			len(options.AuthUser) > 0 ||
+			len(options.User) > 0 ||
+			len(options.UserID) > 0 ||
+			len(options.Protocol) > 0 ||
+			options.ClashMode != "" {
+			return C.RuleSetVersion2
+		}
+	case C.RuleTypeLogical:
+		version := uint8(C.RuleSetVersion1)
+		for _, subRule := range rule.LogicalOptions.Rules {
+			if ruleVersion := minimalRuleVersion(subRule); ruleVersion > version {
+				version = ruleVersion
+			}
+		}
+		return version
+	}
+	return C.RuleSetVersion1
+}
+
 func Read(reader io.Reader, recovery bool) (ruleSet option.PlainRuleSet, err error) {
 	var magicBytes [3]byte
 	_, err = io.ReadFull(reader, magicBytes[:])
@@ -53,7 +98,7 @@ func Read(reader io.Reader, recovery bool) (ruleSet option.PlainRuleSet, err err
 	if err != nil {
 		return ruleSet, err
 	}
-	if version != 1 {
+	if version < C.RuleSetVersion1 || version > C.RuleSetVersion2 {
 		return ruleSet, E.New("unsupported version: ", version)
 	}
 	zReader, err := zlib.NewReader(reader)
@@ -80,7 +125,8 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet) error {
 	if err != nil {
 		return err
 	}
-	err = binary.Write(writer, binary.BigEndian, uint8(1))
+	version := MinimalVersion(ruleSet)
+	err = binary.Write(writer, binary.BigEndian, version)
 	if err != nil {
 		return err
 	}
@@ -93,7 +139,7 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet) error {
 		return err
 	}
 	for _, rule := range ruleSet.Rules {
-		err = writeRule(zWriter, rule)
+		err = writeRule(zWriter, rule, version)
 		if err != nil {
 			return err
 		}
@@ -120,12 +166,12 @@ func readRule(reader io.Reader, recovery bool) (rule option.HeadlessRule, err er
 	return
 }
 
-func writeRule(writer io.Writer, rule option.HeadlessRule) error {
+func writeRule(writer io.Writer, rule option.HeadlessRule, version uint8) error {
 	switch rule.Type {
 	case C.RuleTypeDefault:
-		return writeDefaultRule(writer, rule.DefaultOptions)
+		return writeDefaultRule(writer, rule.DefaultOptions, version)
 	case C.RuleTypeLogical:
-		return writeLogicalRule(writer, rule.LogicalOptions)
+		return writeLogicalRule(writer, rule.LogicalOptions, version)
 	default:
 		panic("unknown rule type: " + rule.Type)
 	}
@@ -153,7 +199,7 @@ func readDefaultRule(reader io.Reader, recovery bool) (rule option.DefaultHeadle
 			rule.Network, err = readRuleItemString(reader)
 		case ruleItemDomain:
 			var matcher *domain.Matcher
-			matcher, err = domain.ReadMatcher(reader)
+			matcher, rule.Domain, rule.DomainSuffix, err = readDomainMatcher(reader, recovery)
 			if err != nil {
 				return
 			}
@@ -196,6 +242,29 @@ func readDefaultRule(reader io.Reader, recovery bool) (rule option.DefaultHeadle
 			rule.WIFISSID, err = readRuleItemString(reader)
 		case ruleItemWIFIBSSID:
 			rule.WIFIBSSID, err = readRuleItemString(reader)
+		case ruleItemDomainSuffix:
+			var matcher *domain.Matcher
+			matcher, _, rule.DomainSuffix, err = readDomainMatcher(reader, recovery)
+			if err != nil {
+				return
+			}
+			rule.DomainSuffixMatcher = matcher
+		case ruleItemSourceIPIsPrivate:
+			rule.SourceIPIsPrivate = true
+		case ruleItemIPIsPrivate:
+			rule.IPIsPrivate = true
+		case ruleItemNetworkType:
+			rule.NetworkType, err = readRuleItemString(reader)
+		case ruleItemAuthUser:
+			rule.AuthUser, err = readRuleItemString(reader)
+		case ruleItemUser:
+			rule.User, err = readRuleItemString(reader)
+		case ruleItemUserID:
+			rule.UserID, err = readRuleItemInt32(reader)
+		case ruleItemProtocol:
+			rule.Protocol, err = readRuleItemString(reader)
+		case ruleItemClashMode:
+			rule.ClashMode, err = rw.ReadVString(reader)
 		case ruleItemFinal:
 			err = binary.Read(reader, binary.BigEndian, &rule.Invert)
 			return
@@ -209,7 +278,7 @@ func readDefaultRule(reader io.Reader, recovery bool) (rule option.DefaultHeadle
 	}
 }
 
-func writeDefaultRule(writer io.Writer, rule option.DefaultHeadlessRule) error {
+func writeDefaultRule(writer io.Writer, rule option.DefaultHeadlessRule, version uint8) error {
 	err := binary.Write(writer, binary.BigEndian, uint8(0))
 	if err != nil {
 		return err
@@ -228,14 +297,25 @@ func writeDefaultRule(writer io.Writer, rule option.DefaultHeadlessRule) error {
 			return err
 		}
 	}
-	if len(rule.Domain) > 0 || len(rule.DomainSuffix) > 0 {
-		err = binary.Write(writer, binary.BigEndian, ruleItemDomain)
-		if err != nil {
-			return err
+	if version == C.RuleSetVersion1 {
+		if len(rule.Domain) > 0 || len(rule.DomainSuffix) > 0 {
+			err = writeRuleItemDomain(writer, ruleItemDomain, rule.Domain, rule.DomainSuffix)
+			if err != nil {
+				return err
+			}
 		}
-		err = domain.NewMatcher(rule.Domain, rule.DomainSuffix).Write(writer)
-		if err != nil {
-			return err
+	} else {
+		if len(rule.Domain) > 0 {
+			err = writeRuleItemDomain(writer, ruleItemDomain, rule.Domain, nil)
+			if err != nil {
+				return err
+			}
+		}
+		if len(rule.DomainSuffix) > 0 {
+			err = writeRuleItemDomain(writer, ruleItemDomainSuffix, nil, rule.DomainSuffix)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	if len(rule.DomainKeyword) > 0 {
@@ -256,12 +336,24 @@ func writeDefaultRule(writer io.Writer, rule option.DefaultHeadlessRule) error {
 			return E.Cause(err, "source_ipcidr")
 		}
 	}
+	if rule.SourceIPIsPrivate {
+		err = binary.Write(writer, binary.BigEndian, ruleItemSourceIPIsPrivate)
+		if err != nil {
+			return err
+		}
+	}
 	if len(rule.IPCIDR) > 0 {
 		err = writeRuleItemCIDR(writer, ruleItemIPCIDR, rule.IPCIDR)
 		if err != nil {
 			return E.Cause(err, "ipcidr")
 		}
 	}
+	if rule.IPIsPrivate {
+		err = binary.Write(writer, binary.BigEndian, ruleItemIPIsPrivate)
+		if err != nil {
+			return err
+		}
+	}
 	if len(rule.SourcePort) > 0 {
 		err = writeRuleItemUint16(writer, ruleItemSourcePort, rule.SourcePort)
 		if err != nil {
@@ -316,6 +408,46 @@ func writeDefaultRule(writer io.Writer, rule option.DefaultHeadlessRule) error {
 			return err
 		}
 	}
+	if len(rule.NetworkType) > 0 {
+		err = writeRuleItemString(writer, ruleItemNetworkType, rule.NetworkType)
+		if err != nil {
+			return err
+		}
+	}
+	if len(rule.AuthUser) > 0 {
+		err = writeRuleItemString(writer, ruleItemAuthUser, rule.AuthUser)
+		if err != nil {
+			return err
+		}
+	}
+	if len(rule.User) > 0 {
+		err = writeRuleItemString(writer, ruleItemUser, rule.User)
+		if err != nil {
+			return err
+		}
+	}
+	if len(rule.UserID) > 0 {
+		err = writeRuleItemInt32(writer, ruleItemUserID, rule.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(rule.Protocol) > 0 {
+		err = writeRuleItemString(writer, ruleItemProtocol, rule.Protocol)
+		if err != nil {
+			return err
+		}
+	}
+	if rule.ClashMode != "" {
+		err = binary.Write(writer, binary.BigEndian, ruleItemClashMode)
+		if err != nil {
+			return err
+		}
+		err = rw.WriteVString(writer, rule.ClashMode)
+		if err != nil {
+			return err
+		}
+	}
 	err = binary.Write(writer, binary.BigEndian, ruleItemFinal)
 	if err != nil {
 		return err
@@ -393,6 +525,47 @@ func writeRuleItemUint16(writer io.Writer, itemType uint8, value []uint16) error
 	return nil
 }
 
+func writeRuleItemDomain(writer io.Writer, itemType uint8, domains []string, domainSuffix []string) error {
+	err := binary.Write(writer, binary.BigEndian, itemType)
+	if err != nil {
+		return err
+	}
+	return domain.NewMatcher(domains, domainSuffix).Write(writer)
+}
+
+func readRuleItemInt32(reader io.Reader) ([]int32, error) {
+	length, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]int32, length)
+	for i := uint64(0); i < length; i++ {
+		err = binary.Read(reader, binary.BigEndian, &value[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func writeRuleItemInt32(writer io.Writer, itemType uint8, value []int32) error {
+	err := binary.Write(writer, binary.BigEndian, itemType)
+	if err != nil {
+		return err
+	}
+	err = rw.WriteUVariant(writer, uint64(len(value)))
+	if err != nil {
+		return err
+	}
+	for _, item := range value {
+		err = binary.Write(writer, binary.BigEndian, item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func writeRuleItemCIDR(writer io.Writer, itemType uint8, value []string) error {
 	var builder netipx.IPSetBuilder
 	for i, prefixString := range value {
@@ -453,7 +626,7 @@ func readLogicalRule(reader io.Reader, recovery bool) (logicalRule option.Logica
 	return
 }
 
-func writeLogicalRule(writer io.Writer, logicalRule option.LogicalHeadlessRule) error {
+func writeLogicalRule(writer io.Writer, logicalRule option.LogicalHeadlessRule, version uint8) error {
 	err := binary.Write(writer, binary.BigEndian, uint8(1))
 	if err != nil {
 		return err
@@ -474,7 +647,7 @@ func writeLogicalRule(writer io.Writer, logicalRule option.LogicalHeadlessRule)
 		return err
 	}
 	for _, rule := range logicalRule.Rules {
-		err = writeRule(writer, rule)
+		err = writeRule(writer, rule, version)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,120 @@
+package srs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf8"
+
+	"github.com/sagernet/sing/common/domain"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/rw"
+)
+
+// the label appended to reversed domain suffixes in the domain matcher.
+const domainPrefixLabel = '\r'
+
+func readDomainMatcher(reader io.Reader, recovery bool) (matcher *domain.Matcher, domains []string, domainSuffix []string, err error) {
+	if !recovery {
+		matcher, err = domain.ReadMatcher(reader)
+		return
+	}
+	var content bytes.Buffer
+	matcher, err = domain.ReadMatcher(io.TeeReader(reader, &content))
+	if err != nil {
+		return
+	}
+	keys, err := readDomainSetKeys(&content)
+	if err != nil {
+		err = E.Cause(err, "recover domain matcher")
+		return
+	}
+	for _, key := range keys {
+		if len(key) > 0 && key[len(key)-1] == domainPrefixLabel {
+			domainSuffix = append(domainSuffix, reverseDomain(key[:len(key)-1]))
+		} else {
+			domains = append(domains, reverseDomain(key))
+		}
+	}
+	return
+}
+
+// readDomainSetKeys walks the succinct trie written by domain.Matcher and returns all keys in it.
+func readDomainSetKeys(reader io.Reader) ([]string, error) {
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := readBitmap(reader)
+	if err != nil {
+		return nil, err
+	}
+	labelBitmap, err := readBitmap(reader)
+	if err != nil {
+		return nil, err
+	}
+	labelsLength, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]byte, labelsLength)
+	_, err = io.ReadFull(reader, labels)
+	if err != nil {
+		return nil, err
+	}
+	// nodes are stored in breadth-first order, each node is a run of zero bits,
+	// one for each child in the order of labels, followed by a one bit.
+	prefixes := []string{""}
+	var (
+		keys    []string
+		nodeID  int
+		childID int
+	)
+	for index := 0; index < len(labelBitmap)*64 && nodeID < len(prefixes); index++ {
+		if getBitmapBit(labelBitmap, index) {
+			if getBitmapBit(leaves, nodeID) {
+				keys = append(keys, prefixes[nodeID])
+			}
+			nodeID++
+			continue
+		}
+		if childID >= len(labels) {
+			return nil, E.New("bad label index")
+		}
+		prefixes = append(prefixes, prefixes[nodeID]+string(labels[childID:childID+1]))
+		childID++
+	}
+	return keys, nil
+}
+
+func readBitmap(reader io.Reader) ([]uint64, error) {
+	length, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := make([]uint64, length)
+	err = binary.Read(reader, binary.BigEndian, bitmap)
+	if err != nil {
+		return nil, err
+	}
+	return bitmap, nil
+}
+
+func getBitmapBit(bitmap []uint64, index int) bool {
+	if index>>6 >= len(bitmap) {
+		return false
+	}
+	return bitmap[index>>6]&(1<<(index&63)) != 0
+}
+
+func reverseDomain(domain string) string {
+	length := len(domain)
+	reversed := make([]byte, length)
+	for i := 0; i < length; {
+		r, n := utf8.DecodeRuneInString(domain[i:])
+		i += n
+		utf8.EncodeRune(reversed[length-i:], r)
+	}
+	return string(reversed)
+}
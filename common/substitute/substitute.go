@@ -0,0 +1,324 @@
+// Package substitute expands ${ENV:NAME} and ${FILE:path} references in JSON string values.
+package substitute
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	SourceEnv  = "ENV"
+	SourceFile = "FILE"
+)
+
+// minimumMaskLength avoids masking short values, which would replace unrelated text such as ports.
+const minimumMaskLength = 6
+
+// secretKeys are object keys whose substituted values are masked even if they come from the environment.
+var secretKeys = []string{"password", "secret", "token", "uuid", "key", "psk", "auth_str"}
+
+type Secret struct {
+	Expression string
+	Value      string
+}
+
+type substitution struct {
+	value   string
+	literal []byte
+}
+
+// Secrets records the substitutions made in a configuration.
+type Secrets struct {
+	// values masked in text
+	secrets []Secret
+	// JSON path -> substituted string
+	substitutions map[string]substitution
+}
+
+func (s *Secrets) IsEmpty() bool {
+	return s == nil || len(s.secrets) == 0 && len(s.substitutions) == 0
+}
+
+func (s *Secrets) Append(other *Secrets) {
+	if other.IsEmpty() {
+		return
+	}
+	s.secrets = append(s.secrets, other.secrets...)
+	if s.substitutions == nil {
+		s.substitutions = make(map[string]substitution)
+	}
+	for path, substitution := range other.substitutions {
+		s.substitutions[path] = substitution
+	}
+}
+
+// Mask replaces secret values in text with their expressions.
+func (s *Secrets) Mask(text string) string {
+	if s.IsEmpty() {
+		return text
+	}
+	secrets := append([]Secret(nil), s.secrets...)
+	// replace longer values first, so that a value containing another is masked as a whole
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return len(secrets[i].Value) > len(secrets[j].Value)
+	})
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret.Value, secret.Expression)
+	}
+	return text
+}
+
+func (s *Secrets) MaskError(err error) error {
+	if err == nil || s.IsEmpty() {
+		return err
+	}
+	masked := s.Mask(err.Error())
+	if masked == err.Error() {
+		return err
+	}
+	return E.New(masked)
+}
+
+// Restore replaces string values in encoded JSON that were produced by substitution with their original literals.
+// Only values at the same path and with the same content as the substituted ones are restored.
+func (s *Secrets) Restore(content []byte) []byte {
+	if s.IsEmpty() {
+		return content
+	}
+	var buffer bytes.Buffer
+	walkStrings(content, func(path string, literal []byte) []byte {
+		substitution, loaded := s.substitutions[path]
+		if !loaded {
+			return literal
+		}
+		var value string
+		if json.Unmarshal(literal, &value) != nil || value != substitution.value {
+			return literal
+		}
+		return substitution.literal
+	}, &buffer)
+	return buffer.Bytes()
+}
+
+// Expand resolves references in all string values of the JSON content.
+// Use $${ to write a literal ${.
+func Expand(content []byte) ([]byte, *Secrets, error) {
+	secrets := &Secrets{substitutions: make(map[string]substitution)}
+	var (
+		buffer    bytes.Buffer
+		expandErr error
+	)
+	walkStrings(content, func(path string, literal []byte) []byte {
+		if expandErr != nil || !bytes.Contains(literal, []byte("${")) {
+			return literal
+		}
+		expanded, changed, err := expandLiteral(literal, isSecretPath(path), secrets)
+		if err != nil {
+			expandErr = err
+			return literal
+		}
+		if changed {
+			var value string
+			if json.Unmarshal(expanded, &value) != nil {
+				expandErr = E.New("invalid string after substitution: ", string(literal))
+				return literal
+			}
+			secrets.substitutions[path] = substitution{value, literal}
+		}
+		return expanded
+	}, &buffer)
+	if expandErr != nil {
+		return nil, nil, expandErr
+	}
+	return buffer.Bytes(), secrets, nil
+}
+
+// isSecretPath reports whether the innermost object key of the path names a secret.
+func isSecretPath(path string) bool {
+	components := strings.Split(path, pathSeparator)
+	key := components[len(components)-1]
+	for i := len(components) - 1; i > 0; i-- {
+		if _, err := strconv.Atoi(key); err != nil {
+			break
+		}
+		key = components[i-1]
+	}
+	key = strings.ToLower(key)
+	for _, secretKey := range secretKeys {
+		if key == secretKey || strings.HasSuffix(key, "_"+secretKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func expandLiteral(literal []byte, secret bool, secrets *Secrets) ([]byte, bool, error) {
+	var (
+		output  bytes.Buffer
+		changed bool
+	)
+	content := string(literal)
+	for {
+		index := strings.Index(content, "${")
+		if index == -1 {
+			output.WriteString(content)
+			break
+		}
+		if index > 0 && content[index-1] == '$' {
+			output.WriteString(content[:index-1])
+			output.WriteString("${")
+			content = content[index+2:]
+			changed = true
+			continue
+		}
+		end := strings.IndexByte(content[index:], '}')
+		if end == -1 {
+			output.WriteString(content)
+			break
+		}
+		expression := content[index : index+end+1]
+		source, name, loaded := strings.Cut(expression[2:len(expression)-1], ":")
+		if !loaded || (source != SourceEnv && source != SourceFile) {
+			output.WriteString(content[:index+end+1])
+			content = content[index+end+1:]
+			continue
+		}
+		value, err := resolve(source, name)
+		if err != nil {
+			return nil, false, err
+		}
+		if (secret || source == SourceFile) && len(value) >= minimumMaskLength {
+			secrets.secrets = append(secrets.secrets, Secret{Expression: expression, Value: value})
+		}
+		encoded, _ := json.Marshal(value)
+		output.WriteString(content[:index])
+		output.Write(encoded[1 : len(encoded)-1])
+		content = content[index+end+1:]
+		changed = true
+	}
+	return output.Bytes(), changed, nil
+}
+
+func resolve(source string, name string) (string, error) {
+	if name == "" {
+		return "", E.New("missing name in ${", source, ":}")
+	}
+	switch source {
+	case SourceEnv:
+		value, loaded := os.LookupEnv(name)
+		if !loaded {
+			return "", E.New("environment variable not set: ", name)
+		}
+		return value, nil
+	default:
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return "", E.Cause(err, "read secret file")
+		}
+		value := strings.TrimSuffix(string(content), "\n")
+		return strings.TrimSuffix(value, "\r"), nil
+	}
+}
+
+// pathSeparator joins the object keys and array indexes of a JSON path.
+const pathSeparator = "\x00"
+
+type walkFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+	index     int
+}
+
+func walkPath(stack []walkFrame) string {
+	var path strings.Builder
+	for i, frame := range stack {
+		if i > 0 {
+			path.WriteString(pathSeparator)
+		}
+		if frame.object {
+			path.WriteString(frame.key)
+		} else {
+			path.WriteString(strconv.Itoa(frame.index))
+		}
+	}
+	return path.String()
+}
+
+// walkStrings copies content to buffer, passing every string value (with quotes) and its path through replace.
+// Object keys and comments are copied as is.
+func walkStrings(content []byte, replace func(path string, literal []byte) []byte, buffer *bytes.Buffer) {
+	var stack []walkFrame
+	for index := 0; index < len(content); {
+		switch {
+		case content[index] == '"':
+			end := index + 1
+			for end < len(content) && content[end] != '"' {
+				if content[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(content) {
+				buffer.Write(content[index:])
+				return
+			}
+			literal := content[index : end+1]
+			if len(stack) > 0 && stack[len(stack)-1].expectKey {
+				frame := &stack[len(stack)-1]
+				if json.Unmarshal(literal, &frame.key) != nil {
+					frame.key = string(literal)
+				}
+				frame.expectKey = false
+				buffer.Write(literal)
+			} else {
+				buffer.Write(replace(walkPath(stack), literal))
+			}
+			index = end + 1
+		case bytes.HasPrefix(content[index:], []byte("//")):
+			end := bytes.IndexByte(content[index:], '\n')
+			if end == -1 {
+				buffer.Write(content[index:])
+				return
+			}
+			buffer.Write(content[index : index+end])
+			index += end
+		case bytes.HasPrefix(content[index:], []byte("/*")):
+			end := bytes.Index(content[index+2:], []byte("*/"))
+			if end == -1 {
+				buffer.Write(content[index:])
+				return
+			}
+			buffer.Write(content[index : index+end+4])
+			index += end + 4
+		default:
+			switch content[index] {
+			case '{':
+				stack = append(stack, walkFrame{object: true, expectKey: true})
+			case '[':
+				stack = append(stack, walkFrame{})
+			case '}', ']':
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			case ',':
+				if len(stack) > 0 {
+					frame := &stack[len(stack)-1]
+					if frame.object {
+						frame.expectKey = true
+					} else {
+						frame.index++
+					}
+				}
+			}
+			buffer.WriteByte(content[index])
+			index++
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package substitute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("SING_BOX_TEST_PASSWORD", "pa\"ss")
+	secretPath := filepath.Join(t.TempDir(), "uuid")
+	require.NoError(t, os.WriteFile(secretPath, []byte("b831381d-6324-4d53-ad4f-8cda48b30811\n"), 0o600))
+	content := []byte(`{
+  // "${ENV:NOT_EXPANDED}"
+  "password": "${ENV:SING_BOX_TEST_PASSWORD}",
+  "uuid": "${FILE:` + secretPath + `}",
+  "header": "Bearer ${ENV:SING_BOX_TEST_PASSWORD}",
+  "escaped": "$${ENV:SING_BOX_TEST_PASSWORD}",
+  "other": "${OTHER:x}"
+}`)
+	expanded, secrets, err := Expand(content)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  // "${ENV:NOT_EXPANDED}"
+  "password": "pa\"ss",
+  "uuid": "b831381d-6324-4d53-ad4f-8cda48b30811",
+  "header": "Bearer pa\"ss",
+  "escaped": "${ENV:SING_BOX_TEST_PASSWORD}",
+  "other": "${OTHER:x}"
+}`, string(expanded))
+	require.Equal(t, "invalid uuid ${FILE:"+secretPath+"}", secrets.Mask("invalid uuid b831381d-6324-4d53-ad4f-8cda48b30811"))
+	require.Equal(t, `{"header":"Bearer ${ENV:SING_BOX_TEST_PASSWORD}","password":"${ENV:SING_BOX_TEST_PASSWORD}"}`,
+		string(secrets.Restore([]byte(`{"header":"Bearer pa\"ss","password":"pa\"ss"}`))))
+}
+
+func TestExpandMissing(t *testing.T) {
+	_, _, err := Expand([]byte(`{"password": "${ENV:SING_BOX_TEST_NOT_SET}"}`))
+	require.ErrorContains(t, err, "environment variable not set: SING_BOX_TEST_NOT_SET")
+	_, _, err = Expand([]byte(`{"password": "${FILE:/nonexistent/secret}"}`))
+	require.Error(t, err)
+}
+
+func TestMask(t *testing.T) {
+	t.Setenv("SING_BOX_TEST_SERVER", "example.org")
+	t.Setenv("SING_BOX_TEST_PASSWORD", "password")
+	t.Setenv("SING_BOX_TEST_SHORT", "abc")
+	t.Setenv("SING_BOX_TEST_KEY", "private key line")
+	_, secrets, err := Expand([]byte(`{
+  "server": "${ENV:SING_BOX_TEST_SERVER}",
+  "users": [{"password": "${ENV:SING_BOX_TEST_PASSWORD}"}],
+  "secret": "${ENV:SING_BOX_TEST_SHORT}",
+  "private_key": ["${ENV:SING_BOX_TEST_KEY}"]
+}`))
+	require.NoError(t, err)
+	require.Equal(t, "dial example.org: bad ${ENV:SING_BOX_TEST_PASSWORD}, abc, ${ENV:SING_BOX_TEST_KEY}",
+		secrets.Mask("dial example.org: bad password, abc, private key line"))
+}
+
+func TestRestorePath(t *testing.T) {
+	t.Setenv("SING_BOX_TEST_PASSWORD", "password")
+	_, secrets, err := Expand([]byte(`{"users": [{"name": "password"}, {"password": "${ENV:SING_BOX_TEST_PASSWORD}"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "users": [
+    {
+      "name": "password"
+    },
+    {
+      "name": "password",
+      "password": "${ENV:SING_BOX_TEST_PASSWORD}"
+    }
+  ],
+  "password": "password"
+}`, string(secrets.Restore([]byte(`{
+  "users": [
+    {
+      "name": "password"
+    },
+    {
+      "name": "password",
+      "password": "password"
+    }
+  ],
+  "password": "password"
+}`))))
+	require.Equal(t, `{"users":[{"name":"password"},{"password":"changed"}]}`,
+		string(secrets.Restore([]byte(`{"users":[{"name":"password"},{"password":"changed"}]}`))))
+}
@@ -29,6 +29,7 @@ const (
 const (
 	TypeSelector = "selector"
 	TypeURLTest  = "urltest"
+	TypeRace     = "race"
 )
 
 const TypeJSTest = "jstest"
@@ -77,6 +78,8 @@ func ProxyDisplayName(proxyType string) string {
 		return "Selector"
 	case TypeURLTest:
 		return "URLTest"
+	case TypeRace:
+		return "Race"
 	case TypeJSTest:
 		return "JSTest"
 	default:
@@ -11,9 +11,22 @@ const (
 )
 
 const (
-	RuleSetTypeLocal    = "local"
-	RuleSetTypeRemote   = "remote"
-	RuleSetVersion1     = 1
-	RuleSetFormatSource = "source"
-	RuleSetFormatBinary = "binary"
+	RuleSetTypeInline    = "inline"
+	RuleSetTypeLocal     = "local"
+	RuleSetTypeRemote    = "remote"
+	RuleSetVersion1      = 1
+	RuleSetVersion2      = 2
+	RuleSetFormatSource  = "source"
+	RuleSetFormatBinary  = "binary"
+	RuleSetFormatClash   = "clash"
+	RuleSetFormatAdGuard = "adguard"
+	RuleSetFormatDnsmasq = "dnsmasq"
+	RuleSetFormatHosts   = "hosts"
+)
+
+const (
+	NetworkTypeWIFI     = "wifi"
+	NetworkTypeCellular = "cellular"
+	NetworkTypeEthernet = "ethernet"
+	NetworkTypeOther    = "other"
 )
@@ -0,0 +1,77 @@
+package clashapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sagernet/sing-box/common/capture"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func captureRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getCapture(ctx))
+	r.Post("/", startCapture(ctx))
+	r.Delete("/", stopCapture(ctx))
+	return r
+}
+
+func getCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		manager := service.PtrFromContext[capture.Manager](ctx)
+		if manager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.JSON(w, r, manager.Status())
+	}
+}
+
+func startCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		manager := service.PtrFromContext[capture.Manager](ctx)
+		if manager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		var options capture.Options
+		err := render.DecodeJSON(r.Body, &options)
+		if err != nil && !errors.Is(err, io.EOF) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		status, err := manager.StartCapture(options)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.JSON(w, r, status)
+	}
+}
+
+func stopCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		manager := service.PtrFromContext[capture.Manager](ctx)
+		if manager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		status, err := manager.StopCapture()
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.JSON(w, r, status)
+	}
+}
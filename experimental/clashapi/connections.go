@@ -90,11 +90,40 @@ func closeConnection(trafficManager *trafficontrol.Manager) func(w http.Response
 
 func closeAllConnections(router adapter.Router, trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		snapshot := trafficManager.Snapshot()
-		for _, c := range snapshot.Connections {
-			c.Close()
+		query := r.URL.Query()
+		filter := trafficontrol.Filter{
+			User:     query["user"],
+			Outbound: query["outbound"],
+			Rule:     query["rule"],
+			Domain:   query["domain"],
+			Inbound:  query["inbound"],
+			Process:  query["process"],
 		}
-		router.ResetNetwork()
-		render.NoContent(w, r)
+		var reroute bool
+		if rerouteStr := query.Get("reroute"); rerouteStr != "" {
+			var err error
+			reroute, err = strconv.ParseBool(rerouteStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, ErrBadRequest)
+				return
+			}
+		}
+		if filter.IsEmpty() && !reroute {
+			snapshot := trafficManager.Snapshot()
+			for _, c := range snapshot.Connections {
+				c.Close()
+			}
+			router.ResetNetwork()
+			render.NoContent(w, r)
+			return
+		}
+		var currentRouter adapter.Router
+		if reroute {
+			currentRouter = router
+		}
+		render.JSON(w, r, render.M{
+			"closed": trafficManager.CloseMatched(filter, currentRouter),
+		})
 	}
 }
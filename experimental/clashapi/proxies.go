@@ -80,6 +80,9 @@ func proxyInfo(server *Server, detour adapter.Outbound) *badjson.JSONObject {
 		info.Put("now", group.Now())
 		info.Put("all", group.All())
 	}
+	if race, isRace := detour.(*outbound.Race); isRace {
+		info.Put("race", race.Statistics())
+	}
 	return &info
 }
 
@@ -1,9 +1,11 @@
 package clashapi
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -12,6 +14,7 @@ import (
 func ruleRouter(router adapter.Router) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", getRules(router))
+	r.Post("/explain", explainRule(router))
 	return r
 }
 
@@ -39,3 +42,24 @@ func getRules(router adapter.Router) func(w http.ResponseWriter, r *http.Request
 		})
 	}
 }
+
+func explainRule(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request adapter.RouteExplainRequest
+		err := render.DecodeJSON(r.Body, &request)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), C.DNSTimeout)
+		defer cancel()
+		explanation, err := router.Explain(ctx, request)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.JSON(w, r, explanation)
+	}
+}
@@ -0,0 +1,130 @@
+package trafficontrol
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing/common"
+)
+
+// Filter selects tracked connections. Values of a field are alternatives, and all non-empty fields must match.
+type Filter struct {
+	User     []string
+	Outbound []string
+	Rule     []string
+	Domain   []string
+	Inbound  []string
+	Process  []string
+}
+
+func (f *Filter) IsEmpty() bool {
+	return len(f.User) == 0 && len(f.Outbound) == 0 && len(f.Rule) == 0 &&
+		len(f.Domain) == 0 && len(f.Inbound) == 0 && len(f.Process) == 0
+}
+
+func (f *Filter) match(info *trackerInfo) bool {
+	metadata := &info.inboundContext
+	if len(f.User) > 0 && !common.Contains(f.User, metadata.User) {
+		return false
+	}
+	if len(f.Inbound) > 0 && !common.Contains(f.Inbound, metadata.Inbound) {
+		return false
+	}
+	if len(f.Outbound) > 0 && !common.Any(info.currentRoute(), func(it string) bool {
+		return common.Contains(f.Outbound, it)
+	}) {
+		return false
+	}
+	if len(f.Rule) > 0 {
+		rule := "final"
+		if info.rule != nil {
+			rule = info.rule.String()
+		}
+		if !common.Contains(f.Rule, rule) && !common.Contains(f.Rule, info.Rule) {
+			return false
+		}
+	}
+	if len(f.Domain) > 0 {
+		domain := metadata.Domain
+		if domain == "" {
+			domain = metadata.Destination.Fqdn
+		}
+		if !matchDomainSuffix(f.Domain, domain) {
+			return false
+		}
+	}
+	if len(f.Process) > 0 && !matchProcess(f.Process, metadata.ProcessInfo) {
+		return false
+	}
+	return true
+}
+
+// CloseMatched closes connections matching the filter and returns the number closed.
+// With a router, only connections whose route would change under the current rules and selections are closed.
+func (m *Manager) CloseMatched(filter Filter, router adapter.Router) int {
+	var closed int
+	m.connections.Range(func(_ string, value tracker) bool {
+		var info *trackerInfo
+		switch connection := value.(type) {
+		case *tcpTracker:
+			info = connection.trackerInfo
+		case *udpTracker:
+			info = connection.trackerInfo
+		default:
+			return true
+		}
+		if !filter.match(info) {
+			return true
+		}
+		if router != nil && equalRoute(info.matchedRoute, router.RouteChain(info.inboundContext)) {
+			return true
+		}
+		value.Close()
+		closed++
+		return true
+	})
+	return closed
+}
+
+func equalRoute(route []string, newRoute []string) bool {
+	if len(route) != len(newRoute) {
+		return false
+	}
+	for i := range route {
+		if route[i] != newRoute[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchDomainSuffix(suffixes []string, domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" {
+		return false
+	}
+	for _, suffix := range suffixes {
+		suffix = strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(suffix, "."), "."))
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchProcess(processes []string, processInfo *process.Info) bool {
+	if processInfo == nil {
+		return false
+	}
+	for _, name := range processes {
+		if processInfo.ProcessPath != "" && (name == processInfo.ProcessPath || name == filepath.Base(processInfo.ProcessPath)) {
+			return true
+		}
+		if processInfo.PackageName != "" && name == processInfo.PackageName {
+			return true
+		}
+	}
+	return false
+}
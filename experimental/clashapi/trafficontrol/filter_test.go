@@ -0,0 +1,171 @@
+package trafficontrol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing/common/bufio"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type testRule struct {
+	adapter.Rule
+	name string
+}
+
+func (r *testRule) String() string {
+	return r.name
+}
+
+type testRouter struct {
+	adapter.Router
+	routes map[string][]string
+}
+
+func (r *testRouter) RouteChain(metadata adapter.InboundContext) []string {
+	return r.routes[metadata.User]
+}
+
+func newTestTrackerInfo(metadata adapter.InboundContext, rule adapter.Rule, route ...string) *trackerInfo {
+	info := &trackerInfo{
+		inboundContext: metadata,
+		rule:           rule,
+		matchedRoute:   route,
+		route:          route,
+	}
+	info.UUID, _ = uuid.NewV4()
+	if rule != nil {
+		info.Rule = rule.String()
+	}
+	return info
+}
+
+func TestFilterIsEmpty(t *testing.T) {
+	t.Parallel()
+	require.True(t, (&Filter{}).IsEmpty())
+	for _, filter := range []Filter{
+		{User: []string{"a"}},
+		{Outbound: []string{"a"}},
+		{Rule: []string{"a"}},
+		{Domain: []string{"a"}},
+		{Inbound: []string{"a"}},
+		{Process: []string{"a"}},
+	} {
+		require.False(t, filter.IsEmpty(), filter)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	t.Parallel()
+	info := newTestTrackerInfo(adapter.InboundContext{
+		Inbound:     "mixed-in",
+		User:        "alice",
+		Domain:      "www.Example.com",
+		Destination: M.ParseSocksaddr("1.1.1.1:443"),
+		ProcessInfo: &process.Info{ProcessPath: "/usr/bin/curl", UserId: -1},
+	}, &testRule{name: "domain_suffix=example.com"}, "hop", "select", "proxy")
+	finalInfo := newTestTrackerInfo(adapter.InboundContext{
+		Destination: M.Socksaddr{Fqdn: "api.example.org.", Port: 443},
+		ProcessInfo: &process.Info{PackageName: "com.example.app", UserId: -1},
+	}, nil, "direct")
+	testCases := []struct {
+		name   string
+		filter Filter
+		info   *trackerInfo
+		match  bool
+	}{
+		{"empty", Filter{}, info, true},
+		{"user", Filter{User: []string{"bob", "alice"}}, info, true},
+		{"user mismatch", Filter{User: []string{"bob"}}, info, false},
+		{"inbound", Filter{Inbound: []string{"mixed-in"}}, info, true},
+		{"inbound mismatch", Filter{Inbound: []string{"tun-in"}}, info, false},
+		{"outbound hop", Filter{Outbound: []string{"hop"}}, info, true},
+		{"outbound group member", Filter{Outbound: []string{"proxy"}}, info, true},
+		{"outbound mismatch", Filter{Outbound: []string{"direct"}}, info, false},
+		{"rule", Filter{Rule: []string{"domain_suffix=example.com"}}, info, true},
+		{"rule mismatch", Filter{Rule: []string{"final"}}, info, false},
+		{"rule final", Filter{Rule: []string{"final"}}, finalInfo, true},
+		{"domain suffix", Filter{Domain: []string{"example.com"}}, info, true},
+		{"domain exact", Filter{Domain: []string{".www.example.com."}}, info, true},
+		{"domain partial label", Filter{Domain: []string{"ample.com"}}, info, false},
+		{"domain from destination", Filter{Domain: []string{"EXAMPLE.org"}}, finalInfo, true},
+		{"domain without domain", Filter{Domain: []string{"1.1.1.1"}}, newTestTrackerInfo(adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:443")}, nil), false},
+		{"process path", Filter{Process: []string{"/usr/bin/curl"}}, info, true},
+		{"process name", Filter{Process: []string{"curl"}}, info, true},
+		{"process package", Filter{Process: []string{"com.example.app"}}, finalInfo, true},
+		{"process mismatch", Filter{Process: []string{"wget"}}, info, false},
+		{"process unknown", Filter{Process: []string{"curl"}}, newTestTrackerInfo(adapter.InboundContext{}, nil), false},
+		{"all fields", Filter{User: []string{"alice"}, Outbound: []string{"proxy"}, Domain: []string{"example.com"}, Process: []string{"curl"}}, info, true},
+		{"one field mismatch", Filter{User: []string{"alice"}, Outbound: []string{"direct"}}, info, false},
+	}
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.match, testCase.filter.match(testCase.info), testCase.name)
+	}
+}
+
+func TestCloseMatched(t *testing.T) {
+	t.Parallel()
+	manager := &Manager{}
+	newTracker := func(metadata adapter.InboundContext, route ...string) *tcpTracker {
+		client, server := net.Pipe()
+		t.Cleanup(func() {
+			client.Close()
+			server.Close()
+		})
+		tracker := &tcpTracker{
+			ExtendedConn: bufio.NewExtendedConn(server),
+			trackerInfo:  newTestTrackerInfo(metadata, nil, route...),
+			manager:      manager,
+		}
+		manager.Join(tracker)
+		return tracker
+	}
+	newTracker(adapter.InboundContext{User: "alice"}, "select", "a")
+	bob := newTracker(adapter.InboundContext{User: "bob"}, "select", "b")
+	direct := newTracker(adapter.InboundContext{User: "alice"}, "direct")
+	connections := func() []string {
+		var ids []string
+		manager.connections.Range(func(id string, _ tracker) bool {
+			ids = append(ids, id)
+			return true
+		})
+		return ids
+	}
+
+	router := &testRouter{routes: map[string][]string{
+		"alice": {"select", "a"},
+		"bob":   {"select", "b"},
+	}}
+	require.Equal(t, 0, manager.CloseMatched(Filter{Outbound: []string{"select"}}, router))
+	require.Len(t, connections(), 3)
+
+	router.routes["alice"] = []string{"select", "b"}
+	require.Equal(t, 1, manager.CloseMatched(Filter{Outbound: []string{"select"}}, router))
+	require.ElementsMatch(t, []string{bob.ID(), direct.ID()}, connections())
+
+	require.Equal(t, 1, manager.CloseMatched(Filter{User: []string{"alice"}}, nil))
+	require.ElementsMatch(t, []string{bob.ID()}, connections())
+
+	// moved to a retry outbound, the matched route is still current
+	bob.UpdateChain([]string{"fallback"})
+	require.False(t, (&Filter{Outbound: []string{"select"}}).match(bob.trackerInfo))
+	require.True(t, (&Filter{Outbound: []string{"fallback"}}).match(bob.trackerInfo))
+	require.Equal(t, 0, manager.CloseMatched(Filter{User: []string{"bob"}}, router))
+	require.ElementsMatch(t, []string{bob.ID()}, connections())
+	router.routes["bob"] = []string{"select", "a"}
+	require.Equal(t, 1, manager.CloseMatched(Filter{User: []string{"bob"}}, router))
+	require.Empty(t, connections())
+}
+
+func TestEqualRoute(t *testing.T) {
+	t.Parallel()
+	require.True(t, equalRoute(nil, nil))
+	require.True(t, equalRoute([]string{"a", "b"}, []string{"a", "b"}))
+	require.False(t, equalRoute([]string{"a", "b"}, []string{"b", "a"}))
+	require.False(t, equalRoute([]string{"a"}, []string{"a", "b"}))
+}
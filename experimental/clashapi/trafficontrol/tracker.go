@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net"
 	"net/netip"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
@@ -42,9 +43,33 @@ type trackerInfo struct {
 	Chain         []string      `json:"chains"`
 	Rule          string        `json:"rule"`
 	RulePayload   string        `json:"rulePayload"`
+
+	inboundContext adapter.InboundContext
+	rule           adapter.Rule
+	// rule hops and outbound chain selected when the connection was routed
+	matchedRoute []string
+	// matchedRoute, or the outbound chain of the retry outbound the connection was moved to
+	route  []string
+	access sync.Mutex
+}
+
+// UpdateChain replaces the displayed chain, which is given from the routed outbound to the final member.
+func (t *trackerInfo) UpdateChain(chain []string) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	t.Chain = common.Reverse(chain)
+	t.route = append([]string(nil), chain...)
 }
 
-func (t trackerInfo) MarshalJSON() ([]byte, error) {
+func (t *trackerInfo) currentRoute() []string {
+	t.access.Lock()
+	defer t.access.Unlock()
+	return t.route
+}
+
+func (t *trackerInfo) MarshalJSON() ([]byte, error) {
+	t.access.Lock()
+	defer t.access.Unlock()
 	return json.Marshal(map[string]any{
 		"id":          t.UUID.String(),
 		"metadata":    t.Metadata,
@@ -88,7 +113,7 @@ func (tt *tcpTracker) WriterReplaceable() bool {
 	return true
 }
 
-func NewTCPTracker(conn net.Conn, manager *Manager, metadata Metadata, router adapter.Router, rule adapter.Rule) *tcpTracker {
+func NewTCPTracker(conn net.Conn, manager *Manager, metadata Metadata, inboundContext adapter.InboundContext, router adapter.Router, rule adapter.Rule) *tcpTracker {
 	uuid, _ := uuid.NewV4()
 
 	var chain []string
@@ -112,6 +137,11 @@ func NewTCPTracker(conn net.Conn, manager *Manager, metadata Metadata, router ad
 		}
 		next = group.Now()
 	}
+	var route []string
+	if rule != nil {
+		route = append(route, rule.Chain()...)
+	}
+	route = append(route, chain...)
 
 	upload := new(atomic.Int64)
 	download := new(atomic.Int64)
@@ -133,6 +163,11 @@ func NewTCPTracker(conn net.Conn, manager *Manager, metadata Metadata, router ad
 			Rule:          "",
 			UploadTotal:   upload,
 			DownloadTotal: download,
+
+			inboundContext: inboundContext,
+			rule:           rule,
+			matchedRoute:   route,
+			route:          route,
 		},
 	}
 
@@ -177,7 +212,7 @@ func (ut *udpTracker) WriterReplaceable() bool {
 	return true
 }
 
-func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata Metadata, router adapter.Router, rule adapter.Rule) *udpTracker {
+func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata Metadata, inboundContext adapter.InboundContext, router adapter.Router, rule adapter.Rule) *udpTracker {
 	uuid, _ := uuid.NewV4()
 
 	var chain []string
@@ -201,6 +236,11 @@ func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata Metadata, route
 		}
 		next = group.Now()
 	}
+	var route []string
+	if rule != nil {
+		route = append(route, rule.Chain()...)
+	}
+	route = append(route, chain...)
 
 	upload := new(atomic.Int64)
 	download := new(atomic.Int64)
@@ -222,6 +262,11 @@ func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata Metadata, route
 			Rule:          "",
 			UploadTotal:   upload,
 			DownloadTotal: download,
+
+			inboundContext: inboundContext,
+			rule:           rule,
+			matchedRoute:   route,
+			route:          route,
 		},
 	}
 
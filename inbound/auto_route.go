@@ -0,0 +1,55 @@
+package inbound
+
+import (
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/redir"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+func newAutoRoute(router adapter.Router, tag string, tproxy bool, network []string, listenOptions option.ListenOptions, options option.AutoRouteOptions) (*redir.AutoRoute, error) {
+	if listenOptions.ListenPort == 0 {
+		return nil, E.New("auto_route requires listen_port")
+	}
+	return redir.NewAutoRoute(redir.AutoRouteOptions{
+		Tag:            tag,
+		TProxy:         tproxy,
+		Network:        network,
+		Address:        listenOptions.Listen.Build(),
+		Port:           listenOptions.ListenPort,
+		Inet6:          options.AutoRouteInet6,
+		DefaultMark:    uint32(router.DefaultMark()),
+		TableIndex:     options.IPRoute2TableIndex,
+		RuleIndex:      options.IPRoute2RuleIndex,
+		ExcludeAddress: common.Map(options.RouteExcludeAddress, netip.Prefix.Masked),
+	})
+}
+
+// CheckAutoRoute rejects tproxy inbounds with auto_route sharing a route table, which is also their packet mark,
+// since the policy routes of one would be removed when the other is closed.
+func CheckAutoRoute(inbounds []option.Inbound) error {
+	tableUsers := make(map[int]string)
+	for i, inbound := range inbounds {
+		if inbound.Type != C.TypeTProxy || !inbound.TProxyOptions.AutoRoute {
+			continue
+		}
+		tag := inbound.Tag
+		if tag == "" {
+			tag = F.ToString(i)
+		}
+		tableIndex := inbound.TProxyOptions.IPRoute2TableIndex
+		if tableIndex == 0 {
+			tableIndex = redir.DefaultAutoRouteTableIndex
+		}
+		if user, loaded := tableUsers[tableIndex]; loaded {
+			return E.New("inbound/tproxy[", tag, "]: iproute2_table_index ", tableIndex, " is already used by inbound/tproxy[", user, "], set another one")
+		}
+		tableUsers[tableIndex] = tag
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package inbound
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAutoRoute(t *testing.T) {
+	t.Parallel()
+	tproxy := func(tag string, autoRoute bool, tableIndex int) option.Inbound {
+		return option.Inbound{Type: C.TypeTProxy, Tag: tag, TProxyOptions: option.TProxyInboundOptions{
+			AutoRouteOptions: option.AutoRouteOptions{AutoRoute: autoRoute, IPRoute2TableIndex: tableIndex},
+		}}
+	}
+	redirect := option.Inbound{Type: C.TypeRedirect, Tag: "redirect-in", RedirectOptions: option.RedirectInboundOptions{
+		AutoRouteOptions: option.AutoRouteOptions{AutoRoute: true},
+	}}
+	require.NoError(t, CheckAutoRoute([]option.Inbound{tproxy("a", true, 0), tproxy("b", true, 2024), tproxy("c", false, 0), redirect}))
+	require.ErrorContains(t, CheckAutoRoute([]option.Inbound{tproxy("a", true, 0), tproxy("", true, 2023)}), "inbound/tproxy[1]: iproute2_table_index 2023 is already used by inbound/tproxy[a]")
+	require.ErrorContains(t, CheckAutoRoute([]option.Inbound{tproxy("a", true, 2030), redirect, tproxy("b", true, 2030)}), "inbound/tproxy[b]")
+}
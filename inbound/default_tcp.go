@@ -1,11 +1,22 @@
 package inbound
 
 import (
+	"bytes"
 	"context"
 	"net"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
@@ -81,3 +92,233 @@ func (a *myInboundAdapter) routeTCP(ctx context.Context, conn net.Conn, metadata
 		a.NewError(ctx, E.Cause(hErr, "process connection from ", metadata.Source))
 	}
 }
+
+type inboundFallback struct {
+	destination M.Socksaddr
+	alpn        []string
+	serverName  []string
+	path        []string
+}
+
+func (f inboundFallback) match(alpn string, serverName string, path string) bool {
+	if len(f.alpn) > 0 && !common.Contains(f.alpn, alpn) {
+		return false
+	}
+	if len(f.serverName) > 0 && !common.Contains(f.serverName, serverName) {
+		return false
+	}
+	if len(f.path) > 0 && !common.Any(f.path, func(it string) bool {
+		return strings.HasPrefix(path, it)
+	}) {
+		return false
+	}
+	return true
+}
+
+func (a *myInboundAdapter) initFallback(options []option.InboundFallbackOptions, tlsEnabled bool) error {
+	if len(options) == 0 {
+		return nil
+	}
+	for i, fallbackOptions := range options {
+		destination := fallbackOptions.Build()
+		if !destination.IsValid() {
+			return E.New("invalid fallback[", i, "] address: ", destination)
+		}
+		if !tlsEnabled && (len(fallbackOptions.ALPN) > 0 || len(fallbackOptions.ServerName) > 0) {
+			return E.New("fallback[", i, "]: fallback for ALPN or server name is not supported without TLS")
+		}
+		a.fallbacks = append(a.fallbacks, inboundFallback{
+			destination: destination,
+			alpn:        fallbackOptions.ALPN,
+			serverName:  fallbackOptions.ServerName,
+			path:        fallbackOptions.Path,
+		})
+	}
+	a.router = &fallbackRouter{a.router}
+	return nil
+}
+
+func (a *myInboundAdapter) selectFallback(alpn string, serverName string, path string) (M.Socksaddr, bool) {
+	for _, fallback := range a.fallbacks {
+		if fallback.match(alpn, serverName, path) {
+			return fallback.destination, true
+		}
+	}
+	return M.Socksaddr{}, false
+}
+
+// newConnectionWithFallback records the client stream until the protocol routes it,
+// and forwards the recorded bytes plus the rest of the stream to a fallback server otherwise.
+func (a *myInboundAdapter) newConnectionWithFallback(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, handler func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error) error {
+	if len(a.fallbacks) == 0 {
+		return handler(ctx, conn, metadata)
+	}
+	recordConn := &fallbackConn{Conn: conn}
+	hErr := handler(context.WithValue(ctx, fallbackContextKey{}, recordConn), recordConn, metadata)
+	cached, loaded := recordConn.takeCache()
+	if !loaded {
+		return hErr
+	}
+	var alpn, serverName string
+	if tlsConn, isTLS := common.Cast[tls.Conn](conn); isTLS {
+		connectionState := tlsConn.ConnectionState()
+		alpn = connectionState.NegotiatedProtocol
+		serverName = connectionState.ServerName
+	}
+	var path string
+	if common.Any(a.fallbacks, func(it inboundFallback) bool {
+		return len(it.path) > 0
+	}) {
+		cached = readRequestLine(conn, cached)
+		path = readHTTPPath(cached)
+	}
+	destination, loaded := a.selectFallback(alpn, serverName, path)
+	if !loaded {
+		return hErr
+	}
+	if hErr != nil {
+		a.logger.DebugContext(ctx, E.Cause(hErr, "process connection from ", metadata.Source))
+	}
+	a.logger.InfoContext(ctx, "fallback connection to ", destination)
+	if len(cached) > 0 {
+		conn = bufio.NewCachedConn(conn, buf.As(cached))
+	}
+	metadata.Destination = destination
+	return a.router.RouteConnection(ctx, conn, metadata)
+}
+
+// readRequestLine completes the cached request line, since protocols may reject the
+// connection after reading only a few bytes of it.
+func readRequestLine(conn net.Conn, cached []byte) []byte {
+	if bytes.Contains(cached, []byte("\r\n")) {
+		return cached
+	}
+	conn.SetReadDeadline(time.Now().Add(C.TCPTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	buffer := make([]byte, 1024)
+	for len(cached) < fallbackCacheSize && !bytes.Contains(cached, []byte("\r\n")) {
+		n, err := conn.Read(buffer)
+		cached = append(cached, buffer[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	return cached
+}
+
+func readHTTPPath(request []byte) string {
+	requestLine, _, loaded := bytes.Cut(request, []byte("\r\n"))
+	if !loaded {
+		return ""
+	}
+	fields := strings.Fields(string(requestLine))
+	if len(fields) != 3 || !strings.HasPrefix(fields[2], "HTTP/") {
+		return ""
+	}
+	return fields[1]
+}
+
+const fallbackCacheSize = buf.BufferSize
+
+type fallbackConn struct {
+	net.Conn
+	access     sync.Mutex
+	routed     atomic.Bool
+	readCache  []byte
+	writeCache []byte
+	overflow   bool
+}
+
+func (c *fallbackConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 && !c.routed.Load() {
+		c.access.Lock()
+		if !c.routed.Load() {
+			if len(c.readCache)+n > fallbackCacheSize {
+				c.overflow = true
+			} else {
+				c.readCache = append(c.readCache, p[:n]...)
+			}
+		}
+		c.access.Unlock()
+	}
+	return
+}
+
+func (c *fallbackConn) Write(p []byte) (n int, err error) {
+	if !c.routed.Load() {
+		c.access.Lock()
+		if !c.routed.Load() {
+			// hold responses until the protocol accepts the connection
+			c.writeCache = append(c.writeCache, p...)
+			c.access.Unlock()
+			return len(p), nil
+		}
+		c.access.Unlock()
+	}
+	return c.Conn.Write(p)
+}
+
+func (c *fallbackConn) markRouted() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.routed.Load() {
+		return nil
+	}
+	var err error
+	if len(c.writeCache) > 0 {
+		_, err = c.Conn.Write(c.writeCache)
+	}
+	c.readCache = nil
+	c.writeCache = nil
+	c.routed.Store(true)
+	return err
+}
+
+func (c *fallbackConn) takeCache() ([]byte, bool) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.routed.Load() {
+		return nil, false
+	}
+	c.routed.Store(true)
+	return c.readCache, !c.overflow
+}
+
+func (c *fallbackConn) Upstream() any {
+	return c.Conn
+}
+
+func (c *fallbackConn) ReaderReplaceable() bool {
+	return c.routed.Load()
+}
+
+func (c *fallbackConn) WriterReplaceable() bool {
+	return c.routed.Load()
+}
+
+type fallbackContextKey struct{}
+
+type fallbackRouter struct {
+	adapter.ConnectionRouter
+}
+
+func (r *fallbackRouter) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	if recordConn, loaded := ctx.Value(fallbackContextKey{}).(*fallbackConn); loaded {
+		err := recordConn.markRouted()
+		if err != nil {
+			return err
+		}
+	}
+	return r.ConnectionRouter.RouteConnection(ctx, conn, metadata)
+}
+
+func (r *fallbackRouter) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	if recordConn, loaded := ctx.Value(fallbackContextKey{}).(*fallbackConn); loaded {
+		err := recordConn.markRouted()
+		if err != nil {
+			return err
+		}
+	}
+	return r.ConnectionRouter.RoutePacketConnection(ctx, conn, metadata)
+}
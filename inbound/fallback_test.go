@@ -0,0 +1,148 @@
+package inbound
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fallbackTestRoute struct {
+	destination M.Socksaddr
+	conn        net.Conn
+	done        chan struct{}
+}
+
+type fallbackTestRouter struct {
+	adapter.Router
+	routes chan fallbackTestRoute
+}
+
+func (r *fallbackTestRouter) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	done := make(chan struct{})
+	r.routes <- fallbackTestRoute{metadata.Destination, conn, done}
+	<-done
+	return nil
+}
+
+var fallbackTestOptions = []option.InboundFallbackOptions{
+	{
+		ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: 8081},
+		Path:          []string{"/api"},
+	},
+	{
+		ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: 8080},
+	},
+}
+
+func newFallbackTestInbounds(t *testing.T, router adapter.Router) map[string]adapter.InjectableInbound {
+	logger := log.NewNOPFactory().Logger()
+	options := option.HTTPMixedInboundOptions{Fallbacks: fallbackTestOptions}
+	http, err := NewHTTP(context.Background(), router, logger, "http-in", options)
+	require.NoError(t, err)
+	mixed, err := NewMixed(context.Background(), router, logger, "mixed-in", options)
+	require.NoError(t, err)
+	return map[string]adapter.InjectableInbound{
+		"http":  http,
+		"mixed": mixed,
+	}
+}
+
+// serveFallbackTest runs the inbound on one end of a pipe, sends request from the other end,
+// and returns the route taken with everything the client received once the connection is closed.
+func serveFallbackTest(t *testing.T, inbound adapter.InjectableInbound, router *fallbackTestRouter, request ...[]byte) (fallbackTestRoute, chan []byte) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+	})
+	go func() {
+		inbound.NewConnection(context.Background(), server, adapter.InboundContext{})
+		server.Close()
+	}()
+	received := make(chan []byte, 1)
+	go func() {
+		content, _ := io.ReadAll(client)
+		received <- content
+	}()
+	for _, content := range request {
+		_, err := client.Write(content)
+		require.NoError(t, err)
+	}
+	select {
+	case route := <-router.routes:
+		return route, received
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not routed")
+		return fallbackTestRoute{}, nil
+	}
+}
+
+func TestFallbackHTTPRequest(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		request     string
+		destination string
+	}{
+		{"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", "127.0.0.1:8080"},
+		{"GET /api/v1 HTTP/1.1\r\nHost: example.com\r\n\r\n", "127.0.0.1:8081"},
+		{"POST /apis HTTP/1.1\r\nHost: example.com\r\nContent-Length: 0\r\n\r\n", "127.0.0.1:8081"},
+	} {
+		router := &fallbackTestRouter{routes: make(chan fallbackTestRoute)}
+		for name, inbound := range newFallbackTestInbounds(t, router) {
+			route, received := serveFallbackTest(t, inbound, router, []byte(testCase.request))
+			require.Equal(t, testCase.destination, route.destination.String(), name)
+			replayed := make([]byte, len(testCase.request))
+			_, err := io.ReadFull(route.conn, replayed)
+			require.NoError(t, err, name)
+			require.Equal(t, testCase.request, string(replayed), name)
+			close(route.done)
+			// the error response of the proxy protocol is dropped
+			require.Empty(t, <-received, name)
+		}
+	}
+}
+
+func TestFallbackHTTPProxy(t *testing.T) {
+	t.Parallel()
+	router := &fallbackTestRouter{routes: make(chan fallbackTestRoute)}
+	for name, inbound := range newFallbackTestInbounds(t, router) {
+		route, received := serveFallbackTest(t, inbound, router, []byte("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n"))
+		require.Equal(t, "example.com:443", route.destination.String(), name)
+		close(route.done)
+		require.Contains(t, string(<-received), " 200 ", name)
+	}
+}
+
+func TestFallbackMixedSocks(t *testing.T) {
+	t.Parallel()
+	router := &fallbackTestRouter{routes: make(chan fallbackTestRoute)}
+	inbound := newFallbackTestInbounds(t, router)["mixed"]
+	request := []byte{5, 1, 0, 3, byte(len("example.com"))}
+	request = append(request, "example.com"...)
+	request = append(request, 1, 187)
+	route, received := serveFallbackTest(t, inbound, router, []byte{5, 1, 0}, request)
+	require.Equal(t, "example.com:443", route.destination.String())
+	close(route.done)
+	reply := <-received
+	require.GreaterOrEqual(t, len(reply), 4)
+	require.Equal(t, []byte{5, 0, 5, 0}, reply[:4])
+}
+
+func TestFallbackMixedRejectsTLSMatch(t *testing.T) {
+	t.Parallel()
+	_, err := NewMixed(context.Background(), &fallbackTestRouter{}, log.NewNOPFactory().Logger(), "mixed-in", option.HTTPMixedInboundOptions{
+		Fallbacks: []option.InboundFallbackOptions{{
+			ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: 8080},
+			ALPN:          []string{"h2"},
+		}},
+	})
+	require.Error(t, err)
+}
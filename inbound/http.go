@@ -51,6 +51,10 @@ func NewHTTP(ctx context.Context, router adapter.Router, logger log.ContextLogge
 		}
 		inbound.tlsConfig = tlsConfig
 	}
+	err := inbound.initFallback(options.Fallbacks, inbound.tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
 	inbound.connHandler = inbound
 	return inbound, nil
 }
@@ -80,7 +84,9 @@ func (h *HTTP) NewConnection(ctx context.Context, conn net.Conn, metadata adapte
 			return err
 		}
 	}
-	return http.HandleConnection(ctx, conn, std_bufio.NewReader(conn), h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+	return h.newConnectionWithFallback(ctx, conn, metadata, func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+		return http.HandleConnection(ctx, conn, std_bufio.NewReader(conn), h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+	})
 }
 
 func (h *HTTP) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
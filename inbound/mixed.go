@@ -32,7 +32,7 @@ type Mixed struct {
 	authenticator *auth.Authenticator
 }
 
-func NewMixed(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) *Mixed {
+func NewMixed(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) (*Mixed, error) {
 	inbound := &Mixed{
 		myInboundAdapter{
 			protocol:       C.TypeMixed,
@@ -46,11 +46,19 @@ func NewMixed(ctx context.Context, router adapter.Router, logger log.ContextLogg
 		},
 		auth.NewAuthenticator(options.Users),
 	}
+	err := inbound.initFallback(options.Fallbacks, false)
+	if err != nil {
+		return nil, err
+	}
 	inbound.connHandler = inbound
-	return inbound
+	return inbound, nil
 }
 
 func (h *Mixed) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return h.newConnectionWithFallback(ctx, conn, metadata, h.newConnection)
+}
+
+func (h *Mixed) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	headerType, err := rw.ReadByte(conn)
 	if err != nil {
 		return err
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/binary"
 	"io"
+	std_log "log"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httputil"
 	"os"
 	"strings"
 	"time"
@@ -24,6 +26,7 @@ import (
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/pipe"
 	"github.com/sagernet/sing/common/rw"
 	sHttp "github.com/sagernet/sing/protocol/http"
 )
@@ -66,6 +69,10 @@ func NewNaive(ctx context.Context, router adapter.Router, logger log.ContextLogg
 		}
 		inbound.tlsConfig = tlsConfig
 	}
+	err := inbound.initFallback(options.Fallbacks, inbound.tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
 	return inbound, nil
 }
 
@@ -131,10 +138,16 @@ func (n *Naive) Close() error {
 func (n *Naive) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	ctx := log.ContextWithNewID(request.Context())
 	if request.Method != "CONNECT" {
+		if n.fallbackRequest(ctx, writer, request) {
+			return
+		}
 		rejectHTTP(writer, http.StatusBadRequest)
 		n.badRequest(ctx, request, E.New("not CONNECT request"))
 		return
 	} else if request.Header.Get("Padding") == "" {
+		if n.fallbackRequest(ctx, writer, request) {
+			return
+		}
 		rejectHTTP(writer, http.StatusBadRequest)
 		n.badRequest(ctx, request, E.New("missing naive padding"))
 		return
@@ -144,6 +157,9 @@ func (n *Naive) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		authOk = n.authenticator.Verify(userName, password)
 	}
 	if !authOk {
+		if n.fallbackRequest(ctx, writer, request) {
+			return
+		}
 		rejectHTTP(writer, http.StatusProxyAuthRequired)
 		n.badRequest(ctx, request, E.New("authorization failed"))
 		return
@@ -190,6 +206,46 @@ func (n *Naive) newConnection(ctx context.Context, conn net.Conn, userName strin
 	}
 }
 
+func (n *Naive) fallbackRequest(ctx context.Context, writer http.ResponseWriter, request *http.Request) bool {
+	var alpn, serverName string
+	if request.TLS != nil {
+		alpn = request.TLS.NegotiatedProtocol
+		serverName = request.TLS.ServerName
+	}
+	destination, loaded := n.selectFallback(alpn, serverName, request.URL.Path)
+	if !loaded {
+		return false
+	}
+	n.logger.InfoContext(ctx, "fallback request to ", destination)
+	source := sHttp.SourceAddress(request)
+	reverseProxy := &httputil.ReverseProxy{
+		Director: func(request *http.Request) {
+			request.URL.Scheme = "http"
+			request.URL.Host = destination.String()
+		},
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(_ context.Context, network, address string) (net.Conn, error) {
+				input, output := pipe.Pipe()
+				go func() {
+					hErr := n.router.RouteConnection(ctx, output, n.createMetadata(output, adapter.InboundContext{
+						Source:      source,
+						Destination: destination,
+					}))
+					if hErr != nil {
+						common.Close(input, output)
+						n.NewError(ctx, E.Cause(hErr, "process fallback request from ", source))
+					}
+				}()
+				return input, nil
+			},
+		},
+		ErrorLog: std_log.New(io.Discard, "", 0),
+	}
+	reverseProxy.ServeHTTP(writer, request)
+	return true
+}
+
 func (n *Naive) badRequest(ctx context.Context, request *http.Request, err error) {
 	n.NewError(ctx, E.Cause(err, "process connection from ", request.RemoteAddr))
 }
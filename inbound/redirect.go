@@ -9,6 +9,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
@@ -16,11 +17,12 @@ import (
 
 type Redirect struct {
 	myInboundAdapter
+	autoRoute *redir.AutoRoute
 }
 
-func NewRedirect(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.RedirectInboundOptions) *Redirect {
+func NewRedirect(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.RedirectInboundOptions) (*Redirect, error) {
 	redirect := &Redirect{
-		myInboundAdapter{
+		myInboundAdapter: myInboundAdapter{
 			protocol:      C.TypeRedirect,
 			network:       []string{N.NetworkTCP},
 			ctx:           ctx,
@@ -31,7 +33,35 @@ func NewRedirect(ctx context.Context, router adapter.Router, logger log.ContextL
 		},
 	}
 	redirect.connHandler = redirect
-	return redirect
+	if options.AutoRoute {
+		autoRoute, err := newAutoRoute(router, tag, false, redirect.network, options.ListenOptions, options.AutoRouteOptions)
+		if err != nil {
+			return nil, err
+		}
+		redirect.autoRoute = autoRoute
+	}
+	return redirect, nil
+}
+
+func (r *Redirect) Start() error {
+	err := r.myInboundAdapter.Start()
+	if err != nil {
+		return err
+	}
+	if r.autoRoute != nil {
+		err = r.autoRoute.Start()
+		if err != nil {
+			return E.Cause(err, "configure auto route")
+		}
+	}
+	return nil
+}
+
+func (r *Redirect) Close() error {
+	return E.Errors(
+		common.Close(common.PtrOrNil(r.autoRoute)),
+		r.myInboundAdapter.Close(),
+	)
 }
 
 func (r *Redirect) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
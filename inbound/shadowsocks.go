@@ -42,6 +42,7 @@ var (
 type Shadowsocks struct {
 	myInboundAdapter
 	service shadowsocks.Service
+	plugin  adapter.V2RayServerTransport
 }
 
 func newShadowsocks(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*Shadowsocks, error) {
@@ -81,8 +82,26 @@ func newShadowsocks(ctx context.Context, router adapter.Router, logger log.Conte
 	default:
 		err = E.New("unsupported method: ", options.Method)
 	}
+	if err != nil {
+		return nil, err
+	}
 	inbound.packetUpstream = inbound.service
-	return inbound, err
+	inbound.plugin, err = newShadowsocksPlugin(ctx, logger, &inbound.myInboundAdapter, options)
+	if err != nil {
+		return nil, err
+	}
+	return inbound, nil
+}
+
+func (h *Shadowsocks) Start() error {
+	return h.startPlugin(h.plugin)
+}
+
+func (h *Shadowsocks) Close() error {
+	return common.Close(
+		&h.myInboundAdapter,
+		h.plugin,
+	)
 }
 
 func (h *Shadowsocks) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
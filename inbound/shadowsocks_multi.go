@@ -32,6 +32,7 @@ type ShadowsocksMulti struct {
 	myInboundAdapter
 	service shadowsocks.MultiService[int]
 	users   []option.ShadowsocksUser
+	plugin  adapter.V2RayServerTransport
 }
 
 func newShadowsocksMulti(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*ShadowsocksMulti, error) {
@@ -89,10 +90,25 @@ func newShadowsocksMulti(ctx context.Context, router adapter.Router, logger log.
 	}
 	inbound.service = service
 	inbound.packetUpstream = service
+	inbound.plugin, err = newShadowsocksPlugin(ctx, logger, &inbound.myInboundAdapter, options)
+	if err != nil {
+		return nil, err
+	}
 	inbound.users = options.Users
 	return inbound, err
 }
 
+func (h *ShadowsocksMulti) Start() error {
+	return h.startPlugin(h.plugin)
+}
+
+func (h *ShadowsocksMulti) Close() error {
+	return common.Close(
+		&h.myInboundAdapter,
+		h.plugin,
+	)
+}
+
 func (h *ShadowsocksMulti) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
 }
@@ -0,0 +1,81 @@
+package inbound
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/sip003"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func newShadowsocksPlugin(ctx context.Context, logger log.ContextLogger, inbound *myInboundAdapter, options option.ShadowsocksInboundOptions) (adapter.V2RayServerTransport, error) {
+	if options.Plugin == "" {
+		return nil, nil
+	}
+	plugin, err := sip003.CreateServerPlugin(ctx, logger, options.Plugin, options.PluginOptions, (*shadowsocksPluginHandler)(inbound))
+	if err != nil {
+		return nil, E.Cause(err, "create plugin: ", options.Plugin)
+	}
+	if common.Contains(plugin.Network(), N.NetworkUDP) && common.Contains(inbound.network, N.NetworkUDP) {
+		logger.Warn("UDP relay is disabled since plugin ", options.Plugin, " takes over the UDP port")
+	}
+	return plugin, nil
+}
+
+func (a *myInboundAdapter) startPlugin(plugin adapter.V2RayServerTransport) error {
+	if plugin == nil {
+		return a.Start()
+	}
+	err := common.Start(plugin)
+	if err != nil {
+		return err
+	}
+	if common.Contains(plugin.Network(), N.NetworkTCP) {
+		tcpListener, err := a.ListenTCP()
+		if err != nil {
+			return err
+		}
+		go func() {
+			sErr := plugin.Serve(tcpListener)
+			if sErr != nil && !E.IsClosed(sErr) {
+				a.logger.Error("plugin serve error: ", sErr)
+			}
+		}()
+	}
+	if common.Contains(plugin.Network(), N.NetworkUDP) {
+		udpConn, err := a.ListenUDP()
+		if err != nil {
+			return err
+		}
+		go func() {
+			sErr := plugin.ServePacket(udpConn)
+			if sErr != nil && !E.IsClosed(sErr) {
+				a.logger.Error("plugin serve error: ", sErr)
+			}
+		}()
+	} else if common.Contains(a.network, N.NetworkUDP) {
+		return a.startUDP()
+	}
+	return nil
+}
+
+var _ adapter.V2RayServerTransportHandler = (*shadowsocksPluginHandler)(nil)
+
+type shadowsocksPluginHandler myInboundAdapter
+
+func (h *shadowsocksPluginHandler) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	(*myInboundAdapter)(h).injectTCP(conn, adapter.InboundContext{
+		Source: metadata.Source,
+	})
+	return nil
+}
+
+func (h *shadowsocksPluginHandler) NewError(ctx context.Context, err error) {
+	(*myInboundAdapter)(h).NewError(ctx, err)
+}
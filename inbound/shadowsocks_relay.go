@@ -28,6 +28,7 @@ type ShadowsocksRelay struct {
 	myInboundAdapter
 	service      *shadowaead_2022.RelayService[int]
 	destinations []option.ShadowsocksDestination
+	plugin       adapter.V2RayServerTransport
 }
 
 func newShadowsocksRelay(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*ShadowsocksRelay, error) {
@@ -75,9 +76,24 @@ func newShadowsocksRelay(ctx context.Context, router adapter.Router, logger log.
 	}
 	inbound.service = service
 	inbound.packetUpstream = service
+	inbound.plugin, err = newShadowsocksPlugin(ctx, logger, &inbound.myInboundAdapter, options)
+	if err != nil {
+		return nil, err
+	}
 	return inbound, err
 }
 
+func (h *ShadowsocksRelay) Start() error {
+	return h.startPlugin(h.plugin)
+}
+
+func (h *ShadowsocksRelay) Close() error {
+	return common.Close(
+		&h.myInboundAdapter,
+		h.plugin,
+	)
+}
+
 func (h *ShadowsocksRelay) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
 }
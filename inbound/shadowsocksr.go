@@ -0,0 +1,92 @@
+package inbound
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/shadowsocksr"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
+	F "github.com/sagernet/sing/common/format"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+var (
+	_ adapter.Inbound           = (*ShadowsocksR)(nil)
+	_ adapter.InjectableInbound = (*ShadowsocksR)(nil)
+)
+
+type ShadowsocksR struct {
+	myInboundAdapter
+	service *shadowsocksr.Service[int]
+	users   []option.ShadowsocksRUser
+}
+
+func NewShadowsocksR(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksRInboundOptions) (*ShadowsocksR, error) {
+	inbound := &ShadowsocksR{
+		myInboundAdapter: myInboundAdapter{
+			protocol:      C.TypeShadowsocksR,
+			network:       []string{N.NetworkTCP},
+			ctx:           ctx,
+			router:        router,
+			logger:        logger,
+			tag:           tag,
+			listenOptions: options.ListenOptions,
+		},
+		users: options.Users,
+	}
+	service, err := shadowsocksr.NewService[int](
+		options.Method,
+		options.Password,
+		options.Obfs,
+		options.Protocol,
+		ntp.TimeFuncFromContext(ctx),
+		adapter.NewUpstreamContextHandler(inbound.newConnection, nil, inbound),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = service.UpdateUsers(common.MapIndexed(options.Users, func(index int, it option.ShadowsocksRUser) int {
+		return index
+	}), common.Map(options.Users, func(it option.ShadowsocksRUser) uint32 {
+		return it.UserID
+	}), common.Map(options.Users, func(it option.ShadowsocksRUser) string {
+		return it.Password
+	}))
+	if err != nil {
+		return nil, err
+	}
+	inbound.service = service
+	inbound.connHandler = inbound
+	return inbound, nil
+}
+
+func (h *ShadowsocksR) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+}
+
+func (h *ShadowsocksR) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return os.ErrInvalid
+}
+
+func (h *ShadowsocksR) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	userIndex, loaded := auth.UserFromContext[int](ctx)
+	if !loaded {
+		h.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
+		return h.router.RouteConnection(ctx, conn, metadata)
+	}
+	user := h.users[userIndex].Name
+	if user == "" {
+		user = F.ToString(userIndex)
+	} else {
+		metadata.User = user
+	}
+	h.logger.InfoContext(ctx, "[", user, "] inbound connection to ", metadata.Destination)
+	return h.router.RouteConnection(ctx, conn, metadata)
+}
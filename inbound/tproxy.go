@@ -22,10 +22,11 @@ import (
 
 type TProxy struct {
 	myInboundAdapter
-	udpNat *udpnat.Service[netip.AddrPort]
+	udpNat    *udpnat.Service[netip.AddrPort]
+	autoRoute *redir.AutoRoute
 }
 
-func NewTProxy(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TProxyInboundOptions) *TProxy {
+func NewTProxy(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TProxyInboundOptions) (*TProxy, error) {
 	tproxy := &TProxy{
 		myInboundAdapter: myInboundAdapter{
 			protocol:      C.TypeTProxy,
@@ -47,7 +48,14 @@ func NewTProxy(ctx context.Context, router adapter.Router, logger log.ContextLog
 	tproxy.oobPacketHandler = tproxy
 	tproxy.udpNat = udpnat.New[netip.AddrPort](udpTimeout, tproxy.upstreamContextHandler())
 	tproxy.packetUpstream = tproxy.udpNat
-	return tproxy
+	if options.AutoRoute {
+		autoRoute, err := newAutoRoute(router, tag, true, tproxy.network, options.ListenOptions, options.AutoRouteOptions)
+		if err != nil {
+			return nil, err
+		}
+		tproxy.autoRoute = autoRoute
+	}
+	return tproxy, nil
 }
 
 func (t *TProxy) Start() error {
@@ -71,9 +79,22 @@ func (t *TProxy) Start() error {
 			return E.Cause(err, "configure tproxy UDP listener")
 		}
 	}
+	if t.autoRoute != nil {
+		err = t.autoRoute.Start()
+		if err != nil {
+			return E.Cause(err, "configure auto route")
+		}
+	}
 	return nil
 }
 
+func (t *TProxy) Close() error {
+	return E.Errors(
+		common.Close(common.PtrOrNil(t.autoRoute)),
+		t.myInboundAdapter.Close(),
+	)
+}
+
 func (t *TProxy) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	metadata.Destination = M.SocksaddrFromNet(conn.LocalAddr()).Unwrap()
 	return t.newConnection(ctx, conn, metadata)
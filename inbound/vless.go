@@ -78,6 +78,13 @@ func NewVLESS(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			return nil, E.Cause(err, "create server transport: ", options.Transport.Type)
 		}
 	}
+	if len(options.Fallbacks) > 0 && options.Transport != nil {
+		return nil, E.New("fallback is not supported with v2ray transport")
+	}
+	err = inbound.initFallback(options.Fallbacks, inbound.tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
 	inbound.connHandler = inbound
 	return inbound, nil
 }
@@ -142,7 +149,9 @@ func (h *VLESS) NewConnection(ctx context.Context, conn net.Conn, metadata adapt
 			return err
 		}
 	}
-	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	return h.newConnectionWithFallback(ctx, conn, metadata, func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+		return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	})
 }
 
 func (h *VLESS) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
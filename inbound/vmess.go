@@ -88,6 +88,13 @@ func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			return nil, E.Cause(err, "create server transport: ", options.Transport.Type)
 		}
 	}
+	if len(options.Fallbacks) > 0 && options.Transport != nil {
+		return nil, E.New("fallback is not supported with v2ray transport")
+	}
+	err = inbound.initFallback(options.Fallbacks, inbound.tlsConfig != nil)
+	if err != nil {
+		return nil, err
+	}
 	inbound.connHandler = inbound
 	return inbound, nil
 }
@@ -152,7 +159,9 @@ func (h *VMess) NewConnection(ctx context.Context, conn net.Conn, metadata adapt
 			return err
 		}
 	}
-	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	return h.newConnectionWithFallback(ctx, conn, metadata, func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+		return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	})
 }
 
 func (h *VMess) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
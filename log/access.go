@@ -0,0 +1,129 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	AccessLogFormatJSON = "json"
+	AccessLogFormatCSV  = "csv"
+)
+
+var accessLogCSVHeader = []string{
+	"start", "duration_ms", "id", "network", "inbound", "inbound_type", "user", "source", "destination",
+	"domain", "protocol", "rule", "outbound", "chain", "upload", "download", "error",
+}
+
+// AccessRecord describes a finished connection.
+type AccessRecord struct {
+	Start       time.Time `json:"start"`
+	Duration    int64     `json:"duration_ms"`
+	ID          uint32    `json:"id,omitempty"`
+	Network     string    `json:"network"`
+	Inbound     string    `json:"inbound,omitempty"`
+	InboundType string    `json:"inbound_type,omitempty"`
+	User        string    `json:"user,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Destination string    `json:"destination"`
+	Domain      string    `json:"domain,omitempty"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Rule        string    `json:"rule"`
+	Outbound    string    `json:"outbound"`
+	Chain       []string  `json:"chain,omitempty"`
+	Upload      int64     `json:"upload"`
+	Download    int64     `json:"download"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type AccessLogger struct {
+	writer *rotateWriter
+	csv    bool
+}
+
+func NewAccessLogger(ctx context.Context, options option.AccessLogOptions) (*AccessLogger, error) {
+	if options.Output == "" {
+		return nil, E.New("missing access log output")
+	}
+	var csvFormat bool
+	switch options.Format {
+	case "", AccessLogFormatJSON:
+	case AccessLogFormatCSV:
+		csvFormat = true
+	default:
+		return nil, E.New("unknown access log format: ", options.Format)
+	}
+	var rotateOptions option.LogRotateOptions
+	if options.Rotate != nil {
+		rotateOptions = *options.Rotate
+	}
+	writer := newRotateWriter(ctx, options.Output, rotateOptions)
+	if csvFormat {
+		writer.header = formatCSV(accessLogCSVHeader)
+	}
+	return &AccessLogger{
+		writer: writer,
+		csv:    csvFormat,
+	}, nil
+}
+
+func (l *AccessLogger) Start() error {
+	return l.writer.Start()
+}
+
+func (l *AccessLogger) Close() error {
+	return l.writer.Close()
+}
+
+func (l *AccessLogger) Write(record AccessRecord) {
+	var content []byte
+	if l.csv {
+		content = formatCSV([]string{
+			record.Start.Format(time.RFC3339Nano),
+			strconv.FormatInt(record.Duration, 10),
+			strconv.FormatUint(uint64(record.ID), 10),
+			record.Network,
+			record.Inbound,
+			record.InboundType,
+			record.User,
+			record.Source,
+			record.Destination,
+			record.Domain,
+			record.Protocol,
+			record.Rule,
+			record.Outbound,
+			strings.Join(record.Chain, " -> "),
+			strconv.FormatInt(record.Upload, 10),
+			strconv.FormatInt(record.Download, 10),
+			record.Error,
+		})
+	} else {
+		var err error
+		content, err = json.Marshal(record)
+		if err != nil {
+			return
+		}
+		content = append(content, '\n')
+	}
+	_, err := l.writer.Write(content)
+	if err != nil {
+		os.Stderr.WriteString("write access log: " + err.Error() + "\n")
+	}
+}
+
+func formatCSV(record []string) []byte {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write(record)
+	writer.Flush()
+	return buffer.Bytes()
+}
@@ -18,9 +18,13 @@ type Formatter struct {
 	FullTimestamp    bool
 	TimestampFormat  string
 	DisableLineBreak bool
+	JSON             bool
 }
 
 func (f Formatter) Format(ctx context.Context, level Level, tag string, message string, timestamp time.Time) string {
+	if f.JSON {
+		return f.formatJSON(ctx, level, tag, message, timestamp)
+	}
 	levelString := strings.ToUpper(FormatLevel(level))
 	if !f.DisableColors {
 		switch level {
@@ -90,6 +94,10 @@ func (f Formatter) Format(ctx context.Context, level Level, tag string, message
 }
 
 func (f Formatter) FormatWithSimple(ctx context.Context, level Level, tag string, message string, timestamp time.Time) (string, string) {
+	if f.JSON {
+		_, messageSimple := Formatter{DisableColors: true}.FormatWithSimple(ctx, level, tag, message, timestamp)
+		return f.formatJSON(ctx, level, tag, message, timestamp), messageSimple
+	}
 	levelString := strings.ToUpper(FormatLevel(level))
 	if !f.DisableColors {
 		switch level {
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type jsonEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Tag     string `json:"tag,omitempty"`
+	ID      uint32 `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f Formatter) formatJSON(ctx context.Context, level Level, tag string, message string, timestamp time.Time) string {
+	entry := jsonEntry{
+		Time:    timestamp.Format(time.RFC3339Nano),
+		Level:   FormatLevel(level),
+		Tag:     tag,
+		Message: strings.TrimSuffix(message, "\n"),
+	}
+	if ctx != nil {
+		if id, loaded := IDFromContext(ctx); loaded {
+			entry.ID = id.ID
+		}
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return ""
+	}
+	if f.DisableLineBreak {
+		return string(content)
+	}
+	return string(content) + "\n"
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+type journaldWriter struct {
+	conn *net.UnixConn
+}
+
+func newJournaldWriter() systemWriter {
+	return &journaldWriter{}
+}
+
+func (w *journaldWriter) Start() error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *journaldWriter) WriteEntry(level Level, tag string, id uint32, message string) error {
+	if w.conn == nil {
+		return nil
+	}
+	var buffer bytes.Buffer
+	writeJournaldField(&buffer, "MESSAGE", strings.TrimSuffix(message, "\n"))
+	writeJournaldField(&buffer, "PRIORITY", strconv.Itoa(syslogPriority(level)))
+	writeJournaldField(&buffer, "SYSLOG_IDENTIFIER", "sing-box")
+	if tag != "" {
+		writeJournaldField(&buffer, "SING_BOX_TAG", tag)
+	}
+	if id != 0 {
+		writeJournaldField(&buffer, "SING_BOX_CONNECTION_ID", strconv.FormatUint(uint64(id), 10))
+	}
+	_, err := w.conn.Write(buffer.Bytes())
+	return err
+}
+
+// writeJournaldField encodes a field in the journal native protocol, values with line breaks are length-prefixed.
+func writeJournaldField(buffer *bytes.Buffer, key string, value string) {
+	buffer.WriteString(key)
+	if strings.Contains(value, "\n") {
+		buffer.WriteByte('\n')
+		binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buffer.WriteByte('=')
+	}
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
+}
+
+func (w *journaldWriter) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.Close()
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package log
+
+import (
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type journaldWriter struct{}
+
+func newJournaldWriter() systemWriter {
+	return &journaldWriter{}
+}
+
+func (w *journaldWriter) Start() error {
+	return E.New("journald is only supported on Linux")
+}
+
+func (w *journaldWriter) WriteEntry(level Level, tag string, id uint32, message string) error {
+	return nil
+}
+
+func (w *journaldWriter) Close() error {
+	return nil
+}
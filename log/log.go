@@ -26,8 +26,11 @@ func New(options Options) (Factory, error) {
 		return NewNOPFactory(), nil
 	}
 
-	var logWriter io.Writer
-	var logFilePath string
+	var (
+		logWriter    io.Writer
+		logFilePath  string
+		systemWriter systemWriter
+	)
 
 	switch logOptions.Output {
 	case "":
@@ -40,7 +43,36 @@ func New(options Options) (Factory, error) {
 	case "stdout":
 		logWriter = os.Stdout
 	default:
-		logFilePath = logOptions.Output
+		var (
+			isSystemOutput bool
+			err            error
+		)
+		systemWriter, isSystemOutput, err = newSystemWriter(logOptions.Output)
+		if err != nil {
+			return nil, err
+		}
+		if !isSystemOutput {
+			logFilePath = logOptions.Output
+		}
+	}
+	if logOptions.Rotate != nil && logFilePath == "" {
+		return nil, E.New("log rotation requires a log file output")
+	}
+	var jsonFormat bool
+	switch logOptions.Format {
+	case "", "text":
+	case "json":
+		jsonFormat = true
+	default:
+		return nil, E.New("unknown log format: ", logOptions.Format)
+	}
+	tagLevels := make(map[string]Level)
+	for tag, levelString := range logOptions.Levels {
+		tagLevel, err := ParseLevel(levelString)
+		if err != nil {
+			return nil, E.Cause(err, "parse log level for ", tag)
+		}
+		tagLevels[tag] = tagLevel
 	}
 	logFormatter := Formatter{
 		BaseTime:         options.BaseTime,
@@ -48,8 +80,9 @@ func New(options Options) (Factory, error) {
 		DisableTimestamp: !logOptions.Timestamp && logFilePath != "",
 		FullTimestamp:    logOptions.Timestamp,
 		TimestampFormat:  "[2006-01-02 15:04:05 UTC-07]",
+		JSON:             jsonFormat,
 	}
-	factory := NewDefaultFactory(
+	factory := newDefaultFactory(
 		options.Context,
 		logFormatter,
 		logWriter,
@@ -57,6 +90,9 @@ func New(options Options) (Factory, error) {
 		options.PlatformWriter,
 		options.Observable,
 	)
+	factory.tagLevels = tagLevels
+	factory.rotateOptions = logOptions.Rotate
+	factory.systemWriter = systemWriter
 	if logOptions.Level != "" {
 		logLevel, err := ParseLevel(logOptions.Level)
 		if err != nil {
@@ -4,8 +4,10 @@ import (
 	"context"
 	"io"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
 	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/observable"
@@ -24,6 +26,10 @@ type defaultFactory struct {
 	platformWriter    PlatformWriter
 	needObservable    bool
 	level             Level
+	tagLevels         map[string]Level
+	rotateOptions     *option.LogRotateOptions
+	rotateWriter      *rotateWriter
+	systemWriter      systemWriter
 	subscriber        *observable.Subscriber[Entry]
 	observer          *observable.Observer[Entry]
 }
@@ -36,6 +42,17 @@ func NewDefaultFactory(
 	platformWriter PlatformWriter,
 	needObservable bool,
 ) ObservableFactory {
+	return newDefaultFactory(ctx, formatter, writer, filePath, platformWriter, needObservable)
+}
+
+func newDefaultFactory(
+	ctx context.Context,
+	formatter Formatter,
+	writer io.Writer,
+	filePath string,
+	platformWriter PlatformWriter,
+	needObservable bool,
+) *defaultFactory {
 	factory := &defaultFactory{
 		ctx:       ctx,
 		formatter: formatter,
@@ -58,7 +75,17 @@ func NewDefaultFactory(
 }
 
 func (f *defaultFactory) Start() error {
-	if f.filePath != "" {
+	if f.systemWriter != nil {
+		return f.systemWriter.Start()
+	}
+	if f.filePath != "" && f.rotateOptions != nil {
+		f.rotateWriter = newRotateWriter(f.ctx, f.filePath, *f.rotateOptions)
+		err := f.rotateWriter.Start()
+		if err != nil {
+			return err
+		}
+		f.writer = f.rotateWriter
+	} else if f.filePath != "" {
 		logFile, err := filemanager.OpenFile(f.ctx, f.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
 		if err != nil {
 			return err
@@ -72,6 +99,8 @@ func (f *defaultFactory) Start() error {
 func (f *defaultFactory) Close() error {
 	return common.Close(
 		common.PtrOrNil(f.file),
+		common.PtrOrNil(f.rotateWriter),
+		f.systemWriter,
 		f.observer,
 	)
 }
@@ -89,7 +118,29 @@ func (f *defaultFactory) Logger() ContextLogger {
 }
 
 func (f *defaultFactory) NewLogger(tag string) ContextLogger {
-	return &observableLogger{f, tag}
+	tagLevel, hasTagLevel := f.levelForTag(tag)
+	return &observableLogger{f, tag, tagLevel, hasTagLevel}
+}
+
+// levelForTag returns the level of the longest key matching the tag,
+// a key matches itself and tags under it, e.g. `outbound` and `outbound/urltest` match `outbound/urltest[auto]`.
+func (f *defaultFactory) levelForTag(tag string) (Level, bool) {
+	var (
+		matchedKey string
+		level      Level
+		loaded     bool
+	)
+	for key, keyLevel := range f.tagLevels {
+		if tag != key && !(strings.HasPrefix(tag, key) && (tag[len(key)] == '/' || tag[len(key)] == '[')) {
+			continue
+		}
+		if !loaded || len(key) > len(matchedKey) {
+			matchedKey = key
+			level = keyLevel
+			loaded = true
+		}
+	}
+	return level, loaded
 }
 
 func (f *defaultFactory) Subscribe() (subscription observable.Subscription[Entry], done <-chan struct{}, err error) {
@@ -104,37 +155,56 @@ var _ ContextLogger = (*observableLogger)(nil)
 
 type observableLogger struct {
 	*defaultFactory
-	tag string
+	tag         string
+	tagLevel    Level
+	hasTagLevel bool
 }
 
 func (l *observableLogger) Log(ctx context.Context, level Level, args []any) {
 	level = OverrideLevelFromContext(level, ctx)
-	if level > l.level {
+	maxLevel := l.level
+	if l.hasTagLevel {
+		maxLevel = l.tagLevel
+	}
+	if level > maxLevel {
 		return
 	}
 	nowTime := time.Now()
+	rawMessage := F.ToString(args...)
 	if l.needObservable {
-		message, messageSimple := l.formatter.FormatWithSimple(ctx, level, l.tag, F.ToString(args...), nowTime)
+		message, messageSimple := l.formatter.FormatWithSimple(ctx, level, l.tag, rawMessage, nowTime)
 		if level == LevelPanic {
 			panic(message)
 		}
-		l.writer.Write([]byte(message))
+		l.write(ctx, level, rawMessage, message)
 		if level == LevelFatal {
 			os.Exit(1)
 		}
 		l.subscriber.Emit(Entry{level, messageSimple})
 	} else {
-		message := l.formatter.Format(ctx, level, l.tag, F.ToString(args...), nowTime)
+		message := l.formatter.Format(ctx, level, l.tag, rawMessage, nowTime)
 		if level == LevelPanic {
 			panic(message)
 		}
-		l.writer.Write([]byte(message))
+		l.write(ctx, level, rawMessage, message)
 		if level == LevelFatal {
 			os.Exit(1)
 		}
 	}
 	if l.platformWriter != nil {
-		l.platformWriter.WriteMessage(level, l.platformFormatter.Format(ctx, level, l.tag, F.ToString(args...), nowTime))
+		l.platformWriter.WriteMessage(level, l.platformFormatter.Format(ctx, level, l.tag, rawMessage, nowTime))
+	}
+}
+
+func (l *observableLogger) write(ctx context.Context, level Level, rawMessage string, message string) {
+	if l.systemWriter != nil {
+		var id uint32
+		if loadedID, loaded := IDFromContext(ctx); loaded {
+			id = loadedID.ID
+		}
+		l.systemWriter.WriteEntry(level, l.tag, id, rawMessage)
+	} else {
+		l.writer.Write([]byte(message))
 	}
 }
 
@@ -0,0 +1,196 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+var _ io.WriteCloser = (*rotateWriter)(nil)
+
+type rotateWriter struct {
+	ctx           context.Context
+	path          string
+	maxSize       int64
+	maxAge        time.Duration
+	maxBackups    int
+	compress      bool
+	header        []byte
+	access        sync.Mutex
+	cleanupAccess sync.Mutex
+	file          *os.File
+	size          int64
+	openedAt      time.Time
+}
+
+func newRotateWriter(ctx context.Context, path string, options option.LogRotateOptions) *rotateWriter {
+	return &rotateWriter{
+		ctx:        ctx,
+		path:       filemanager.BasePath(ctx, path),
+		maxSize:    int64(options.MaxSize),
+		maxAge:     time.Duration(options.MaxAge),
+		maxBackups: options.MaxBackups,
+		compress:   options.Compress,
+	}
+}
+
+func (w *rotateWriter) Start() error {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.open()
+}
+
+func (w *rotateWriter) open() error {
+	file, err := filemanager.OpenFile(w.ctx, w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = fileInfo.Size()
+	w.openedAt = time.Now()
+	if w.size == 0 && len(w.header) > 0 {
+		n, err := file.Write(w.header)
+		w.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *rotateWriter) Write(p []byte) (n int, err error) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.size > 0 && (w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize || w.maxAge > 0 && time.Since(w.openedAt) >= w.maxAge) {
+		err = w.rotate()
+		if err != nil {
+			return
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return
+}
+
+func (w *rotateWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	backupPath := w.backupPrefix() + time.Now().Format(rotateTimeFormat) + filepath.Ext(w.path)
+	err = os.Rename(w.path, backupPath)
+	if err != nil {
+		return err
+	}
+	err = w.open()
+	if err != nil {
+		return err
+	}
+	go w.cleanup(backupPath)
+	return nil
+}
+
+func (w *rotateWriter) backupPrefix() string {
+	return strings.TrimSuffix(w.path, filepath.Ext(w.path)) + "-"
+}
+
+func (w *rotateWriter) cleanup(backupPath string) {
+	w.cleanupAccess.Lock()
+	defer w.cleanupAccess.Unlock()
+	if w.compress {
+		err := compressFile(backupPath)
+		if err != nil && !os.IsNotExist(err) {
+			os.Stderr.WriteString("compress log file: " + err.Error() + "\n")
+		}
+	}
+	if w.maxBackups <= 0 {
+		return
+	}
+	backups := w.listBackups()
+	if len(backups) <= w.maxBackups {
+		return
+	}
+	for _, path := range backups[:len(backups)-w.maxBackups] {
+		os.Remove(path)
+	}
+}
+
+func (w *rotateWriter) listBackups() []string {
+	prefix := w.backupPrefix()
+	extension := filepath.Ext(w.path)
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(w.path), entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		timestamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(path, prefix), ".gz"), extension)
+		if _, err = time.Parse(rotateTimeFormat, timestamp); err != nil {
+			continue
+		}
+		backups = append(backups, path)
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+func (w *rotateWriter) Close() error {
+	w.access.Lock()
+	defer w.access.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	destination, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		source.Close()
+		return err
+	}
+	writer := gzip.NewWriter(destination)
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
+	}
+	source.Close()
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
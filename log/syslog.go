@@ -0,0 +1,54 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+)
+
+type syslogWriter struct {
+	network string
+	address string
+	writer  *syslog.Writer
+}
+
+func newSyslogWriter(network string, address string) systemWriter {
+	return &syslogWriter{network: network, address: address}
+}
+
+func (w *syslogWriter) Start() error {
+	writer, err := syslog.Dial(w.network, w.address, syslog.LOG_INFO|syslog.LOG_DAEMON, "sing-box")
+	if err != nil {
+		return err
+	}
+	w.writer = writer
+	return nil
+}
+
+func (w *syslogWriter) WriteEntry(level Level, tag string, id uint32, message string) error {
+	if w.writer == nil {
+		return nil
+	}
+	message = formatSystemMessage(tag, id, message)
+	switch syslogPriority(level) {
+	case 0:
+		return w.writer.Emerg(message)
+	case 2:
+		return w.writer.Crit(message)
+	case 3:
+		return w.writer.Err(message)
+	case 4:
+		return w.writer.Warning(message)
+	case 6:
+		return w.writer.Info(message)
+	default:
+		return w.writer.Debug(message)
+	}
+}
+
+func (w *syslogWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
@@ -0,0 +1,25 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type syslogWriter struct{}
+
+func newSyslogWriter(network string, address string) systemWriter {
+	return &syslogWriter{}
+}
+
+func (w *syslogWriter) Start() error {
+	return E.New("syslog is not supported on this platform")
+}
+
+func (w *syslogWriter) WriteEntry(level Level, tag string, id uint32, message string) error {
+	return nil
+}
+
+func (w *syslogWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+// systemWriter writes entries to a system logging service that records level and time by itself.
+type systemWriter interface {
+	Start() error
+	WriteEntry(level Level, tag string, id uint32, message string) error
+	io.Closer
+}
+
+func newSystemWriter(output string) (systemWriter, bool, error) {
+	switch {
+	case output == "syslog":
+		return newSyslogWriter("", ""), true, nil
+	case strings.HasPrefix(output, "syslog+"):
+		outputURL, err := url.Parse(output)
+		if err != nil {
+			return nil, true, E.Cause(err, "parse syslog address")
+		}
+		network := strings.TrimPrefix(outputURL.Scheme, "syslog+")
+		switch network {
+		case "udp", "tcp", "unix", "unixgram":
+		default:
+			return nil, true, E.New("unknown syslog network: ", network)
+		}
+		address := outputURL.Host
+		if network == "unix" || network == "unixgram" {
+			address = outputURL.Path
+		}
+		return newSyslogWriter(network, address), true, nil
+	case output == "journald":
+		return newJournaldWriter(), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func syslogPriority(level Level) int {
+	switch level {
+	case LevelPanic:
+		return 0
+	case LevelFatal:
+		return 2
+	case LevelError:
+		return 3
+	case LevelWarn:
+		return 4
+	case LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func formatSystemMessage(tag string, id uint32, message string) string {
+	message = strings.TrimSuffix(message, "\n")
+	if tag != "" {
+		message = tag + ": " + message
+	}
+	if id != 0 {
+		message = F.ToString("[", id, "] ", message)
+	}
+	return message
+}
@@ -34,9 +34,26 @@ func (o *Options) UnmarshalJSON(content []byte) error {
 }
 
 type LogOptions struct {
-	Disabled     bool   `json:"disabled,omitempty"`
-	Level        string `json:"level,omitempty"`
-	Output       string `json:"output,omitempty"`
-	Timestamp    bool   `json:"timestamp,omitempty"`
-	DisableColor bool   `json:"-"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	Level        string            `json:"level,omitempty"`
+	Levels       map[string]string `json:"levels,omitempty"`
+	Output       string            `json:"output,omitempty"`
+	Format       string            `json:"format,omitempty"`
+	Timestamp    bool              `json:"timestamp,omitempty"`
+	Rotate       *LogRotateOptions `json:"rotate,omitempty"`
+	Access       *AccessLogOptions `json:"access,omitempty"`
+	DisableColor bool              `json:"-"`
+}
+
+type LogRotateOptions struct {
+	MaxSize    MemoryBytes `json:"max_size,omitempty"`
+	MaxAge     Duration    `json:"max_age,omitempty"`
+	MaxBackups int         `json:"max_backups,omitempty"`
+	Compress   bool        `json:"compress,omitempty"`
+}
+
+type AccessLogOptions struct {
+	Output string            `json:"output,omitempty"`
+	Format string            `json:"format,omitempty"`
+	Rotate *LogRotateOptions `json:"rotate,omitempty"`
 }
@@ -0,0 +1,28 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/json"
+)
+
+type _DomainResolveOptions struct {
+	Server       string         `json:"server"`
+	Strategy     DomainStrategy `json:"strategy,omitempty"`
+	DisableCache bool           `json:"disable_cache,omitempty"`
+}
+
+type DomainResolveOptions _DomainResolveOptions
+
+func (o DomainResolveOptions) MarshalJSON() ([]byte, error) {
+	if o.Strategy == DomainStrategy(0) && !o.DisableCache {
+		return json.Marshal(o.Server)
+	}
+	return json.Marshal(_DomainResolveOptions(o))
+}
+
+func (o *DomainResolveOptions) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, &o.Server)
+	if err == nil {
+		return nil
+	}
+	return json.Unmarshal(bytes, (*_DomainResolveOptions)(o))
+}
@@ -14,3 +14,11 @@ type URLTestOutboundOptions struct {
 	IdleTimeout               Duration `json:"idle_timeout,omitempty"`
 	InterruptExistConnections bool     `json:"interrupt_exist_connections,omitempty"`
 }
+
+type RaceOutboundOptions struct {
+	Outbounds     []string         `json:"outbounds"`
+	Concurrency   int              `json:"concurrency,omitempty"`
+	FallbackDelay Duration         `json:"fallback_delay,omitempty"`
+	Network       NetworkList      `json:"network,omitempty"`
+	RuleSet       Listable[string] `json:"rule_set,omitempty"`
+}
@@ -7,24 +7,25 @@ import (
 )
 
 type _Inbound struct {
-	Type               string                    `json:"type"`
-	Tag                string                    `json:"tag,omitempty"`
-	TunOptions         TunInboundOptions         `json:"-"`
-	RedirectOptions    RedirectInboundOptions    `json:"-"`
-	TProxyOptions      TProxyInboundOptions      `json:"-"`
-	DirectOptions      DirectInboundOptions      `json:"-"`
-	SocksOptions       SocksInboundOptions       `json:"-"`
-	HTTPOptions        HTTPMixedInboundOptions   `json:"-"`
-	MixedOptions       HTTPMixedInboundOptions   `json:"-"`
-	ShadowsocksOptions ShadowsocksInboundOptions `json:"-"`
-	VMessOptions       VMessInboundOptions       `json:"-"`
-	TrojanOptions      TrojanInboundOptions      `json:"-"`
-	NaiveOptions       NaiveInboundOptions       `json:"-"`
-	HysteriaOptions    HysteriaInboundOptions    `json:"-"`
-	ShadowTLSOptions   ShadowTLSInboundOptions   `json:"-"`
-	VLESSOptions       VLESSInboundOptions       `json:"-"`
-	TUICOptions        TUICInboundOptions        `json:"-"`
-	Hysteria2Options   Hysteria2InboundOptions   `json:"-"`
+	Type                string                     `json:"type"`
+	Tag                 string                     `json:"tag,omitempty"`
+	TunOptions          TunInboundOptions          `json:"-"`
+	RedirectOptions     RedirectInboundOptions     `json:"-"`
+	TProxyOptions       TProxyInboundOptions       `json:"-"`
+	DirectOptions       DirectInboundOptions       `json:"-"`
+	SocksOptions        SocksInboundOptions        `json:"-"`
+	HTTPOptions         HTTPMixedInboundOptions    `json:"-"`
+	MixedOptions        HTTPMixedInboundOptions    `json:"-"`
+	ShadowsocksOptions  ShadowsocksInboundOptions  `json:"-"`
+	ShadowsocksROptions ShadowsocksRInboundOptions `json:"-"`
+	VMessOptions        VMessInboundOptions        `json:"-"`
+	TrojanOptions       TrojanInboundOptions       `json:"-"`
+	NaiveOptions        NaiveInboundOptions        `json:"-"`
+	HysteriaOptions     HysteriaInboundOptions     `json:"-"`
+	ShadowTLSOptions    ShadowTLSInboundOptions    `json:"-"`
+	VLESSOptions        VLESSInboundOptions        `json:"-"`
+	TUICOptions         TUICInboundOptions         `json:"-"`
+	Hysteria2Options    Hysteria2InboundOptions    `json:"-"`
 }
 
 type Inbound _Inbound
@@ -48,6 +49,8 @@ func (h *Inbound) RawOptions() (any, error) {
 		rawOptionsPtr = &h.MixedOptions
 	case C.TypeShadowsocks:
 		rawOptionsPtr = &h.ShadowsocksOptions
+	case C.TypeShadowsocksR:
+		rawOptionsPtr = &h.ShadowsocksROptions
 	case C.TypeVMess:
 		rawOptionsPtr = &h.VMessOptions
 	case C.TypeTrojan:
@@ -104,6 +107,14 @@ type InboundOptions struct {
 	UDPDisableDomainUnmapping bool           `json:"udp_disable_domain_unmapping,omitempty"`
 }
 
+type InboundOptionsWrapper interface {
+	TakeInboundOptions() InboundOptions
+}
+
+func (o *InboundOptions) TakeInboundOptions() InboundOptions {
+	return *o
+}
+
 type ListenOptions struct {
 	Listen                      *ListenAddress `json:"listen,omitempty"`
 	ListenPort                  uint16         `json:"listen_port,omitempty"`
@@ -130,3 +141,10 @@ func (o *ListenOptions) TakeListenOptions() ListenOptions {
 func (o *ListenOptions) ReplaceListenOptions(options ListenOptions) {
 	*o = options
 }
+
+type InboundFallbackOptions struct {
+	ServerOptions
+	ALPN       Listable[string] `json:"alpn,omitempty"`
+	ServerName Listable[string] `json:"server_name,omitempty"`
+	Path       Listable[string] `json:"path,omitempty"`
+}
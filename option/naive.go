@@ -7,4 +7,5 @@ type NaiveInboundOptions struct {
 	Users   []auth.User `json:"users,omitempty"`
 	Network NetworkList `json:"network,omitempty"`
 	InboundTLSOptionsContainer
+	Fallbacks []InboundFallbackOptions `json:"fallbacks,omitempty"`
 }
@@ -29,6 +29,7 @@ type _Outbound struct {
 	SelectorOptions     SelectorOutboundOptions     `json:"-"`
 	URLTestOptions      URLTestOutboundOptions      `json:"-"`
 	JSTestOptions       JSTestOutboundOptions       `json:"-"`
+	RaceOptions         RaceOutboundOptions         `json:"-"`
 }
 
 type Outbound _Outbound
@@ -76,6 +77,8 @@ func (h *Outbound) RawOptions() (any, error) {
 		rawOptionsPtr = &h.URLTestOptions
 	case C.TypeJSTest:
 		rawOptionsPtr = &h.JSTestOptions
+	case C.TypeRace:
+		rawOptionsPtr = &h.RaceOptions
 	case "":
 		return nil, E.New("missing outbound type")
 	default:
@@ -114,20 +117,21 @@ type DialerOptionsWrapper interface {
 }
 
 type DialerOptions struct {
-	Detour             string         `json:"detour,omitempty"`
-	BindInterface      string         `json:"bind_interface,omitempty"`
-	Inet4BindAddress   *ListenAddress `json:"inet4_bind_address,omitempty"`
-	Inet6BindAddress   *ListenAddress `json:"inet6_bind_address,omitempty"`
-	ProtectPath        string         `json:"protect_path,omitempty"`
-	RoutingMark        int            `json:"routing_mark,omitempty"`
-	ReuseAddr          bool           `json:"reuse_addr,omitempty"`
-	ConnectTimeout     Duration       `json:"connect_timeout,omitempty"`
-	TCPFastOpen        bool           `json:"tcp_fast_open,omitempty"`
-	TCPMultiPath       bool           `json:"tcp_multi_path,omitempty"`
-	UDPFragment        *bool          `json:"udp_fragment,omitempty"`
-	UDPFragmentDefault bool           `json:"-"`
-	DomainStrategy     DomainStrategy `json:"domain_strategy,omitempty"`
-	FallbackDelay      Duration       `json:"fallback_delay,omitempty"`
+	Detour             string                `json:"detour,omitempty"`
+	BindInterface      string                `json:"bind_interface,omitempty"`
+	Inet4BindAddress   *ListenAddress        `json:"inet4_bind_address,omitempty"`
+	Inet6BindAddress   *ListenAddress        `json:"inet6_bind_address,omitempty"`
+	ProtectPath        string                `json:"protect_path,omitempty"`
+	RoutingMark        int                   `json:"routing_mark,omitempty"`
+	ReuseAddr          bool                  `json:"reuse_addr,omitempty"`
+	ConnectTimeout     Duration              `json:"connect_timeout,omitempty"`
+	TCPFastOpen        bool                  `json:"tcp_fast_open,omitempty"`
+	TCPMultiPath       bool                  `json:"tcp_multi_path,omitempty"`
+	UDPFragment        *bool                 `json:"udp_fragment,omitempty"`
+	UDPFragmentDefault bool                  `json:"-"`
+	DomainStrategy     DomainStrategy        `json:"domain_strategy,omitempty"`
+	DomainResolver     *DomainResolveOptions `json:"domain_resolver,omitempty"`
+	FallbackDelay      Duration              `json:"fallback_delay,omitempty"`
 }
 
 func (o *DialerOptions) TakeDialerOptions() DialerOptions {
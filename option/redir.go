@@ -1,10 +1,22 @@
 package option
 
+import "net/netip"
+
 type RedirectInboundOptions struct {
 	ListenOptions
+	AutoRouteOptions
 }
 
 type TProxyInboundOptions struct {
 	ListenOptions
 	Network NetworkList `json:"network,omitempty"`
+	AutoRouteOptions
+}
+
+type AutoRouteOptions struct {
+	AutoRoute           bool                   `json:"auto_route,omitempty"`
+	AutoRouteInet6      bool                   `json:"auto_route_inet6,omitempty"`
+	IPRoute2TableIndex  int                    `json:"iproute2_table_index,omitempty"`
+	IPRoute2RuleIndex   int                    `json:"iproute2_rule_index,omitempty"`
+	RouteExcludeAddress Listable[netip.Prefix] `json:"route_exclude_address,omitempty"`
 }
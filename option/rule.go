@@ -66,10 +66,17 @@ func (r Rule) IsValid() bool {
 
 type DefaultRule struct {
 	Inbound                  Listable[string] `json:"inbound,omitempty"`
+	InboundInterface         Listable[string] `json:"inbound_interface,omitempty"`
 	IPVersion                int              `json:"ip_version,omitempty"`
 	Network                  Listable[string] `json:"network,omitempty"`
 	AuthUser                 Listable[string] `json:"auth_user,omitempty"`
 	Protocol                 Listable[string] `json:"protocol,omitempty"`
+	ALPN                     Listable[string] `json:"alpn,omitempty"`
+	TLSFingerprint           Listable[string] `json:"tls_fingerprint,omitempty"`
+	HTTPMethod               Listable[string] `json:"http_method,omitempty"`
+	HTTPPath                 Listable[string] `json:"http_path,omitempty"`
+	UserAgent                Listable[string] `json:"user_agent,omitempty"`
+	UserAgentRegex           Listable[string] `json:"user_agent_regex,omitempty"`
 	Domain                   Listable[string] `json:"domain,omitempty"`
 	DomainSuffix             Listable[string] `json:"domain_suffix,omitempty"`
 	DomainKeyword            Listable[string] `json:"domain_keyword,omitempty"`
@@ -78,6 +85,8 @@ type DefaultRule struct {
 	SourceGeoIP              Listable[string] `json:"source_geoip,omitempty"`
 	GeoIP                    Listable[string] `json:"geoip,omitempty"`
 	SourceIPCIDR             Listable[string] `json:"source_ip_cidr,omitempty"`
+	SourceMACAddress         Listable[string] `json:"source_mac_address,omitempty"`
+	SourceHostname           Listable[string] `json:"source_hostname,omitempty"`
 	SourceIPIsPrivate        bool             `json:"source_ip_is_private,omitempty"`
 	IPCIDR                   Listable[string] `json:"ip_cidr,omitempty"`
 	IPIsPrivate              bool             `json:"ip_is_private,omitempty"`
@@ -91,28 +100,42 @@ type DefaultRule struct {
 	User                     Listable[string] `json:"user,omitempty"`
 	UserID                   Listable[int32]  `json:"user_id,omitempty"`
 	ClashMode                string           `json:"clash_mode,omitempty"`
+	Schedule                 *ScheduleOptions `json:"schedule,omitempty"`
+	NetworkType              Listable[string] `json:"network_type,omitempty"`
 	WIFISSID                 Listable[string] `json:"wifi_ssid,omitempty"`
 	WIFIBSSID                Listable[string] `json:"wifi_bssid,omitempty"`
 	RuleSet                  Listable[string] `json:"rule_set,omitempty"`
 	RuleSetIPCIDRMatchSource bool             `json:"rule_set_ipcidr_match_source,omitempty"`
 	Invert                   bool             `json:"invert,omitempty"`
 	Outbound                 string           `json:"outbound,omitempty"`
+	Chain                    Listable[string] `json:"chain,omitempty"`
+	Retry                    Listable[string] `json:"retry,omitempty"`
 }
 
 func (r DefaultRule) IsValid() bool {
 	var defaultValue DefaultRule
 	defaultValue.Invert = r.Invert
 	defaultValue.Outbound = r.Outbound
+	defaultValue.Chain = r.Chain
+	defaultValue.Retry = r.Retry
 	return !reflect.DeepEqual(r, defaultValue)
 }
 
 type LogicalRule struct {
-	Mode     string `json:"mode"`
-	Rules    []Rule `json:"rules,omitempty"`
-	Invert   bool   `json:"invert,omitempty"`
-	Outbound string `json:"outbound,omitempty"`
+	Mode     string           `json:"mode"`
+	Rules    []Rule           `json:"rules,omitempty"`
+	Invert   bool             `json:"invert,omitempty"`
+	Outbound string           `json:"outbound,omitempty"`
+	Chain    Listable[string] `json:"chain,omitempty"`
+	Retry    Listable[string] `json:"retry,omitempty"`
 }
 
 func (r LogicalRule) IsValid() bool {
 	return len(r.Rules) > 0 && common.All(r.Rules, Rule.IsValid)
 }
+
+type ScheduleOptions struct {
+	Weekday  Listable[string] `json:"weekday,omitempty"`
+	Time     Listable[string] `json:"time,omitempty"`
+	TimeZone string           `json:"timezone,omitempty"`
+}
@@ -90,6 +90,8 @@ type DefaultDNSRule struct {
 	UserID            Listable[int32]        `json:"user_id,omitempty"`
 	Outbound          Listable[string]       `json:"outbound,omitempty"`
 	ClashMode         string                 `json:"clash_mode,omitempty"`
+	Schedule          *ScheduleOptions       `json:"schedule,omitempty"`
+	NetworkType       Listable[string]       `json:"network_type,omitempty"`
 	WIFISSID          Listable[string]       `json:"wifi_ssid,omitempty"`
 	WIFIBSSID         Listable[string]       `json:"wifi_bssid,omitempty"`
 	RuleSet           Listable[string]       `json:"rule_set,omitempty"`
@@ -0,0 +1,166 @@
+package option
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/jsonschema"
+	C "github.com/sagernet/sing-box/constant"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// Schema returns the JSON Schema of the configuration.
+func Schema() *jsonschema.Schema {
+	reflector := &jsonschema.Reflector{
+		Mapper: mapSchema,
+		Unions: schemaUnions,
+	}
+	return reflector.Reflect(reflect.TypeOf(Options{}))
+}
+
+var schemaUnions = map[reflect.Type]jsonschema.Union{
+	reflect.TypeOf(Inbound{}): {
+		Key: "type",
+		Variants: []jsonschema.Variant{
+			{Value: C.TypeTun, Options: TunInboundOptions{}},
+			{Value: C.TypeRedirect, Options: RedirectInboundOptions{}},
+			{Value: C.TypeTProxy, Options: TProxyInboundOptions{}},
+			{Value: C.TypeDirect, Options: DirectInboundOptions{}},
+			{Value: C.TypeSOCKS, Options: SocksInboundOptions{}},
+			{Value: C.TypeHTTP, Options: HTTPMixedInboundOptions{}},
+			{Value: C.TypeMixed, Options: HTTPMixedInboundOptions{}},
+			{Value: C.TypeShadowsocks, Options: ShadowsocksInboundOptions{}},
+			{Value: C.TypeShadowsocksR, Options: ShadowsocksRInboundOptions{}},
+			{Value: C.TypeVMess, Options: VMessInboundOptions{}},
+			{Value: C.TypeTrojan, Options: TrojanInboundOptions{}},
+			{Value: C.TypeNaive, Options: NaiveInboundOptions{}},
+			{Value: C.TypeHysteria, Options: HysteriaInboundOptions{}},
+			{Value: C.TypeShadowTLS, Options: ShadowTLSInboundOptions{}},
+			{Value: C.TypeVLESS, Options: VLESSInboundOptions{}},
+			{Value: C.TypeTUIC, Options: TUICInboundOptions{}},
+			{Value: C.TypeHysteria2, Options: Hysteria2InboundOptions{}},
+		},
+	},
+	reflect.TypeOf(Outbound{}): {
+		Key: "type",
+		Variants: []jsonschema.Variant{
+			{Value: C.TypeDirect, Options: DirectOutboundOptions{}},
+			{Value: C.TypeBlock},
+			{Value: C.TypeDNS},
+			{Value: C.TypeSOCKS, Options: SocksOutboundOptions{}},
+			{Value: C.TypeHTTP, Options: HTTPOutboundOptions{}},
+			{Value: C.TypeShadowsocks, Options: ShadowsocksOutboundOptions{}},
+			{Value: C.TypeVMess, Options: VMessOutboundOptions{}},
+			{Value: C.TypeTrojan, Options: TrojanOutboundOptions{}},
+			{Value: C.TypeWireGuard, Options: WireGuardOutboundOptions{}},
+			{Value: C.TypeHysteria, Options: HysteriaOutboundOptions{}},
+			{Value: C.TypeTor, Options: TorOutboundOptions{}},
+			{Value: C.TypeSSH, Options: SSHOutboundOptions{}},
+			{Value: C.TypeShadowTLS, Options: ShadowTLSOutboundOptions{}},
+			{Value: C.TypeShadowsocksR, Options: ShadowsocksROutboundOptions{}},
+			{Value: C.TypeVLESS, Options: VLESSOutboundOptions{}},
+			{Value: C.TypeTUIC, Options: TUICOutboundOptions{}},
+			{Value: C.TypeHysteria2, Options: Hysteria2OutboundOptions{}},
+			{Value: C.TypeRandomAddr, Options: RandomAddrOutboundOptions{}},
+			{Value: C.TypeSelector, Options: SelectorOutboundOptions{}},
+			{Value: C.TypeURLTest, Options: URLTestOutboundOptions{}},
+			{Value: C.TypeJSTest, Options: JSTestOutboundOptions{}},
+			{Value: C.TypeRace, Options: RaceOutboundOptions{}},
+		},
+	},
+	reflect.TypeOf(ProxyProviderGroup{}): {
+		Key: "type",
+		Variants: []jsonschema.Variant{
+			{Value: C.TypeSelector, Options: SelectorOutboundOptions{}},
+			{Value: C.TypeURLTest, Options: URLTestOutboundOptions{}},
+			{Value: C.TypeJSTest, Options: JSTestOutboundOptions{}},
+		},
+	},
+	reflect.TypeOf(V2RayTransportOptions{}): {
+		Key: "type",
+		Variants: []jsonschema.Variant{
+			{Value: C.V2RayTransportTypeHTTP, Options: V2RayHTTPOptions{}},
+			{Value: C.V2RayTransportTypeWebsocket, Options: V2RayWebsocketOptions{}},
+			{Value: C.V2RayTransportTypeQUIC, Options: V2RayQUICOptions{}},
+			{Value: C.V2RayTransportTypeGRPC, Options: V2RayGRPCOptions{}},
+			{Value: C.V2RayTransportTypeHTTPUpgrade, Options: V2RayHTTPUpgradeOptions{}},
+			{Value: C.V2RayTransportTypeKCP, Options: V2RayKCPOptions{}},
+			{Value: C.V2RayTransportTypeSplitHTTP, Options: V2RaySplitHTTPOptions{}},
+		},
+	},
+	reflect.TypeOf(Rule{}): {
+		Key:     "type",
+		Default: C.RuleTypeDefault,
+		Variants: []jsonschema.Variant{
+			{Value: C.RuleTypeDefault, Options: DefaultRule{}},
+			{Value: C.RuleTypeLogical, Options: LogicalRule{}},
+		},
+	},
+	reflect.TypeOf(DNSRule{}): {
+		Key:     "type",
+		Default: C.RuleTypeDefault,
+		Variants: []jsonschema.Variant{
+			{Value: C.RuleTypeDefault, Options: DefaultDNSRule{}},
+			{Value: C.RuleTypeLogical, Options: LogicalDNSRule{}},
+		},
+	},
+	reflect.TypeOf(HeadlessRule{}): {
+		Key:     "type",
+		Default: C.RuleTypeDefault,
+		Variants: []jsonschema.Variant{
+			{Value: C.RuleTypeDefault, Options: DefaultHeadlessRule{}},
+			{Value: C.RuleTypeLogical, Options: LogicalHeadlessRule{}},
+		},
+	},
+	reflect.TypeOf(RuleSet{}): {
+		Key: "type",
+		Variants: []jsonschema.Variant{
+			{Value: C.RuleSetTypeInline, Options: PlainRuleSet{}},
+			{Value: C.RuleSetTypeLocal, Options: LocalRuleSet{}},
+			{Value: C.RuleSetTypeRemote, Options: RemoteRuleSet{}},
+		},
+	},
+	reflect.TypeOf(ACMEDNS01ChallengeOptions{}): {
+		Key: "provider",
+		Variants: []jsonschema.Variant{
+			{Value: C.DNSProviderAliDNS, Options: ACMEDNS01AliDNSOptions{}},
+			{Value: C.DNSProviderCloudflare, Options: ACMEDNS01CloudflareOptions{}},
+		},
+	},
+}
+
+func mapSchema(r *jsonschema.Reflector, t reflect.Type) *jsonschema.Schema {
+	if t.PkgPath() == reflect.TypeOf(Options{}).PkgPath() && strings.HasPrefix(t.Name(), "Listable[") {
+		element := r.Type(t.Elem())
+		return jsonschema.AnyOf(element, jsonschema.ArrayOf(element))
+	}
+	switch t {
+	case reflect.TypeOf(Options{}):
+		return r.Object(t)
+	case reflect.TypeOf(ListenAddress{}), reflect.TypeOf(Duration(0)):
+		return jsonschema.String()
+	case reflect.TypeOf(MemoryBytes(0)), reflect.TypeOf(DNSQueryType(0)):
+		return jsonschema.AnyOf(jsonschema.Integer(), jsonschema.String())
+	case reflect.TypeOf(NetworkList{}):
+		network := jsonschema.Enum(N.NetworkTCP, N.NetworkUDP)
+		network.Type = jsonschema.TypeString
+		return jsonschema.AnyOf(network, jsonschema.ArrayOf(network))
+	case reflect.TypeOf(DomainStrategy(0)):
+		strategy := jsonschema.Enum("", "as_is", "prefer_ipv4", "prefer_ipv6", "ipv4_only", "ipv6_only")
+		strategy.Type = jsonschema.TypeString
+		return strategy
+	case reflect.TypeOf(OnDemandRuleAction(0)):
+		action := jsonschema.Enum("connect", "disconnect", "evaluate_connection", "ignore")
+		action.Type = jsonschema.TypeString
+		return action
+	case reflect.TypeOf(OnDemandRuleInterfaceType(0)):
+		interfaceType := jsonschema.Enum("any", "wifi", "cellular")
+		interfaceType.Type = jsonschema.TypeString
+		return interfaceType
+	case reflect.TypeOf(UDPOverTCPOptions{}):
+		return jsonschema.AnyOf(jsonschema.Boolean(), r.Object(t))
+	case reflect.TypeOf(DomainResolveOptions{}):
+		return jsonschema.AnyOf(jsonschema.String(), r.Object(t))
+	}
+	return nil
+}
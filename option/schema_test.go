@@ -0,0 +1,224 @@
+package option
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/jsonschema"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testClientConfig = `{
+  "log": {
+    "level": "info",
+    "timestamp": true
+  },
+  "dns": {
+    "servers": [
+      {
+        "tag": "google",
+        "address": "tls://8.8.8.8"
+      },
+      {
+        "tag": "local",
+        "address": "223.5.5.5",
+        "detour": "direct"
+      }
+    ],
+    "rules": [
+      {
+        "outbound": "any",
+        "server": "local"
+      }
+    ],
+    "strategy": "ipv4_only"
+  },
+  "inbounds": [
+    {
+      "type": "tun",
+      "inet4_address": "172.19.0.1/30",
+      "auto_route": true,
+      "strict_route": true,
+      "stack": "system",
+      "sniff": true
+    },
+    {
+      "type": "mixed",
+      "tag": "mixed-in",
+      "listen": "127.0.0.1",
+      "listen_port": 2080,
+      "users": [
+        {
+          "username": "admin",
+          "password": "admin"
+        }
+      ],
+      "set_system_proxy": false
+    },
+    {
+      "type": "socks",
+      "listen": "127.0.0.1",
+      "listen_port": 1080,
+      "users": [
+        {
+          "Username": "admin",
+          "Password": "admin"
+        }
+      ]
+    },
+    {
+      "type": "http",
+      "listen": "127.0.0.1",
+      "listen_port": 8080,
+      "users": [
+        {
+          "username": "admin",
+          "password": "admin"
+        }
+      ]
+    }
+  ],
+  "outbounds": [
+    {
+      "type": "selector",
+      "tag": "proxy",
+      "outbounds": [
+        "auto",
+        "ss",
+        "vmess-ws"
+      ],
+      "default": "auto"
+    },
+    {
+      "type": "urltest",
+      "tag": "auto",
+      "outbounds": [
+        "ss",
+        "vmess-ws"
+      ],
+      "interval": "5m"
+    },
+    {
+      "type": "shadowsocks",
+      "tag": "ss",
+      "server": "127.0.0.1",
+      "server_port": 8080,
+      "method": "2022-blake3-aes-128-gcm",
+      "password": "8JCsPssfgS8tiRwiMlhARg==",
+      "multiplex": {
+        "enabled": true
+      }
+    },
+    {
+      "type": "vmess",
+      "tag": "vmess-ws",
+      "server": "example.org",
+      "server_port": 443,
+      "uuid": "bf000d23-0752-40b4-affe-68f7707a9661",
+      "security": "auto",
+      "tls": {
+        "enabled": true,
+        "server_name": "example.org",
+        "utls": {
+          "enabled": true,
+          "fingerprint": "chrome"
+        }
+      },
+      "transport": {
+        "type": "ws",
+        "path": "/ws",
+        "headers": {
+          "Host": "example.org"
+        }
+      }
+    },
+    {
+      "type": "socks",
+      "tag": "socks-out",
+      "server": "127.0.0.1",
+      "server_port": 1080,
+      "username": "admin",
+      "password": "admin"
+    },
+    {
+      "type": "direct",
+      "tag": "direct"
+    },
+    {
+      "type": "block",
+      "tag": "block"
+    },
+    {
+      "type": "dns",
+      "tag": "dns-out"
+    }
+  ],
+  "route": {
+    "rules": [
+      {
+        "protocol": "dns",
+        "outbound": "dns-out"
+      },
+      {
+        "type": "logical",
+        "mode": "and",
+        "rules": [
+          {
+            "port": 443
+          },
+          {
+            "network": "udp"
+          }
+        ],
+        "outbound": "block"
+      },
+      {
+        "domain_suffix": [
+          ".cn"
+        ],
+        "ip_is_private": true,
+        "outbound": "direct"
+      }
+    ],
+    "final": "proxy",
+    "auto_detect_interface": true
+  },
+  "experimental": {
+    "cache_file": {
+      "enabled": true
+    },
+    "clash_api": {
+      "external_controller": "127.0.0.1:9090"
+    }
+  }
+}`
+
+func validateConfig(t *testing.T, schema *jsonschema.Schema, content []byte) []*jsonschema.ValidationError {
+	value, err := json.UnmarshalExtended[any](content)
+	require.NoError(t, err)
+	return jsonschema.Validate(schema, value)
+}
+
+func TestSchemaExamples(t *testing.T) {
+	t.Parallel()
+	schema := Schema()
+	releaseConfig, err := os.ReadFile("../release/config/config.json")
+	require.NoError(t, err)
+	for name, content := range map[string][]byte{
+		"release": releaseConfig,
+		"client":  []byte(testClientConfig),
+	} {
+		require.Empty(t, validateConfig(t, schema, content), name)
+		_, err = json.UnmarshalExtended[Options](content)
+		require.NoError(t, err, name)
+	}
+}
+
+func TestSchemaUnknownField(t *testing.T) {
+	t.Parallel()
+	errors := validateConfig(t, Schema(), []byte(`{"inbounds": [{"type": "mixed", "users": [{"username": "a", "passwd": "b"}]}]}`))
+	require.Len(t, errors, 1)
+	require.Equal(t, "/inbounds/0/users/0/passwd", errors[0].Path)
+}
@@ -2,12 +2,14 @@ package option
 
 type ShadowsocksInboundOptions struct {
 	ListenOptions
-	Network      NetworkList              `json:"network,omitempty"`
-	Method       string                   `json:"method"`
-	Password     string                   `json:"password,omitempty"`
-	Users        []ShadowsocksUser        `json:"users,omitempty"`
-	Destinations []ShadowsocksDestination `json:"destinations,omitempty"`
-	Multiplex    *InboundMultiplexOptions `json:"multiplex,omitempty"`
+	Network       NetworkList              `json:"network,omitempty"`
+	Method        string                   `json:"method"`
+	Password      string                   `json:"password,omitempty"`
+	Users         []ShadowsocksUser        `json:"users,omitempty"`
+	Destinations  []ShadowsocksDestination `json:"destinations,omitempty"`
+	Plugin        string                   `json:"plugin,omitempty"`
+	PluginOptions string                   `json:"plugin_opts,omitempty"`
+	Multiplex     *InboundMultiplexOptions `json:"multiplex,omitempty"`
 }
 
 type ShadowsocksUser struct {
@@ -1,5 +1,20 @@
 package option
 
+type ShadowsocksRInboundOptions struct {
+	ListenOptions
+	Method   string             `json:"method"`
+	Password string             `json:"password,omitempty"`
+	Obfs     string             `json:"obfs,omitempty"`
+	Protocol string             `json:"protocol,omitempty"`
+	Users    []ShadowsocksRUser `json:"users,omitempty"`
+}
+
+type ShadowsocksRUser struct {
+	Name     string `json:"name"`
+	UserID   uint32 `json:"uid"`
+	Password string `json:"password"`
+}
+
 type ShadowsocksROutboundOptions struct {
 	DialerOptions
 	ServerOptions
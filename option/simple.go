@@ -12,6 +12,7 @@ type HTTPMixedInboundOptions struct {
 	Users          []auth.User `json:"users,omitempty"`
 	SetSystemProxy bool        `json:"set_system_proxy,omitempty"`
 	InboundTLSOptionsContainer
+	Fallbacks []InboundFallbackOptions `json:"fallbacks,omitempty"`
 }
 
 type SocksOutboundOptions struct {
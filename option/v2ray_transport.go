@@ -13,6 +13,8 @@ type _V2RayTransportOptions struct {
 	QUICOptions        V2RayQUICOptions        `json:"-"`
 	GRPCOptions        V2RayGRPCOptions        `json:"-"`
 	HTTPUpgradeOptions V2RayHTTPUpgradeOptions `json:"-"`
+	KCPOptions         V2RayKCPOptions         `json:"-"`
+	SplitHTTPOptions   V2RaySplitHTTPOptions   `json:"-"`
 }
 
 type V2RayTransportOptions _V2RayTransportOptions
@@ -30,6 +32,10 @@ func (o V2RayTransportOptions) MarshalJSON() ([]byte, error) {
 		v = o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeKCP:
+		v = o.KCPOptions
+	case C.V2RayTransportTypeSplitHTTP:
+		v = o.SplitHTTPOptions
 	case "":
 		return nil, E.New("missing transport type")
 	default:
@@ -55,6 +61,10 @@ func (o *V2RayTransportOptions) UnmarshalJSON(bytes []byte) error {
 		v = &o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = &o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeKCP:
+		v = &o.KCPOptions
+	case C.V2RayTransportTypeSplitHTTP:
+		v = &o.SplitHTTPOptions
 	default:
 		return E.New("unknown transport type: " + o.Type)
 	}
@@ -96,3 +106,25 @@ type V2RayHTTPUpgradeOptions struct {
 	Path    string     `json:"path,omitempty"`
 	Headers HTTPHeader `json:"headers,omitempty"`
 }
+
+type V2RayKCPOptions struct {
+	MTU              uint32 `json:"mtu,omitempty"`
+	TTI              uint32 `json:"tti,omitempty"`
+	UplinkCapacity   uint32 `json:"uplink_capacity,omitempty"`
+	DownlinkCapacity uint32 `json:"downlink_capacity,omitempty"`
+	Congestion       bool   `json:"congestion,omitempty"`
+	ReadBufferSize   uint32 `json:"read_buffer_size,omitempty"`
+	WriteBufferSize  uint32 `json:"write_buffer_size,omitempty"`
+	HeaderType       string `json:"header_type,omitempty"`
+	Seed             string `json:"seed,omitempty"`
+}
+
+type V2RaySplitHTTPOptions struct {
+	Host               string     `json:"host,omitempty"`
+	Path               string     `json:"path,omitempty"`
+	Headers            HTTPHeader `json:"headers,omitempty"`
+	XPaddingBytes      string     `json:"x_padding_bytes,omitempty"`
+	MaxEachPostBytes   uint32     `json:"max_each_post_bytes,omitempty"`
+	MaxConcurrentPosts uint32     `json:"max_concurrent_posts,omitempty"`
+	NoSSEHeader        bool       `json:"no_sse_header,omitempty"`
+}
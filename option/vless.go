@@ -6,6 +6,7 @@ type VLESSInboundOptions struct {
 	InboundTLSOptionsContainer
 	Multiplex *InboundMultiplexOptions `json:"multiplex,omitempty"`
 	Transport *V2RayTransportOptions   `json:"transport,omitempty"`
+	Fallbacks []InboundFallbackOptions `json:"fallbacks,omitempty"`
 }
 
 type VLESSUser struct {
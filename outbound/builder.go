@@ -63,6 +63,8 @@ func New(ctx context.Context, router adapter.Router, logger log.ContextLogger, t
 		return NewURLTest(ctx, router, logger, tag, options.URLTestOptions)
 	case C.TypeJSTest:
 		return NewJSTest(ctx, router, logger, tag, options.JSTestOptions)
+	case C.TypeRace:
+		return NewRace(ctx, router, logger, tag, options.RaceOptions)
 	default:
 		return nil, E.New("unknown outbound type: ", options.Type)
 	}
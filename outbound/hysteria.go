@@ -25,6 +25,7 @@ import (
 var (
 	_ adapter.Outbound                = (*TUIC)(nil)
 	_ adapter.InterfaceUpdateListener = (*TUIC)(nil)
+	_ adapter.SharedSessionOutbound   = (*Hysteria)(nil)
 )
 
 type Hysteria struct {
@@ -134,6 +135,10 @@ func (h *Hysteria) InterfaceUpdated() error {
 	return h.client.CloseWithError(E.New("network changed"))
 }
 
+func (h *Hysteria) SharedSession() bool {
+	return true
+}
+
 func (h *Hysteria) Close() error {
 	return h.client.CloseWithError(os.ErrClosed)
 }
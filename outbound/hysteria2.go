@@ -25,6 +25,7 @@ import (
 var (
 	_ adapter.Outbound                = (*TUIC)(nil)
 	_ adapter.InterfaceUpdateListener = (*TUIC)(nil)
+	_ adapter.SharedSessionOutbound   = (*Hysteria2)(nil)
 )
 
 type Hysteria2 struct {
@@ -120,6 +121,10 @@ func (h *Hysteria2) InterfaceUpdated() error {
 	return h.client.CloseWithError(E.New("network changed"))
 }
 
+func (h *Hysteria2) SharedSession() bool {
+	return true
+}
+
 func (h *Hysteria2) Close() error {
 	return h.client.CloseWithError(os.ErrClosed)
 }
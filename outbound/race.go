@@ -0,0 +1,365 @@
+package outbound
+
+import (
+	"context"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+const DefaultRaceConcurrency = 2
+
+var (
+	_ adapter.Outbound      = (*Race)(nil)
+	_ adapter.OutboundGroup = (*Race)(nil)
+)
+
+type Race struct {
+	myOutboundAdapter
+	ctx           context.Context
+	tags          []string
+	outbounds     []adapter.Outbound
+	concurrency   int
+	fallbackDelay time.Duration
+	raceNetwork   []string
+	ruleSetTags   []string
+	ruleSets      []adapter.RuleSet
+	history       *urltest.HistoryStorage
+
+	access     sync.Mutex
+	statistics map[string]*RaceStatistics
+	lastWinner string
+}
+
+type RaceStatistics struct {
+	Wins     uint64    `json:"wins"`
+	Failures uint64    `json:"failures"`
+	LastWin  time.Time `json:"last_win,omitempty"`
+}
+
+func NewRace(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.RaceOutboundOptions) (*Race, error) {
+	outbound := &Race{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol:     C.TypeRace,
+			router:       router,
+			logger:       logger,
+			tag:          tag,
+			dependencies: options.Outbounds,
+		},
+		ctx:           ctx,
+		tags:          options.Outbounds,
+		concurrency:   options.Concurrency,
+		fallbackDelay: time.Duration(options.FallbackDelay),
+		raceNetwork:   options.Network.Build(),
+		ruleSetTags:   options.RuleSet,
+		statistics:    make(map[string]*RaceStatistics),
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	if outbound.concurrency == 0 {
+		outbound.concurrency = DefaultRaceConcurrency
+	} else if outbound.concurrency < 0 {
+		return nil, E.New("invalid concurrency: ", outbound.concurrency)
+	}
+	return outbound, nil
+}
+
+func (r *Race) Network() []string {
+	if len(r.outbounds) == 0 {
+		return []string{N.NetworkTCP, N.NetworkUDP}
+	}
+	var networks []string
+	for _, network := range []string{N.NetworkTCP, N.NetworkUDP} {
+		if common.Any(r.outbounds, func(it adapter.Outbound) bool {
+			return common.Contains(it.Network(), network)
+		}) {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func (r *Race) Start() error {
+	for i, tag := range r.tags {
+		detour, loaded := r.router.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		r.outbounds = append(r.outbounds, detour)
+		r.statistics[tag] = &RaceStatistics{}
+	}
+	for _, tag := range r.ruleSetTags {
+		ruleSet, loaded := r.router.RuleSet(tag)
+		if !loaded {
+			return E.New("rule-set not found: ", tag)
+		}
+		r.ruleSets = append(r.ruleSets, ruleSet)
+	}
+	if r.history = service.PtrFromContext[urltest.HistoryStorage](r.ctx); r.history != nil {
+	} else if clashServer := r.router.ClashServer(); clashServer != nil {
+		r.history = clashServer.HistoryStorage()
+	}
+	return nil
+}
+
+func (r *Race) Now() string {
+	r.access.Lock()
+	lastWinner := r.lastWinner
+	r.access.Unlock()
+	if lastWinner != "" {
+		return lastWinner
+	}
+	candidates := r.candidates(N.NetworkTCP)
+	if len(candidates) == 0 {
+		return r.tags[0]
+	}
+	return candidates[0].Tag()
+}
+
+func (r *Race) All() []string {
+	return r.tags
+}
+
+// Statistics returns the race results of each member.
+func (r *Race) Statistics() map[string]RaceStatistics {
+	r.access.Lock()
+	defer r.access.Unlock()
+	statistics := make(map[string]RaceStatistics, len(r.statistics))
+	for tag, memberStatistics := range r.statistics {
+		statistics[tag] = *memberStatistics
+	}
+	return statistics
+}
+
+// candidates returns members supporting the network, ordered by the last URL test delay if available.
+func (r *Race) candidates(network string) []adapter.Outbound {
+	candidates := common.Filter(r.outbounds, func(it adapter.Outbound) bool {
+		return common.Contains(it.Network(), network)
+	})
+	if r.history != nil {
+		delays := make(map[adapter.Outbound]uint16)
+		for _, detour := range candidates {
+			if history := r.history.LoadURLTestHistory(RealTag(detour)); history != nil {
+				delays[detour] = history.Delay
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			delayI, loadedI := delays[candidates[i]]
+			delayJ, loadedJ := delays[candidates[j]]
+			return loadedI && (!loadedJ || delayI < delayJ)
+		})
+	}
+	if len(candidates) > r.concurrency {
+		candidates = candidates[:r.concurrency]
+	}
+	return candidates
+}
+
+func (r *Race) shouldRace(ctx context.Context, network string) bool {
+	if !common.Contains(r.raceNetwork, network) {
+		return false
+	}
+	if len(r.ruleSets) == 0 {
+		return true
+	}
+	metadata := adapter.ContextFrom(ctx)
+	if metadata == nil {
+		return false
+	}
+	matchMetadata := *metadata
+	for _, ruleSet := range r.ruleSets {
+		matchMetadata.ResetRuleCache()
+		if ruleSet.Match(&matchMetadata) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Race) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	candidates := r.candidates(N.NetworkName(network))
+	if len(candidates) == 0 {
+		return nil, E.New("missing supported outbound")
+	}
+	if len(candidates) == 1 || !r.shouldRace(ctx, N.NetworkName(network)) {
+		return candidates[0].DialContext(ctx, network, destination)
+	}
+	conn, cancel, err := raceDial(ctx, r, candidates, func(ctx context.Context, detour adapter.Outbound) (net.Conn, error) {
+		return detour.DialContext(ctx, network, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &raceConn{conn, cancel}, nil
+}
+
+func (r *Race) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	candidates := r.candidates(N.NetworkUDP)
+	if len(candidates) == 0 {
+		return nil, E.New("missing supported outbound")
+	}
+	if len(candidates) == 1 || !r.shouldRace(ctx, N.NetworkUDP) {
+		return candidates[0].ListenPacket(ctx, destination)
+	}
+	conn, cancel, err := raceDial(ctx, r, candidates, func(ctx context.Context, detour adapter.Outbound) (net.PacketConn, error) {
+		return detour.ListenPacket(ctx, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &racePacketConn{conn, cancel}, nil
+}
+
+func (r *Race) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return NewConnection(ctx, r, conn, metadata)
+}
+
+func (r *Race) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return NewPacketConnection(ctx, r, conn, metadata)
+}
+
+func (r *Race) recordFailure(detour adapter.Outbound) {
+	r.access.Lock()
+	defer r.access.Unlock()
+	r.statistics[detour.Tag()].Failures++
+}
+
+func (r *Race) recordWin(detour adapter.Outbound) {
+	r.access.Lock()
+	defer r.access.Unlock()
+	memberStatistics := r.statistics[detour.Tag()]
+	memberStatistics.Wins++
+	memberStatistics.LastWin = time.Now()
+	r.lastWinner = detour.Tag()
+}
+
+// raceDial dials through all candidates, starting the next one after the fallback delay or a failure,
+// keeps the first success and closes the rest, like happy eyeballs across outbounds.
+// The context of the winner is not canceled here since transports may bind the connection to it,
+// the returned CancelFunc must be called when the connection is closed.
+func raceDial[T io.Closer](ctx context.Context, r *Race, candidates []adapter.Outbound, dial func(ctx context.Context, detour adapter.Outbound) (T, error)) (T, context.CancelFunc, error) {
+	type raceResult struct {
+		index  int
+		detour adapter.Outbound
+		conn   T
+		err    error
+	}
+	returned := make(chan struct{})
+	defer close(returned)
+	results := make(chan raceResult)
+	var cancels []context.CancelFunc
+	winner := -1
+	defer func() {
+		for index, cancel := range cancels {
+			if index != winner {
+				cancel()
+			}
+		}
+	}()
+	startRacer := func(detour adapter.Outbound) {
+		racerCtx, cancel := context.WithCancel(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			conn, err := dial(racerCtx, detour)
+			select {
+			case results <- raceResult{index, detour, conn, err}:
+			case <-returned:
+				if err == nil {
+					conn.Close()
+				}
+			}
+		}()
+	}
+	startTime := time.Now()
+	var (
+		next    int
+		pending int
+		errors  []error
+	)
+	startNext := func() {
+		if next < len(candidates) {
+			startRacer(candidates[next])
+			next++
+			pending++
+		}
+	}
+	var fallbackTimer <-chan time.Time
+	if r.fallbackDelay > 0 {
+		startNext()
+		ticker := time.NewTicker(r.fallbackDelay)
+		defer ticker.Stop()
+		fallbackTimer = ticker.C
+	} else {
+		for next < len(candidates) {
+			startNext()
+		}
+	}
+	for {
+		select {
+		case <-fallbackTimer:
+			startNext()
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				winner = result.index
+				r.recordWin(result.detour)
+				r.logger.DebugContext(ctx, "race won by outbound/", result.detour.Type(), "[", result.detour.Tag(), "] in ", time.Since(startTime).Milliseconds(), "ms")
+				return result.conn, cancels[winner], nil
+			}
+			r.recordFailure(result.detour)
+			errors = append(errors, E.Cause(result.err, "outbound/", result.detour.Type(), "[", result.detour.Tag(), "]"))
+			startNext()
+			if pending == 0 {
+				var zero T
+				return zero, nil, E.Errors(errors...)
+			}
+		case <-ctx.Done():
+			var zero T
+			return zero, nil, ctx.Err()
+		}
+	}
+}
+
+// raceConn releases the context of the winning dial when closed.
+type raceConn struct {
+	net.Conn
+	cancel context.CancelFunc
+}
+
+func (c *raceConn) Close() error {
+	defer c.cancel()
+	return c.Conn.Close()
+}
+
+func (c *raceConn) Upstream() any {
+	return c.Conn
+}
+
+type racePacketConn struct {
+	net.PacketConn
+	cancel context.CancelFunc
+}
+
+func (c *racePacketConn) Close() error {
+	defer c.cancel()
+	return c.PacketConn.Close()
+}
+
+func (c *racePacketConn) Upstream() any {
+	return c.PacketConn
+}
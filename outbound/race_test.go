@@ -0,0 +1,213 @@
+package outbound
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type raceTestConn struct {
+	ctx    context.Context
+	closed atomic.Bool
+}
+
+func (c *raceTestConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func newRaceTest(fallbackDelay time.Duration, tags ...string) (*Race, []adapter.Outbound) {
+	logger := log.NewNOPFactory().Logger()
+	race := &Race{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol: C.TypeRace,
+			logger:   logger,
+			tag:      "race",
+		},
+		fallbackDelay: fallbackDelay,
+		statistics:    make(map[string]*RaceStatistics),
+	}
+	var candidates []adapter.Outbound
+	for _, tag := range tags {
+		candidates = append(candidates, NewBlock(logger, tag))
+		race.statistics[tag] = &RaceStatistics{}
+	}
+	return race, candidates
+}
+
+func TestRaceDialWinnerSurvives(t *testing.T) {
+	t.Parallel()
+	race, candidates := newRaceTest(0, "fast", "slow")
+	loserCtx := make(chan context.Context, 1)
+	conn, cancel, err := raceDial(context.Background(), race, candidates, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "fast" {
+			return &raceTestConn{ctx: ctx}, nil
+		}
+		loserCtx <- ctx
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.ctx.Err())
+	require.False(t, conn.closed.Load())
+	select {
+	case ctx := <-loserCtx:
+		<-ctx.Done()
+	case <-time.After(time.Second):
+		t.Fatal("loser not canceled")
+	}
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, conn.ctx.Err())
+	statistics := race.Statistics()
+	require.Equal(t, uint64(1), statistics["fast"].Wins)
+	require.Equal(t, uint64(0), statistics["slow"].Wins)
+	require.Equal(t, "fast", race.lastWinner)
+	cancel()
+	require.ErrorIs(t, conn.ctx.Err(), context.Canceled)
+}
+
+func TestRaceDialClosesLateLoser(t *testing.T) {
+	t.Parallel()
+	race, candidates := newRaceTest(0, "fast", "late")
+	release := make(chan struct{})
+	loserConn := make(chan *raceTestConn, 1)
+	conn, cancel, err := raceDial(context.Background(), race, candidates, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "fast" {
+			return &raceTestConn{ctx: ctx}, nil
+		}
+		<-release
+		lateConn := &raceTestConn{ctx: ctx}
+		loserConn <- lateConn
+		return lateConn, nil
+	})
+	require.NoError(t, err)
+	close(release)
+	lateConn := <-loserConn
+	require.Eventually(t, lateConn.closed.Load, time.Second, 10*time.Millisecond)
+	require.False(t, conn.closed.Load())
+	require.NoError(t, conn.ctx.Err())
+	cancel()
+}
+
+func TestRaceDialFallbackDelay(t *testing.T) {
+	t.Parallel()
+	race, candidates := newRaceTest(time.Second, "first", "second")
+	var started atomic.Int32
+	conn, cancel, err := raceDial(context.Background(), race, candidates, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		started.Add(1)
+		return &raceTestConn{ctx: ctx}, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.ctx.Err())
+	require.Equal(t, int32(1), started.Load())
+	cancel()
+}
+
+func TestRaceDialFailureStartsNext(t *testing.T) {
+	t.Parallel()
+	race, candidates := newRaceTest(time.Hour, "broken", "working")
+	conn, cancel, err := raceDial(context.Background(), race, candidates, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		if detour.Tag() == "broken" {
+			return nil, E.New("refused")
+		}
+		return &raceTestConn{ctx: ctx}, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.ctx.Err())
+	statistics := race.Statistics()
+	require.Equal(t, uint64(1), statistics["broken"].Failures)
+	require.Equal(t, uint64(1), statistics["working"].Wins)
+	cancel()
+}
+
+func TestRaceDialAllFailed(t *testing.T) {
+	t.Parallel()
+	race, candidates := newRaceTest(0, "a", "b")
+	_, _, err := raceDial(context.Background(), race, candidates, func(ctx context.Context, detour adapter.Outbound) (*raceTestConn, error) {
+		return nil, E.New("refused by ", detour.Tag())
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "refused by a")
+	require.Contains(t, err.Error(), "refused by b")
+	statistics := race.Statistics()
+	require.Equal(t, uint64(1), statistics["a"].Failures)
+	require.Equal(t, uint64(1), statistics["b"].Failures)
+}
+
+type raceTestNetConn struct {
+	net.Conn
+	raceTestConn
+}
+
+func (c *raceTestNetConn) Close() error {
+	return c.raceTestConn.Close()
+}
+
+type raceTestOutbound struct {
+	adapter.Outbound
+	tag     string
+	delay   time.Duration
+	started chan time.Time
+	conn    chan *raceTestNetConn
+}
+
+func (o *raceTestOutbound) Type() string {
+	return "test"
+}
+
+func (o *raceTestOutbound) Tag() string {
+	return o.tag
+}
+
+func (o *raceTestOutbound) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (o *raceTestOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	o.started <- time.Now()
+	time.Sleep(o.delay)
+	conn := &raceTestNetConn{raceTestConn: raceTestConn{ctx: ctx}}
+	o.conn <- conn
+	return conn, nil
+}
+
+func TestRaceFallbackDelayAndLosers(t *testing.T) {
+	t.Parallel()
+	const fallbackDelay = 100 * time.Millisecond
+	race, _ := newRaceTest(fallbackDelay)
+	race.concurrency = DefaultRaceConcurrency
+	race.raceNetwork = []string{N.NetworkTCP}
+	slow := &raceTestOutbound{tag: "slow", delay: 3 * fallbackDelay, started: make(chan time.Time, 1), conn: make(chan *raceTestNetConn, 1)}
+	fast := &raceTestOutbound{tag: "fast", started: make(chan time.Time, 1), conn: make(chan *raceTestNetConn, 1)}
+	race.outbounds = []adapter.Outbound{slow, fast}
+	race.statistics["slow"] = &RaceStatistics{}
+	race.statistics["fast"] = &RaceStatistics{}
+
+	conn, err := race.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddr("example.com:443"))
+	require.NoError(t, err)
+	slowStarted, fastStarted := <-slow.started, <-fast.started
+	require.GreaterOrEqual(t, fastStarted.Sub(slowStarted), fallbackDelay)
+	winner := <-fast.conn
+	require.NoError(t, winner.ctx.Err())
+	require.Equal(t, "fast", race.lastWinner)
+
+	loser := <-slow.conn
+	require.Eventually(t, loser.closed.Load, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, loser.ctx.Err(), context.Canceled)
+	require.False(t, winner.closed.Load())
+
+	require.NoError(t, conn.Close())
+	require.True(t, winner.closed.Load())
+	require.ErrorIs(t, winner.ctx.Err(), context.Canceled)
+}
@@ -20,7 +20,10 @@ import (
 	"github.com/sagernet/sing/common/uot"
 )
 
-var _ adapter.Outbound = (*Shadowsocks)(nil)
+var (
+	_ adapter.Outbound              = (*Shadowsocks)(nil)
+	_ adapter.SharedSessionOutbound = (*Shadowsocks)(nil)
+)
 
 type Shadowsocks struct {
 	myOutboundAdapter
@@ -78,6 +81,10 @@ func NewShadowsocks(ctx context.Context, router adapter.Router, logger log.Conte
 	return outbound, nil
 }
 
+func (h *Shadowsocks) SharedSession() bool {
+	return h.multiplexDialer != nil
+}
+
 func (h *Shadowsocks) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	ctx, metadata := adapter.AppendContext(ctx)
 	metadata.Outbound = h.tag
@@ -27,6 +27,7 @@ import (
 var (
 	_ adapter.Outbound                = (*SSH)(nil)
 	_ adapter.InterfaceUpdateListener = (*SSH)(nil)
+	_ adapter.SharedSessionOutbound   = (*SSH)(nil)
 )
 
 type SSH struct {
@@ -184,6 +185,10 @@ func (s *SSH) InterfaceUpdated() {
 	return
 }
 
+func (s *SSH) SharedSession() bool {
+	return true
+}
+
 func (s *SSH) Close() error {
 	return common.Close(s.clientConn)
 }
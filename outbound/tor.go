@@ -24,7 +24,10 @@ import (
 	"github.com/cretz/bine/tor"
 )
 
-var _ adapter.Outbound = (*Tor)(nil)
+var (
+	_ adapter.Outbound              = (*Tor)(nil)
+	_ adapter.SharedSessionOutbound = (*Tor)(nil)
+)
 
 type Tor struct {
 	myOutboundAdapter
@@ -195,6 +198,10 @@ func (t *Tor) recvLoop() {
 	}
 }
 
+func (t *Tor) SharedSession() bool {
+	return true
+}
+
 func (t *Tor) Close() error {
 	err := common.Close(
 		common.PtrOrNil(t.proxy),
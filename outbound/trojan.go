@@ -20,7 +20,10 @@ import (
 	N "github.com/sagernet/sing/common/network"
 )
 
-var _ adapter.Outbound = (*Trojan)(nil)
+var (
+	_ adapter.Outbound              = (*Trojan)(nil)
+	_ adapter.SharedSessionOutbound = (*Trojan)(nil)
+)
 
 type Trojan struct {
 	myOutboundAdapter
@@ -30,6 +33,7 @@ type Trojan struct {
 	multiplexDialer *mux.Client
 	tlsConfig       tls.Config
 	transport       adapter.V2RayClientTransport
+	sharedSession   bool
 }
 
 func NewTrojan(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TrojanOutboundOptions) (*Trojan, error) {
@@ -66,9 +70,14 @@ func NewTrojan(ctx context.Context, router adapter.Router, logger log.ContextLog
 	if err != nil {
 		return nil, err
 	}
+	outbound.sharedSession = outbound.multiplexDialer != nil || options.Transport != nil && v2ray.SharedSession(*options.Transport)
 	return outbound, nil
 }
 
+func (h *Trojan) SharedSession() bool {
+	return h.sharedSession
+}
+
 func (h *Trojan) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	if h.multiplexDialer == nil {
 		switch N.NetworkName(network) {
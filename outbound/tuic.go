@@ -28,6 +28,7 @@ import (
 var (
 	_ adapter.Outbound                = (*TUIC)(nil)
 	_ adapter.InterfaceUpdateListener = (*TUIC)(nil)
+	_ adapter.SharedSessionOutbound   = (*TUIC)(nil)
 )
 
 type TUIC struct {
@@ -148,6 +149,10 @@ func (h *TUIC) InterfaceUpdated() {
 	_ = h.client.CloseWithError(E.New("network changed"))
 }
 
+func (h *TUIC) SharedSession() bool {
+	return true
+}
+
 func (h *TUIC) Close() error {
 	return h.client.CloseWithError(os.ErrClosed)
 }
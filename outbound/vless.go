@@ -21,7 +21,10 @@ import (
 	N "github.com/sagernet/sing/common/network"
 )
 
-var _ adapter.Outbound = (*VLESS)(nil)
+var (
+	_ adapter.Outbound              = (*VLESS)(nil)
+	_ adapter.SharedSessionOutbound = (*VLESS)(nil)
+)
 
 type VLESS struct {
 	myOutboundAdapter
@@ -31,6 +34,7 @@ type VLESS struct {
 	multiplexDialer *mux.Client
 	tlsConfig       tls.Config
 	transport       adapter.V2RayClientTransport
+	sharedSession   bool
 	packetAddr      bool
 	xudp            bool
 }
@@ -85,9 +89,14 @@ func NewVLESS(ctx context.Context, router adapter.Router, logger log.ContextLogg
 	if err != nil {
 		return nil, err
 	}
+	outbound.sharedSession = outbound.multiplexDialer != nil || options.Transport != nil && v2ray.SharedSession(*options.Transport)
 	return outbound, nil
 }
 
+func (h *VLESS) SharedSession() bool {
+	return h.sharedSession
+}
+
 func (h *VLESS) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	if h.multiplexDialer == nil {
 		switch N.NetworkName(network) {
@@ -21,7 +21,10 @@ import (
 	"github.com/sagernet/sing/common/ntp"
 )
 
-var _ adapter.Outbound = (*VMess)(nil)
+var (
+	_ adapter.Outbound              = (*VMess)(nil)
+	_ adapter.SharedSessionOutbound = (*VMess)(nil)
+)
 
 type VMess struct {
 	myOutboundAdapter
@@ -31,6 +34,7 @@ type VMess struct {
 	multiplexDialer *mux.Client
 	tlsConfig       tls.Config
 	transport       adapter.V2RayClientTransport
+	sharedSession   bool
 	packetAddr      bool
 	xudp            bool
 }
@@ -68,6 +72,7 @@ func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogg
 	if err != nil {
 		return nil, err
 	}
+	outbound.sharedSession = outbound.multiplexDialer != nil || options.Transport != nil && v2ray.SharedSession(*options.Transport)
 	switch options.PacketEncoding {
 	case "":
 	case "packetaddr":
@@ -113,6 +118,10 @@ func (h *VMess) Close() error {
 	return common.Close(common.PtrOrNil(h.multiplexDialer), h.transport)
 }
 
+func (h *VMess) SharedSession() bool {
+	return h.sharedSession
+}
+
 func (h *VMess) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	if h.multiplexDialer == nil {
 		switch N.NetworkName(network) {
@@ -28,6 +28,7 @@ import (
 var (
 	_ adapter.Outbound                = (*WireGuard)(nil)
 	_ adapter.InterfaceUpdateListener = (*WireGuard)(nil)
+	_ adapter.SharedSessionOutbound   = (*WireGuard)(nil)
 )
 
 type WireGuard struct {
@@ -238,6 +239,10 @@ func (w *WireGuard) Start() error {
 	return w.tunDevice.Start()
 }
 
+func (w *WireGuard) SharedSession() bool {
+	return true
+}
+
 func (w *WireGuard) Close() error {
 	if w.device != nil {
 		w.device.Close()
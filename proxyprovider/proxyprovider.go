@@ -590,6 +590,10 @@ func copyDialerOptions(dialer *option.DialerOptions) option.DialerOptions {
 		newDialer.UDPFragment = new(bool)
 		*newDialer.UDPFragment = *dialer.UDPFragment
 	}
+	if dialer.DomainResolver != nil {
+		newDialer.DomainResolver = new(option.DomainResolveOptions)
+		*newDialer.DomainResolver = *dialer.DomainResolver
+	}
 	return newDialer
 }
 
@@ -109,7 +109,13 @@ func (p *VLESS) ParseLink(link string) error {
 	_type := args.Get("type")
 	switch _type {
 	case "kcp":
-		return fmt.Errorf("parse link `%s` failed: kcp unsupported", link)
+		options.VLESSOptions.Transport = &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeKCP,
+			KCPOptions: option.V2RayKCPOptions{
+				HeaderType: args.Get("headerType"),
+				Seed:       args.Get("seed"),
+			},
+		}
 	case "quic":
 		quicSecurity := args.Get("quicSecurity")
 		if quicSecurity != "" && quicSecurity != "none" {
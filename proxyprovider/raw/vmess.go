@@ -87,7 +87,13 @@ func (p *VMess) ParseLink(link string) error {
 	}
 	switch _vmessInfo.Network {
 	case "kcp":
-		return fmt.Errorf("parse link `%s` failed: kcp unsupported", link)
+		options.VMessOptions.Transport = &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeKCP,
+			KCPOptions: option.V2RayKCPOptions{
+				HeaderType: _vmessInfo.Type,
+				Seed:       _vmessInfo.Path,
+			},
+		}
 	case "quic":
 		quicSecurity := _vmessInfo.Type
 		if quicSecurity != "" && quicSecurity != "none" {
@@ -13,10 +13,12 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/capture"
 	"github.com/sagernet/sing-box/common/conntrack"
 	"github.com/sagernet/sing-box/common/dialer"
 	"github.com/sagernet/sing-box/common/geoip"
 	"github.com/sagernet/sing-box/common/geosite"
+	"github.com/sagernet/sing-box/common/neighbor"
 	"github.com/sagernet/sing-box/common/process"
 	"github.com/sagernet/sing-box/common/sniff"
 	"github.com/sagernet/sing-box/common/taskmonitor"
@@ -54,12 +56,14 @@ type Router struct {
 	logger                             log.ContextLogger
 	dnsLogger                          log.ContextLogger
 	inboundByTag                       map[string]adapter.Inbound
+	inboundOptions                     map[string]option.InboundOptions
 	outbounds                          []adapter.Outbound
 	outboundByTag                      map[string]adapter.Outbound
 	proxyProviders                     []adapter.ProxyProvider
 	proxyProviderByTag                 map[string]adapter.ProxyProvider
 	rules                              []adapter.Rule
 	defaultDetour                      string
+	retry                              []string
 	defaultOutboundForConnection       adapter.Outbound
 	defaultOutboundForPacketConnection adapter.Outbound
 	needGeoIPDatabase                  bool
@@ -75,6 +79,9 @@ type Router struct {
 	geoIPPath                          string
 	geoUpdateLock                      sync.Mutex
 	needFindProcess                    bool
+	needFindNeighbor                   bool
+	needFindHostname                   bool
+	needFindInboundInterface           bool
 	dnsClient                          *dns.Client
 	defaultDomainStrategy              dns.DomainStrategy
 	dnsRules                           []adapter.DNSRule
@@ -94,10 +101,13 @@ type Router struct {
 	interfaceMonitor                   tun.DefaultInterfaceMonitor
 	packageManager                     tun.PackageManager
 	processSearcher                    process.Searcher
+	neighborResolver                   *neighbor.Resolver
 	timeService                        *ntp.Service
 	pauseManager                       pause.Manager
 	clashServer                        adapter.ClashServer
 	v2rayServer                        adapter.V2RayServer
+	accessLogger                       *log.AccessLogger
+	captureManager                     *capture.Manager
 	platformInterface                  platform.Interface
 	needWIFIState                      bool
 	needPackageManager                 bool
@@ -117,28 +127,33 @@ func NewRouter(
 	reloadChan chan<- struct{},
 ) (*Router, error) {
 	router := &Router{
-		ctx:                   ctx,
-		logger:                logFactory.NewLogger("router"),
-		dnsLogger:             logFactory.NewLogger("dns"),
-		outboundByTag:         make(map[string]adapter.Outbound),
-		rules:                 make([]adapter.Rule, 0, len(options.Rules)),
-		dnsRules:              make([]adapter.DNSRule, 0, len(dnsOptions.Rules)),
-		ruleSetMap:            make(map[string]adapter.RuleSet),
-		needGeoIPDatabase:     hasRule(options.Rules, isGeoIPRule) || hasDNSRule(dnsOptions.Rules, isGeoIPDNSRule),
-		needGeositeDatabase:   hasRule(options.Rules, isGeositeRule) || hasDNSRule(dnsOptions.Rules, isGeositeDNSRule),
-		geoIPOptions:          common.PtrValueOrDefault(options.GeoIP),
-		geositeOptions:        common.PtrValueOrDefault(options.Geosite),
-		geositeCache:          make(map[string]adapter.Rule),
-		needFindProcess:       hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
-		defaultDetour:         options.Final,
-		defaultDomainStrategy: dns.DomainStrategy(dnsOptions.Strategy),
-		autoDetectInterface:   options.AutoDetectInterface,
-		defaultInterface:      options.DefaultInterface,
-		defaultMark:           options.DefaultMark,
-		pauseManager:          pause.ManagerFromContext(ctx),
-		platformInterface:     platformInterface,
-		needWIFIState:         hasRule(options.Rules, isWIFIRule) || hasDNSRule(dnsOptions.Rules, isWIFIDNSRule),
-		reloadChan:            reloadChan,
+		ctx:                      ctx,
+		logger:                   logFactory.NewLogger("router"),
+		dnsLogger:                logFactory.NewLogger("dns"),
+		outboundByTag:            make(map[string]adapter.Outbound),
+		rules:                    make([]adapter.Rule, 0, len(options.Rules)),
+		dnsRules:                 make([]adapter.DNSRule, 0, len(dnsOptions.Rules)),
+		ruleSetMap:               make(map[string]adapter.RuleSet),
+		needGeoIPDatabase:        hasRule(options.Rules, isGeoIPRule) || hasDNSRule(dnsOptions.Rules, isGeoIPDNSRule),
+		needGeositeDatabase:      hasRule(options.Rules, isGeositeRule) || hasDNSRule(dnsOptions.Rules, isGeositeDNSRule),
+		geoIPOptions:             common.PtrValueOrDefault(options.GeoIP),
+		geositeOptions:           common.PtrValueOrDefault(options.Geosite),
+		geositeCache:             make(map[string]adapter.Rule),
+		needFindProcess:          hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
+		needFindNeighbor:         hasRule(options.Rules, isNeighborRule),
+		needFindHostname:         hasRule(options.Rules, isHostnameRule),
+		needFindInboundInterface: hasRule(options.Rules, isInboundInterfaceRule),
+		defaultDetour:            options.Final,
+		retry:                    options.Retry,
+		defaultDomainStrategy:    dns.DomainStrategy(dnsOptions.Strategy),
+		autoDetectInterface:      options.AutoDetectInterface,
+		defaultInterface:         options.DefaultInterface,
+		defaultMark:              options.DefaultMark,
+		pauseManager:             pause.ManagerFromContext(ctx),
+		captureManager:           service.PtrFromContext[capture.Manager](ctx),
+		platformInterface:        platformInterface,
+		needWIFIState:            hasRule(options.Rules, isWIFIRule) || hasDNSRule(dnsOptions.Rules, isWIFIDNSRule),
+		reloadChan:               reloadChan,
 		needPackageManager: C.IsAndroid && platformInterface == nil && common.Any(inbounds, func(inbound option.Inbound) bool {
 			return len(inbound.TunOptions.IncludePackage) > 0 || len(inbound.TunOptions.ExcludePackage) > 0
 		}),
@@ -163,6 +178,19 @@ func NewRouter(
 		}
 		router.dnsRules = append(router.dnsRules, dnsRule)
 	}
+	router.inboundOptions = make(map[string]option.InboundOptions)
+	for _, inbound := range inbounds {
+		if inbound.Tag == "" {
+			continue
+		}
+		rawOptions, err := inbound.RawOptions()
+		if err != nil {
+			continue
+		}
+		if wrapper, isWrapper := rawOptions.(option.InboundOptionsWrapper); isWrapper {
+			router.inboundOptions[inbound.Tag] = wrapper.TakeInboundOptions()
+		}
+	}
 	for i, ruleSetOptions := range options.RuleSet {
 		if _, exists := router.ruleSetMap[ruleSetOptions.Tag]; exists {
 			return nil, E.New("duplicate rule-set tag: ", ruleSetOptions.Tag)
@@ -285,6 +313,14 @@ func NewRouter(
 	router.transportMap = transportMap
 	router.transportDomainStrategy = transportDomainStrategy
 
+	if router.needFindNeighbor || router.needFindInboundInterface {
+		var lookupPTR neighbor.PTRLookupFunc
+		if router.needFindHostname {
+			lookupPTR = router.lookupPTR
+		}
+		router.neighborResolver = neighbor.NewResolver(options.DHCPLeaseFiles, lookupPTR)
+	}
+
 	if dnsOptions.ReverseMapping {
 		router.dnsReverseMapping = NewDNSReverseMapping()
 	}
@@ -428,6 +464,25 @@ func (r *Router) Initialize(inbounds []adapter.Inbound, outbounds []adapter.Outb
 		if _, loaded := outboundByTag[rule.Outbound()]; !loaded {
 			return E.New("outbound not found for rule[", i, "]: ", rule.Outbound())
 		}
+		for _, hop := range rule.Chain() {
+			if _, loaded := outboundByTag[hop]; !loaded {
+				return E.New("chain outbound not found for rule[", i, "]: ", hop)
+			}
+		}
+		err := checkChain(outboundByTag, rule)
+		if err != nil {
+			return E.Cause(err, "chain of rule[", i, "]")
+		}
+		for _, tag := range rule.Retry() {
+			if _, loaded := outboundByTag[tag]; !loaded {
+				return E.New("retry outbound not found for rule[", i, "]: ", tag)
+			}
+		}
+	}
+	for _, tag := range r.retry {
+		if _, loaded := outboundByTag[tag]; !loaded {
+			return E.New("retry outbound not found: ", tag)
+		}
 	}
 	r.proxyProviders = proxyProviders
 	r.proxyProviderByTag = proxyProviderByTag
@@ -734,7 +789,17 @@ func (r *Router) RuleSet(tag string) (adapter.RuleSet, bool) {
 	return ruleSet, loaded
 }
 
-func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) (err error) {
+	metadata.Network = N.NetworkTCP
+	var record *accessRecord
+	if r.accessLogger != nil {
+		record = newAccessRecord(ctx)
+		defer func() {
+			if record != nil {
+				r.writeAccessRecord(record, metadata, err)
+			}
+		}()
+	}
 	if metadata.InboundDetour != "" {
 		if metadata.LastInbound == metadata.InboundDetour {
 			return E.New("routing loop on detour: ", metadata.InboundDetour)
@@ -753,14 +818,15 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 		metadata.LastInbound = metadata.Inbound
 		metadata.Inbound = metadata.InboundDetour
 		metadata.InboundDetour = ""
-		err := injectable.NewConnection(ctx, conn, metadata)
+		// the injected inbound routes the connection again and writes its own access record
+		record = nil
+		err = injectable.NewConnection(ctx, conn, metadata)
 		if err != nil {
 			return E.Cause(err, "inject ", detour.Tag())
 		}
 		return nil
 	}
 	conntrack.KillerCheck()
-	metadata.Network = N.NetworkTCP
 	switch metadata.Destination.Fqdn {
 	case mux.Destination.Fqdn:
 		return E.New("global multiplex is deprecated since sing-box v1.7.0, enable multiplex in inbound options instead.")
@@ -790,12 +856,22 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 		conn = deadline.NewConn(conn)
 	}
 
+	if record != nil {
+		conn = record.wrapConn(conn)
+	}
+
 	if metadata.InboundOptions.SniffEnabled {
 		buffer := buf.NewPacket()
-		sniffMetadata, err := sniff.PeekStream(ctx, conn, buffer, time.Duration(metadata.InboundOptions.SniffTimeout), sniff.StreamDomainNameQuery, sniff.TLSClientHello, sniff.HTTPHost)
+		sniffMetadata, err := sniff.PeekStream(ctx, conn, buffer, time.Duration(metadata.InboundOptions.SniffTimeout), sniff.StreamDomainNameQuery, sniff.TLSClientHello, sniff.HTTPHost, sniff.BitTorrent, sniff.SSH, sniff.RDP)
 		if sniffMetadata != nil {
 			metadata.Protocol = sniffMetadata.Protocol
 			metadata.Domain = sniffMetadata.Domain
+			metadata.ALPN = sniffMetadata.ALPN
+			metadata.JA3 = sniffMetadata.JA3
+			metadata.JA4 = sniffMetadata.JA4
+			metadata.HTTPMethod = sniffMetadata.HTTPMethod
+			metadata.HTTPPath = sniffMetadata.HTTPPath
+			metadata.UserAgent = sniffMetadata.UserAgent
 			if metadata.InboundOptions.SniffOverrideDestination && M.IsDomainName(metadata.Domain) {
 				metadata.Destination = M.Socksaddr{
 					Fqdn: metadata.Domain,
@@ -838,6 +914,7 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 	} else if metadata.Destination.IsIPv6() {
 		metadata.IPVersion = 6
 	}
+	routeCtx := ctx
 	ctx, matchedRule, detour, err := r.match(ctx, &metadata, r.defaultOutboundForConnection)
 	if err != nil {
 		return err
@@ -845,8 +922,13 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 	if !common.Contains(detour.Network(), N.NetworkTCP) {
 		return E.New("missing supported outbound, closing connection")
 	}
+	if record != nil {
+		r.prepareAccessRecord(record, matchedRule, detour)
+	}
+	var tracker adapter.Tracker
 	if r.clashServer != nil {
-		trackerConn, tracker := r.clashServer.RoutedConnection(ctx, conn, metadata, matchedRule)
+		var trackerConn net.Conn
+		trackerConn, tracker = r.clashServer.RoutedConnection(ctx, conn, metadata, matchedRule)
 		defer tracker.Leave()
 		conn = trackerConn
 	}
@@ -855,10 +937,28 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 			conn = statsService.RoutedConnection(metadata.Inbound, detour.Tag(), metadata.User, conn)
 		}
 	}
-	return detour.NewConnection(ctx, conn, metadata)
+	if activeCapture := r.captureManager.Active(); activeCapture.MatchConnection(&metadata) {
+		conn = activeCapture.NewConn(conn, metadata, detour.Tag())
+	}
+	if retry := r.retryOutbounds(matchedRule); len(retry) > 0 {
+		err = r.newConnectionWithRetry(ctx, routeCtx, conn, metadata, detour, retry, record, tracker)
+	} else {
+		err = detour.NewConnection(ctx, conn, metadata)
+	}
+	return err
 }
 
-func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) (err error) {
+	metadata.Network = N.NetworkUDP
+	var record *accessRecord
+	if r.accessLogger != nil {
+		record = newAccessRecord(ctx)
+		defer func() {
+			if record != nil {
+				r.writeAccessRecord(record, metadata, err)
+			}
+		}()
+	}
 	if metadata.InboundDetour != "" {
 		if metadata.LastInbound == metadata.InboundDetour {
 			return E.New("routing loop on detour: ", metadata.InboundDetour)
@@ -877,14 +977,15 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 		metadata.LastInbound = metadata.Inbound
 		metadata.Inbound = metadata.InboundDetour
 		metadata.InboundDetour = ""
-		err := injectable.NewPacketConnection(ctx, conn, metadata)
+		// the injected inbound routes the connection again and writes its own access record
+		record = nil
+		err = injectable.NewPacketConnection(ctx, conn, metadata)
 		if err != nil {
 			return E.Cause(err, "inject ", detour.Tag())
 		}
 		return nil
 	}
 	conntrack.KillerCheck()
-	metadata.Network = N.NetworkUDP
 
 	if r.fakeIPStore != nil && r.fakeIPStore.Contains(metadata.Destination.Addr) {
 		domain, loaded := r.fakeIPStore.Lookup(metadata.Destination.Addr)
@@ -905,6 +1006,10 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 		conn = deadline.NewPacketConn(bufio.NewNetPacketConn(conn))
 	}*/
 
+	if record != nil {
+		conn = record.wrapPacketConn(conn)
+	}
+
 	if metadata.InboundOptions.SniffEnabled || metadata.Destination.Addr.IsUnspecified() {
 		buffer := buf.NewPacket()
 		destination, err := conn.ReadPacket(buffer)
@@ -916,10 +1021,16 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 			metadata.Destination = destination
 		}
 		if metadata.InboundOptions.SniffEnabled {
-			sniffMetadata, _ := sniff.PeekPacket(ctx, buffer.Bytes(), sniff.DomainNameQuery, sniff.QUICClientHello, sniff.STUNMessage)
+			sniffMetadata, _ := sniff.PeekPacket(ctx, buffer.Bytes(), sniff.DomainNameQuery, sniff.QUICClientHello, sniff.STUNMessage, sniff.DTLSClientHello, sniff.DHT, sniff.UTP, sniff.NTP)
 			if sniffMetadata != nil {
 				metadata.Protocol = sniffMetadata.Protocol
 				metadata.Domain = sniffMetadata.Domain
+				metadata.ALPN = sniffMetadata.ALPN
+				metadata.JA3 = sniffMetadata.JA3
+				metadata.JA4 = sniffMetadata.JA4
+				metadata.HTTPMethod = sniffMetadata.HTTPMethod
+				metadata.HTTPPath = sniffMetadata.HTTPPath
+				metadata.UserAgent = sniffMetadata.UserAgent
 				if metadata.InboundOptions.SniffOverrideDestination && M.IsDomainName(metadata.Domain) {
 					metadata.Destination = M.Socksaddr{
 						Fqdn: metadata.Domain,
@@ -955,6 +1066,7 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 	} else if metadata.Destination.IsIPv6() {
 		metadata.IPVersion = 6
 	}
+	routeCtx := ctx
 	ctx, matchedRule, detour, err := r.match(ctx, &metadata, r.defaultOutboundForPacketConnection)
 	if err != nil {
 		return err
@@ -962,8 +1074,13 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 	if !common.Contains(detour.Network(), N.NetworkUDP) {
 		return E.New("missing supported outbound, closing packet connection")
 	}
+	if record != nil {
+		r.prepareAccessRecord(record, matchedRule, detour)
+	}
+	var tracker adapter.Tracker
 	if r.clashServer != nil {
-		trackerConn, tracker := r.clashServer.RoutedPacketConnection(ctx, conn, metadata, matchedRule)
+		var trackerConn N.PacketConn
+		trackerConn, tracker = r.clashServer.RoutedPacketConnection(ctx, conn, metadata, matchedRule)
 		defer tracker.Leave()
 		conn = trackerConn
 	}
@@ -975,7 +1092,15 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 	if metadata.FakeIP {
 		conn = bufio.NewNATPacketConn(bufio.NewNetPacketConn(conn), metadata.OriginDestination, metadata.Destination)
 	}
-	return detour.NewPacketConnection(ctx, conn, metadata)
+	if activeCapture := r.captureManager.Active(); activeCapture.MatchConnection(&metadata) {
+		conn = activeCapture.NewPacketConn(conn, metadata, detour.Tag())
+	}
+	if retry := r.retryOutbounds(matchedRule); len(retry) > 0 {
+		err = r.newPacketConnectionWithRetry(ctx, routeCtx, conn, metadata, detour, retry, record, tracker)
+	} else {
+		err = detour.NewPacketConnection(ctx, conn, metadata)
+	}
+	return err
 }
 
 func (r *Router) match(ctx context.Context, metadata *adapter.InboundContext, defaultOutbound adapter.Outbound) (context.Context, adapter.Rule, adapter.Outbound, error) {
@@ -986,6 +1111,9 @@ func (r *Router) match(ctx context.Context, metadata *adapter.InboundContext, de
 		}
 	}
 	ctx = outbound.ContextWithTag(ctx, matchOutbound.Tag())
+	if matchRule != nil && len(matchRule.Chain()) > 0 {
+		ctx = dialer.ContextWithChain(ctx, matchRule.Chain())
+	}
 	return ctx, matchRule, matchOutbound, nil
 }
 
@@ -1021,18 +1149,45 @@ func (r *Router) match0(ctx context.Context, metadata *adapter.InboundContext, d
 			metadata.ProcessInfo = processInfo
 		}
 	}
+	if r.neighborResolver != nil && metadata.Source.IsIP() {
+		r.findNeighbor(ctx, metadata)
+	}
+	matchedRule, matchedOutbound := r.matchRules(metadata, func(index int, rule adapter.Rule) bool {
+		if !rule.Match(metadata) {
+			return false
+		}
+		if chain := rule.Chain(); len(chain) > 0 {
+			r.logger.DebugContext(ctx, "match[", index, "] ", rule.String(), " => ", strings.Join(chain, " -> "), " -> ", rule.Outbound())
+		} else {
+			r.logger.DebugContext(ctx, "match[", index, "] ", rule.String(), " => ", rule.Outbound())
+		}
+		return true
+	}, func(index int, rule adapter.Rule) {
+		r.logger.ErrorContext(ctx, "outbound not found: ", rule.Outbound())
+	})
+	if matchedRule == nil {
+		return nil, defaultOutbound
+	}
+	return matchedRule, matchedOutbound
+}
+
+// matchRules is the rule loop shared by match0, Explain and RouteChain:
+// the first rule accepted by matchRule whose outbound exists wins,
+// outboundNotFound is called for accepted rules with a missing outbound and may be nil.
+func (r *Router) matchRules(metadata *adapter.InboundContext, matchRule func(index int, rule adapter.Rule) bool, outboundNotFound func(index int, rule adapter.Rule)) (adapter.Rule, adapter.Outbound) {
 	for i, rule := range r.rules {
 		metadata.ResetRuleCache()
-		if rule.Match(metadata) {
-			detour := rule.Outbound()
-			r.logger.DebugContext(ctx, "match[", i, "] ", rule.String(), " => ", detour)
-			if outbound, loaded := r.Outbound(detour); loaded {
-				return rule, outbound
-			}
-			r.logger.ErrorContext(ctx, "outbound not found: ", detour)
+		if !matchRule(i, rule) {
+			continue
+		}
+		if outbound, loaded := r.Outbound(rule.Outbound()); loaded {
+			return rule, outbound
+		}
+		if outboundNotFound != nil {
+			outboundNotFound(i, rule)
 		}
 	}
-	return nil, defaultOutbound
+	return nil, nil
 }
 
 func (r *Router) InterfaceFinder() control.InterfaceFinder {
@@ -1096,6 +1251,13 @@ func (r *Router) Rules() []adapter.Rule {
 	return r.rules
 }
 
+func (r *Router) TimeFunc() func() time.Time {
+	if r.timeService == nil {
+		return time.Now
+	}
+	return r.timeService.TimeFunc()
+}
+
 func (r *Router) WIFIState() adapter.WIFIState {
 	return r.wifiState
 }
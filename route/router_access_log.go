@@ -0,0 +1,100 @@
+package route
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing/common/atomic"
+	"github.com/sagernet/sing/common/bufio"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type accessRecord struct {
+	log.AccessRecord
+	upload   atomic.Int64
+	download atomic.Int64
+}
+
+func (r *accessRecord) countUpload(n int64) {
+	r.upload.Add(n)
+}
+
+func (r *accessRecord) countDownload(n int64) {
+	r.download.Add(n)
+}
+
+func (r *Router) SetAccessLogger(logger *log.AccessLogger) {
+	r.accessLogger = logger
+}
+
+func newAccessRecord(ctx context.Context) *accessRecord {
+	record := &accessRecord{
+		AccessRecord: log.AccessRecord{
+			Start: time.Now(),
+		},
+	}
+	if id, loaded := log.IDFromContext(ctx); loaded {
+		record.ID = id.ID
+		record.Start = id.CreatedAt
+	}
+	return record
+}
+
+// Counters are installed before sniffing, so the sniffed payload replayed from cache is counted too.
+func (r *accessRecord) wrapConn(conn net.Conn) net.Conn {
+	return bufio.NewCounterConn(conn, []N.CountFunc{r.countUpload}, []N.CountFunc{r.countDownload})
+}
+
+func (r *accessRecord) wrapPacketConn(conn N.PacketConn) N.PacketConn {
+	return bufio.NewCounterPacketConn(conn, []N.CountFunc{r.countUpload}, []N.CountFunc{r.countDownload})
+}
+
+// prepareAccessRecord records the routing result, the retry path updates the outbound and chain later.
+func (r *Router) prepareAccessRecord(record *accessRecord, matchedRule adapter.Rule, detour adapter.Outbound) {
+	record.Outbound = detour.Tag()
+	record.Chain = nil
+	if matchedRule != nil {
+		record.Rule = matchedRule.String()
+		record.Chain = append(record.Chain, matchedRule.Chain()...)
+	} else {
+		record.Rule = "final"
+	}
+	var outbound adapter.Outbound = detour
+	for {
+		record.Chain = append(record.Chain, outbound.Tag())
+		group, isGroup := outbound.(adapter.OutboundGroup)
+		if !isGroup {
+			break
+		}
+		next, loaded := r.Outbound(group.Now())
+		if !loaded {
+			break
+		}
+		outbound = next
+	}
+}
+
+// writeAccessRecord is deferred by the route functions, so connections failed before or during routing are recorded too,
+// with the metadata as far as it has been resolved.
+func (r *Router) writeAccessRecord(record *accessRecord, metadata adapter.InboundContext, err error) {
+	record.Network = metadata.Network
+	record.Inbound = metadata.Inbound
+	record.InboundType = metadata.InboundType
+	record.User = metadata.User
+	if metadata.Source.IsValid() {
+		record.Source = metadata.Source.String()
+	}
+	record.Destination = metadata.Destination.String()
+	record.Domain = metadata.Domain
+	record.Protocol = metadata.Protocol
+	record.Duration = time.Since(record.Start).Milliseconds()
+	record.Upload = record.upload.Load()
+	record.Download = record.download.Load()
+	if err != nil {
+		record.Error = err.Error()
+	}
+	r.accessLogger.Write(record.AccessRecord)
+}
@@ -0,0 +1,284 @@
+package route
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testConnectionOutbound struct {
+	testOutbound
+	network []string
+	err     error
+}
+
+func (o *testConnectionOutbound) Network() []string {
+	if o.network != nil {
+		return o.network
+	}
+	return o.testOutbound.Network()
+}
+
+func (o *testConnectionOutbound) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return o.err
+}
+
+func (o *testConnectionOutbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return o.err
+}
+
+type testInjectableInbound struct {
+	adapter.Inbound
+}
+
+func (i *testInjectableInbound) Network() []string {
+	return []string{N.NetworkTCP, N.NetworkUDP}
+}
+
+func (i *testInjectableInbound) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return nil
+}
+
+func (i *testInjectableInbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return nil
+}
+
+type testClosedPacketConn struct {
+	N.PacketConn
+}
+
+func (c *testClosedPacketConn) ReadPacket(buffer *buf.Buffer) (M.Socksaddr, error) {
+	return M.Socksaddr{}, io.EOF
+}
+
+func newAccessLogTestRouter(t *testing.T, defaultOutbound adapter.Outbound) (*Router, string) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	accessLogger, err := log.NewAccessLogger(context.Background(), option.AccessLogOptions{Output: path})
+	require.NoError(t, err)
+	require.NoError(t, accessLogger.Start())
+	t.Cleanup(func() {
+		accessLogger.Close()
+	})
+	logger := log.NewNOPFactory().Logger()
+	return &Router{
+		logger:       logger,
+		dnsLogger:    logger,
+		accessLogger: accessLogger,
+		inboundByTag: map[string]adapter.Inbound{
+			"injectable": &testInjectableInbound{},
+		},
+		outboundByTag: map[string]adapter.Outbound{
+			defaultOutbound.Tag(): defaultOutbound,
+		},
+		defaultOutboundForConnection:       defaultOutbound,
+		defaultOutboundForPacketConnection: defaultOutbound,
+	}, path
+}
+
+func readAccessRecords(t *testing.T, path string) []log.AccessRecord {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var records []log.AccessRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record log.AccessRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestAccessLogRouteConnection(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		outbound *testConnectionOutbound
+		metadata adapter.InboundContext
+		rule     string
+		err      string
+	}{
+		{
+			name:     "success",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			rule:     "final",
+		},
+		{
+			name:     "outbound error",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}, err: E.New("connection refused")},
+			rule:     "final",
+			err:      "connection refused",
+		},
+		{
+			name:     "unsupported network",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}, network: []string{N.NetworkUDP}},
+			err:      "missing supported outbound",
+		},
+		{
+			name:     "detour loop",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			metadata: adapter.InboundContext{LastInbound: "injectable", InboundDetour: "injectable"},
+			err:      "routing loop on detour",
+		},
+		{
+			name:     "detour not found",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			metadata: adapter.InboundContext{InboundDetour: "missing"},
+			err:      "inbound detour not found",
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			router, path := newAccessLogTestRouter(t, testCase.outbound)
+			metadata := testCase.metadata
+			metadata.Inbound = "in"
+			metadata.InboundType = "mixed"
+			metadata.Source = M.ParseSocksaddr("10.0.0.1:1000")
+			metadata.Destination = M.ParseSocksaddr("1.1.1.1:443")
+			ctx := log.ContextWithNewID(context.Background())
+			id, _ := log.IDFromContext(ctx)
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			err := router.RouteConnection(ctx, server, metadata)
+			records := readAccessRecords(t, path)
+			require.Len(t, records, 1)
+			record := records[0]
+			require.Equal(t, id.ID, record.ID)
+			require.Equal(t, N.NetworkTCP, record.Network)
+			require.Equal(t, "in", record.Inbound)
+			require.Equal(t, "10.0.0.1:1000", record.Source)
+			require.Equal(t, "1.1.1.1:443", record.Destination)
+			require.Equal(t, testCase.rule, record.Rule)
+			if testCase.err == "" {
+				require.NoError(t, err)
+				require.Empty(t, record.Error)
+				require.Equal(t, "direct", record.Outbound)
+				require.Equal(t, []string{"direct"}, record.Chain)
+			} else {
+				require.ErrorContains(t, err, testCase.err)
+				require.Contains(t, record.Error, testCase.err)
+			}
+		})
+	}
+}
+
+func TestAccessLogRouteConnectionMatchedRule(t *testing.T) {
+	t.Parallel()
+	router, path := newAccessLogTestRouter(t, &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}})
+	router.outboundByTag["proxy"] = &testConnectionOutbound{testOutbound: testOutbound{tag: "proxy"}}
+	router.rules = []adapter.Rule{&testRule{outbound: "proxy", chain: []string{"direct"}}}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	require.NoError(t, router.RouteConnection(context.Background(), server, adapter.InboundContext{
+		Destination: M.ParseSocksaddr("example.com:443"),
+	}))
+	records := readAccessRecords(t, path)
+	require.Len(t, records, 1)
+	require.Equal(t, "test", records[0].Rule)
+	require.Equal(t, "proxy", records[0].Outbound)
+	require.Equal(t, []string{"direct", "proxy"}, records[0].Chain)
+	require.Equal(t, "example.com:443", records[0].Destination)
+}
+
+func TestAccessLogRouteConnectionInjected(t *testing.T) {
+	t.Parallel()
+	router, path := newAccessLogTestRouter(t, &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}})
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	require.NoError(t, router.RouteConnection(context.Background(), server, adapter.InboundContext{
+		InboundDetour: "injectable",
+		Destination:   M.ParseSocksaddr("1.1.1.1:443"),
+	}))
+	require.Empty(t, readAccessRecords(t, path))
+}
+
+func TestAccessLogRoutePacketConnection(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		outbound *testConnectionOutbound
+		conn     N.PacketConn
+		metadata adapter.InboundContext
+		err      string
+	}{
+		{
+			name:     "success",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			metadata: adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:53")},
+		},
+		{
+			name:     "outbound error",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}, err: E.New("network unreachable")},
+			metadata: adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:53")},
+			err:      "network unreachable",
+		},
+		{
+			name:     "read error",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			conn:     &testClosedPacketConn{},
+			metadata: adapter.InboundContext{Destination: M.ParseSocksaddr("0.0.0.0:0")},
+			err:      io.EOF.Error(),
+		},
+		{
+			name:     "unsupported network",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}, network: []string{N.NetworkTCP}},
+			metadata: adapter.InboundContext{Destination: M.ParseSocksaddr("1.1.1.1:53")},
+			err:      "missing supported outbound",
+		},
+		{
+			name:     "detour not found",
+			outbound: &testConnectionOutbound{testOutbound: testOutbound{tag: "direct"}},
+			metadata: adapter.InboundContext{InboundDetour: "missing", Destination: M.ParseSocksaddr("1.1.1.1:53")},
+			err:      "inbound detour not found",
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			router, path := newAccessLogTestRouter(t, testCase.outbound)
+			conn := testCase.conn
+			if conn == nil {
+				conn = &testClosedPacketConn{}
+			}
+			metadata := testCase.metadata
+			metadata.Inbound = "in"
+			err := router.RoutePacketConnection(context.Background(), conn, metadata)
+			records := readAccessRecords(t, path)
+			require.Len(t, records, 1)
+			record := records[0]
+			require.Equal(t, N.NetworkUDP, record.Network)
+			require.Equal(t, "in", record.Inbound)
+			if testCase.err == "" {
+				require.NoError(t, err)
+				require.Empty(t, record.Error)
+				require.Equal(t, "final", record.Rule)
+				require.Equal(t, "direct", record.Outbound)
+			} else {
+				require.ErrorContains(t, err, testCase.err)
+				require.Contains(t, record.Error, testCase.err)
+			}
+		})
+	}
+}
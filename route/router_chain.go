@@ -0,0 +1,46 @@
+package route
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// checkChain rejects chains through outbounds sharing sessions between connections,
+// since the session would be dialed through the chain of the connection opening it, and reused by any other.
+// The first hop dials with its own dialer and is not affected.
+func checkChain(outboundByTag map[string]adapter.Outbound, rule adapter.Rule) error {
+	chain := rule.Chain()
+	if len(chain) == 0 {
+		return nil
+	}
+	dialedThroughChain := append([]string{rule.Outbound()}, chain[1:]...)
+	for _, tag := range dialedThroughChain {
+		if detour := sharedSessionOutbound(outboundByTag, tag, make(map[string]bool)); detour != nil {
+			return E.New("outbound/", detour.Type(), "[", detour.Tag(), "] shares sessions between connections (multiplex or QUIC based), and can only be the first hop of a chain")
+		}
+	}
+	return nil
+}
+
+// sharedSessionOutbound returns the outbound sharing sessions, or a member of a group doing so.
+func sharedSessionOutbound(outboundByTag map[string]adapter.Outbound, tag string, visited map[string]bool) adapter.Outbound {
+	if visited[tag] {
+		return nil
+	}
+	visited[tag] = true
+	detour, loaded := outboundByTag[tag]
+	if !loaded {
+		return nil
+	}
+	if sharedSession, isSharedSession := detour.(adapter.SharedSessionOutbound); isSharedSession && sharedSession.SharedSession() {
+		return detour
+	}
+	if group, isGroup := detour.(adapter.OutboundGroup); isGroup {
+		for _, member := range group.All() {
+			if memberDetour := sharedSessionOutbound(outboundByTag, member, visited); memberDetour != nil {
+				return memberDetour
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOutbound struct {
+	adapter.Outbound
+	tag           string
+	sharedSession bool
+}
+
+func (o *testOutbound) Type() string {
+	return "test"
+}
+
+func (o *testOutbound) Tag() string {
+	return o.tag
+}
+
+func (o *testOutbound) Network() []string {
+	return []string{N.NetworkTCP, N.NetworkUDP}
+}
+
+func (o *testOutbound) SharedSession() bool {
+	return o.sharedSession
+}
+
+type testGroup struct {
+	testOutbound
+	members []string
+}
+
+func (g *testGroup) Now() string {
+	return g.members[0]
+}
+
+func (g *testGroup) All() []string {
+	return g.members
+}
+
+type testRule struct {
+	adapter.Rule
+	outbound string
+	chain    []string
+}
+
+func (r *testRule) Outbound() string {
+	return r.outbound
+}
+
+func (r *testRule) Chain() []string {
+	return r.chain
+}
+
+func (r *testRule) String() string {
+	return "test"
+}
+
+func (r *testRule) Match(metadata *adapter.InboundContext) bool {
+	return true
+}
+
+func (r *testRule) Retry() []string {
+	return nil
+}
+
+func TestCheckChain(t *testing.T) {
+	t.Parallel()
+	outboundByTag := map[string]adapter.Outbound{
+		"direct": &testOutbound{tag: "direct"},
+		"socks":  &testOutbound{tag: "socks"},
+		"mux":    &testOutbound{tag: "mux", sharedSession: true},
+		"group": &testGroup{
+			testOutbound: testOutbound{tag: "group"},
+			members:      []string{"socks", "mux"},
+		},
+		"loop": &testGroup{
+			testOutbound: testOutbound{tag: "loop"},
+			members:      []string{"loop", "socks"},
+		},
+	}
+	for _, rule := range []*testRule{
+		{outbound: "mux"},
+		{outbound: "socks", chain: []string{"direct"}},
+		{outbound: "socks", chain: []string{"mux", "direct"}},
+		{outbound: "socks", chain: []string{"group"}},
+		{outbound: "loop", chain: []string{"direct"}},
+	} {
+		require.NoError(t, checkChain(outboundByTag, rule), rule.outbound, rule.chain)
+	}
+	for _, rule := range []*testRule{
+		{outbound: "mux", chain: []string{"direct"}},
+		{outbound: "socks", chain: []string{"direct", "mux"}},
+		{outbound: "group", chain: []string{"direct"}},
+		{outbound: "socks", chain: []string{"direct", "group"}},
+	} {
+		require.ErrorContains(t, checkChain(outboundByTag, rule), "outbound/test[mux]", rule.outbound, rule.chain)
+	}
+}
@@ -129,6 +129,39 @@ func (r *Router) Lookup(ctx context.Context, domain string, strategy dns.DomainS
 	if strategy == dns.DomainStrategyAsIS {
 		strategy = transportStrategy
 	}
+	return r.lookup(ctx, transport, domain, strategy)
+}
+
+func (r *Router) LookupDefault(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return r.Lookup(ctx, domain, dns.DomainStrategyAsIS)
+}
+
+func (r *Router) DNSTransport(tag string) (dns.Transport, bool) {
+	transport, loaded := r.transportMap[tag]
+	return transport, loaded
+}
+
+func (r *Router) LookupWithOptions(ctx context.Context, domain string, options adapter.DNSQueryOptions) ([]netip.Addr, error) {
+	transport, loaded := r.transportMap[options.Transport]
+	if !loaded {
+		return nil, E.New("dns server not found: ", options.Transport)
+	}
+	r.dnsLogger.DebugContext(ctx, "lookup domain ", domain, " with ", options.Transport)
+	strategy := options.Strategy
+	if strategy == dns.DomainStrategyAsIS {
+		if domainStrategy, dsLoaded := r.transportDomainStrategy[transport]; dsLoaded {
+			strategy = domainStrategy
+		} else {
+			strategy = r.defaultDomainStrategy
+		}
+	}
+	if options.DisableCache {
+		ctx = dns.ContextWithDisableCache(ctx, true)
+	}
+	return r.lookup(ctx, transport, domain, strategy)
+}
+
+func (r *Router) lookup(ctx context.Context, transport dns.Transport, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
 	ctx, cancel := context.WithTimeout(ctx, C.DNSTimeout)
 	defer cancel()
 	addrs, err := r.dnsClient.Lookup(ctx, transport, domain, strategy)
@@ -143,10 +176,6 @@ func (r *Router) Lookup(ctx context.Context, domain string, strategy dns.DomainS
 	return addrs, err
 }
 
-func (r *Router) LookupDefault(ctx context.Context, domain string) ([]netip.Addr, error) {
-	return r.Lookup(ctx, domain, dns.DomainStrategyAsIS)
-}
-
 func (r *Router) ClearDNSCache() {
 	r.dnsClient.ClearCache()
 	if r.platformInterface != nil {
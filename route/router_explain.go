@@ -0,0 +1,292 @@
+package route
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/process"
+	dns "github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// Explain routes a synthetic connection through the same steps as RouteConnection and RoutePacketConnection,
+// and reports the result of every rule evaluated.
+func (r *Router) Explain(ctx context.Context, request adapter.RouteExplainRequest) (*adapter.RouteExplanation, error) {
+	metadata, err := r.explainMetadata(request)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &adapter.RouteExplanation{}
+	addStep := func(message ...any) {
+		explanation.Steps = append(explanation.Steps, F.ToString(message...))
+	}
+	if r.fakeIPStore != nil && r.fakeIPStore.Contains(metadata.Destination.Addr) {
+		domain, loaded := r.fakeIPStore.Lookup(metadata.Destination.Addr)
+		if !loaded {
+			return nil, E.New("missing fakeip context")
+		}
+		metadata.OriginDestination = metadata.Destination
+		metadata.Destination = M.Socksaddr{
+			Fqdn: domain,
+			Port: metadata.Destination.Port,
+		}
+		metadata.FakeIP = true
+		addStep("found fakeip domain: ", domain)
+	}
+	if metadata.Inbound != "" && !metadata.InboundOptions.SniffEnabled {
+		if metadata.Domain != "" || metadata.Protocol != "" {
+			addStep("sniff disabled on inbound/", metadata.InboundType, "[", metadata.Inbound, "], ignored sniffed metadata")
+		}
+		metadata.Domain = ""
+		metadata.Protocol = ""
+		resetSniffMetadata(&metadata)
+	} else if metadata.Domain != "" {
+		if metadata.Protocol != "" {
+			addStep("sniffed protocol: ", metadata.Protocol, ", domain: ", metadata.Domain)
+		} else {
+			addStep("sniffed domain: ", metadata.Domain)
+		}
+		if metadata.InboundOptions.SniffOverrideDestination && M.IsDomainName(metadata.Domain) {
+			metadata.Destination = M.Socksaddr{
+				Fqdn: metadata.Domain,
+				Port: metadata.Destination.Port,
+			}
+			addStep("override destination: ", metadata.Destination)
+		}
+	}
+	if r.dnsReverseMapping != nil && metadata.Domain == "" {
+		domain, loaded := r.dnsReverseMapping.Query(metadata.Destination.Addr)
+		if loaded {
+			metadata.Domain = domain
+			addStep("found reserve mapped domain: ", metadata.Domain)
+		}
+	}
+	if metadata.Destination.IsFqdn() && dns.DomainStrategy(metadata.InboundOptions.DomainStrategy) != dns.DomainStrategyAsIS {
+		addresses, err := r.Lookup(adapter.WithContext(ctx, &metadata), metadata.Destination.Fqdn, dns.DomainStrategy(metadata.InboundOptions.DomainStrategy))
+		if err != nil {
+			return nil, E.Cause(err, "resolve ", metadata.Destination.Fqdn)
+		}
+		metadata.DestinationAddresses = addresses
+		addStep("resolved [", strings.Join(F.MapToString(metadata.DestinationAddresses), " "), "]")
+	}
+	if r.neighborResolver != nil && metadata.Source.IsIP() {
+		r.findNeighbor(ctx, &metadata)
+	}
+	if request.SourceMACAddress != "" {
+		hardwareAddr, err := net.ParseMAC(request.SourceMACAddress)
+		if err != nil {
+			return nil, E.Cause(err, "parse source MAC address")
+		}
+		metadata.SourceHardwareAddr = hardwareAddr
+	}
+	if request.SourceHostname != "" {
+		metadata.SourceHostname = request.SourceHostname
+	}
+	if request.InboundInterface != "" {
+		metadata.InboundInterface = request.InboundInterface
+	}
+	if metadata.InboundInterface != "" {
+		addStep("inbound interface: ", metadata.InboundInterface)
+	}
+	if metadata.SourceHardwareAddr != nil {
+		addStep("source MAC address: ", metadata.SourceHardwareAddr)
+	}
+	if metadata.SourceHostname != "" {
+		addStep("source hostname: ", metadata.SourceHostname)
+	}
+	if metadata.Destination.IsIPv4() {
+		metadata.IPVersion = 4
+	} else if metadata.Destination.IsIPv6() {
+		metadata.IPVersion = 6
+	}
+	matchedRule, detour := r.matchRules(&metadata, func(index int, rule adapter.Rule) bool {
+		ruleExplanation := explainRule(rule, &metadata)
+		ruleExplanation.Index = index
+		ruleExplanation.Outbound = rule.Outbound()
+		ruleExplanation.Hops = rule.Chain()
+		explanation.Rules = append(explanation.Rules, ruleExplanation)
+		return ruleExplanation.Matched
+	}, func(index int, rule adapter.Rule) {
+		explanation.Rules[len(explanation.Rules)-1].Error = "outbound not found: " + rule.Outbound()
+	})
+	if matchedRule != nil {
+		explanation.Hops = matchedRule.Chain()
+	} else {
+		explanation.Final = true
+		if metadata.Network == N.NetworkUDP {
+			detour = r.defaultOutboundForPacketConnection
+		} else {
+			detour = r.defaultOutboundForConnection
+		}
+		if detour == nil {
+			return nil, E.New("missing default outbound")
+		}
+	}
+	if !common.Contains(detour.Network(), metadata.Network) {
+		addStep("outbound/", detour.Type(), "[", detour.Tag(), "] does not support ", metadata.Network, ", connection will be closed")
+	}
+	explanation.Outbound = detour.Tag()
+	explanation.Chain = r.outboundChain(detour)
+	return explanation, nil
+}
+
+// RouteChain matches the metadata of a routed connection against the current rules and selections,
+// and returns the rule hops followed by the outbound chain.
+func (r *Router) RouteChain(metadata adapter.InboundContext) []string {
+	var hops []string
+	matchedRule, detour := r.matchRules(&metadata, func(index int, rule adapter.Rule) bool {
+		return rule.Match(&metadata)
+	}, nil)
+	if matchedRule != nil {
+		hops = matchedRule.Chain()
+	} else {
+		if metadata.Network == N.NetworkUDP {
+			detour = r.defaultOutboundForPacketConnection
+		} else {
+			detour = r.defaultOutboundForConnection
+		}
+		if detour == nil {
+			return nil
+		}
+	}
+	return append(append([]string(nil), hops...), r.outboundChain(detour)...)
+}
+
+func (r *Router) explainMetadata(request adapter.RouteExplainRequest) (adapter.InboundContext, error) {
+	var metadata adapter.InboundContext
+	if request.Inbound != "" {
+		inbound, loaded := r.inboundByTag[request.Inbound]
+		if !loaded {
+			return metadata, E.New("inbound not found: ", request.Inbound)
+		}
+		metadata.Inbound = inbound.Tag()
+		metadata.InboundType = inbound.Type()
+		metadata.InboundOptions = r.inboundOptions[inbound.Tag()]
+	}
+	switch request.Network {
+	case "", N.NetworkTCP:
+		metadata.Network = N.NetworkTCP
+	case N.NetworkUDP:
+		metadata.Network = N.NetworkUDP
+	default:
+		return metadata, E.Cause(N.ErrUnknownNetwork, request.Network)
+	}
+	if request.Source != "" {
+		metadata.Source = M.ParseSocksaddr(request.Source)
+		if !metadata.Source.IsIP() {
+			return metadata, E.New("source must be an IP address: ", request.Source)
+		}
+	}
+	if request.Destination != "" {
+		metadata.Destination = M.ParseSocksaddr(request.Destination)
+	} else if request.Domain != "" {
+		metadata.Destination = M.Socksaddr{Fqdn: request.Domain}
+	} else {
+		return metadata, E.New("missing destination or domain")
+	}
+	metadata.Domain = request.Domain
+	metadata.Protocol = request.Protocol
+	metadata.ALPN = request.ALPN
+	metadata.JA3 = request.JA3
+	metadata.JA4 = request.JA4
+	metadata.HTTPMethod = request.HTTPMethod
+	metadata.HTTPPath = request.HTTPPath
+	metadata.UserAgent = request.UserAgent
+	metadata.User = request.User
+	if request.ProcessPath != "" || request.PackageName != "" {
+		metadata.ProcessInfo = &process.Info{
+			ProcessPath: request.ProcessPath,
+			PackageName: request.PackageName,
+			UserId:      -1,
+		}
+	}
+	if request.WIFISSID != "" || request.WIFIBSSID != "" {
+		metadata.WIFIState = &adapter.WIFIState{
+			SSID:  request.WIFISSID,
+			BSSID: request.WIFIBSSID,
+		}
+	}
+	return metadata, nil
+}
+
+func resetSniffMetadata(metadata *adapter.InboundContext) {
+	metadata.ALPN = nil
+	metadata.JA3 = ""
+	metadata.JA4 = ""
+	metadata.HTTPMethod = ""
+	metadata.HTTPPath = ""
+	metadata.UserAgent = ""
+}
+
+func (r *Router) outboundChain(detour adapter.Outbound) []string {
+	chain := []string{detour.Tag()}
+	for {
+		group, isGroup := detour.(adapter.OutboundGroup)
+		if !isGroup {
+			return chain
+		}
+		now := group.Now()
+		if common.Contains(chain, now) {
+			return chain
+		}
+		outbound, loaded := r.Outbound(now)
+		if !loaded {
+			return chain
+		}
+		chain = append(chain, now)
+		detour = outbound
+	}
+}
+
+type explainableRule interface {
+	explain(metadata *adapter.InboundContext) adapter.RuleExplanation
+}
+
+func explainRule(rule adapter.HeadlessRule, metadata *adapter.InboundContext) adapter.RuleExplanation {
+	if explainable, isExplainable := rule.(explainableRule); isExplainable {
+		return explainable.explain(metadata)
+	}
+	return adapter.RuleExplanation{
+		Rule:    F.ToString(rule),
+		Matched: rule.Match(metadata),
+	}
+}
+
+// explain reports the result of each item alone, the rule itself is matched as usual.
+func (r *abstractDefaultRule) explain(metadata *adapter.InboundContext) adapter.RuleExplanation {
+	explanation := adapter.RuleExplanation{
+		Rule:   r.String(),
+		Invert: r.invert,
+	}
+	for _, item := range r.allItems {
+		itemMetadata := *metadata
+		itemMetadata.ResetRuleCache()
+		explanation.Items = append(explanation.Items, adapter.RuleItemExplanation{
+			Item:    item.String(),
+			Matched: item.Match(&itemMetadata),
+		})
+	}
+	explanation.Matched = r.Match(metadata)
+	return explanation
+}
+
+func (r *abstractLogicalRule) explain(metadata *adapter.InboundContext) adapter.RuleExplanation {
+	explanation := adapter.RuleExplanation{
+		Rule:   r.String(),
+		Mode:   r.mode,
+		Invert: r.invert,
+	}
+	for _, rule := range r.rules {
+		metadata.ResetRuleCache()
+		explanation.Rules = append(explanation.Rules, explainRule(rule, metadata))
+	}
+	metadata.ResetRuleCache()
+	explanation.Matched = r.Match(metadata)
+	return explanation
+}
@@ -0,0 +1,125 @@
+package route
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type matchTestRule struct {
+	testRule
+	match bool
+}
+
+func newMatchTestRule(match bool, outbound string, chain ...string) *matchTestRule {
+	return &matchTestRule{testRule{outbound: outbound, chain: chain}, match}
+}
+
+func (r *matchTestRule) Match(metadata *adapter.InboundContext) bool {
+	return r.match
+}
+
+func newMatchTestRouter(rules ...adapter.Rule) *Router {
+	logger := log.NewNOPFactory().Logger()
+	direct := &testOutbound{tag: "direct"}
+	return &Router{
+		logger:    logger,
+		dnsLogger: logger,
+		rules:     rules,
+		outboundByTag: map[string]adapter.Outbound{
+			"direct": direct,
+			"a":      &testOutbound{tag: "a"},
+			"b":      &testOutbound{tag: "b"},
+			"group":  &testGroup{testOutbound: testOutbound{tag: "group"}, members: []string{"b", "a"}},
+		},
+		defaultOutboundForConnection:       direct,
+		defaultOutboundForPacketConnection: direct,
+	}
+}
+
+func TestRuleMatchConsistency(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		rules    []adapter.Rule
+		rule     int
+		outbound string
+		route    []string
+		errors   map[int]string
+	}{
+		{
+			name:     "final",
+			rules:    []adapter.Rule{newMatchTestRule(false, "a")},
+			rule:     -1,
+			outbound: "direct",
+			route:    []string{"direct"},
+		},
+		{
+			name:     "first match",
+			rules:    []adapter.Rule{newMatchTestRule(false, "a"), newMatchTestRule(true, "b"), newMatchTestRule(true, "a")},
+			rule:     1,
+			outbound: "b",
+			route:    []string{"b"},
+		},
+		{
+			name:     "missing outbound",
+			rules:    []adapter.Rule{newMatchTestRule(true, "missing"), newMatchTestRule(true, "group", "a")},
+			rule:     1,
+			outbound: "group",
+			route:    []string{"a", "group", "b"},
+			errors:   map[int]string{0: "outbound not found: missing"},
+		},
+		{
+			name:     "all missing",
+			rules:    []adapter.Rule{newMatchTestRule(true, "missing")},
+			rule:     -1,
+			outbound: "direct",
+			route:    []string{"direct"},
+			errors:   map[int]string{0: "outbound not found: missing"},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			router := newMatchTestRouter(testCase.rules...)
+			var expectedRule adapter.Rule
+			if testCase.rule >= 0 {
+				expectedRule = testCase.rules[testCase.rule]
+			}
+
+			metadata := adapter.InboundContext{Network: N.NetworkTCP}
+			matchedRule, detour := router.match0(context.Background(), &metadata, router.defaultOutboundForConnection)
+			if expectedRule == nil {
+				require.Nil(t, matchedRule)
+			} else {
+				require.Same(t, expectedRule, matchedRule)
+			}
+			require.Equal(t, testCase.outbound, detour.Tag())
+
+			require.Equal(t, testCase.route, router.RouteChain(adapter.InboundContext{Network: N.NetworkTCP}))
+
+			explanation, err := router.Explain(context.Background(), adapter.RouteExplainRequest{Destination: "1.1.1.1:443"})
+			require.NoError(t, err)
+			require.Equal(t, testCase.outbound, explanation.Outbound)
+			require.Equal(t, testCase.rule < 0, explanation.Final)
+			if testCase.rule >= 0 {
+				require.Len(t, explanation.Rules, testCase.rule+1)
+				require.True(t, explanation.Rules[testCase.rule].Matched)
+				require.Equal(t, expectedRule.Chain(), explanation.Hops)
+			} else {
+				require.Len(t, explanation.Rules, len(testCase.rules))
+			}
+			require.Equal(t, testCase.route, append(append([]string(nil), explanation.Hops...), explanation.Chain...))
+			for i, ruleExplanation := range explanation.Rules {
+				require.Equal(t, i, ruleExplanation.Index)
+				require.Equal(t, testCase.errors[i], ruleExplanation.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+
+	mDNS "github.com/miekg/dns"
+)
+
+func (r *Router) findNeighbor(ctx context.Context, metadata *adapter.InboundContext) {
+	sourceAddr := metadata.Source.Addr
+	if r.needFindInboundInterface {
+		interfaceName, err := r.neighborResolver.LookupInterface(sourceAddr)
+		if err != nil {
+			r.logger.DebugContext(ctx, "failed to search inbound interface: ", err)
+		} else {
+			metadata.InboundInterface = interfaceName
+			r.logger.DebugContext(ctx, "found inbound interface: ", interfaceName)
+		}
+	}
+	if !r.needFindNeighbor {
+		return
+	}
+	hardwareAddr, err := r.neighborResolver.LookupHardwareAddr(sourceAddr)
+	if err != nil {
+		r.logger.DebugContext(ctx, "failed to search neighbor: ", err)
+	} else {
+		metadata.SourceHardwareAddr = hardwareAddr
+		r.logger.DebugContext(ctx, "found MAC address: ", hardwareAddr)
+	}
+	if r.needFindHostname {
+		hostname, err := r.neighborResolver.LookupHostname(ctx, sourceAddr, hardwareAddr)
+		if err != nil {
+			r.logger.DebugContext(ctx, "failed to search hostname: ", err)
+		} else {
+			metadata.SourceHostname = hostname
+			r.logger.DebugContext(ctx, "found hostname: ", hostname)
+		}
+	}
+}
+
+// lookupPTR looks up the hostname of a LAN client by DNS rules, without the context of the connection being routed.
+func (r *Router) lookupPTR(_ context.Context, addr netip.Addr) (string, error) {
+	reverseAddr, err := mDNS.ReverseAddr(addr.String())
+	if err != nil {
+		return "", err
+	}
+	message := &mDNS.Msg{
+		MsgHdr: mDNS.MsgHdr{
+			RecursionDesired: true,
+		},
+		Question: []mDNS.Question{{
+			Name:   reverseAddr,
+			Qtype:  mDNS.TypePTR,
+			Qclass: mDNS.ClassINET,
+		}},
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, C.DNSTimeout)
+	defer cancel()
+	response, err := r.Exchange(ctx, message)
+	if err != nil {
+		return "", err
+	}
+	for _, answer := range response.Answer {
+		if record, isPTR := answer.(*mDNS.PTR); isPTR {
+			return record.Ptr, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,131 @@
+package route
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// retryOutbounds returns the alternate outbounds of the matched rule, or the global ones.
+func (r *Router) retryOutbounds(rule adapter.Rule) []string {
+	if rule != nil && len(rule.Retry()) > 0 {
+		return rule.Retry()
+	}
+	return r.retry
+}
+
+func (r *Router) newConnectionWithRetry(ctx context.Context, routeCtx context.Context, conn net.Conn, metadata adapter.InboundContext, detour adapter.Outbound, retry []string, record *accessRecord, tracker adapter.Tracker) error {
+	retryConn := &retryConn{Conn: conn}
+	err := r.retry0(ctx, routeCtx, N.NetworkTCP, detour, retry, &retryConn.retryHandshake, record, tracker, func(ctx context.Context, detour adapter.Outbound) error {
+		return detour.NewConnection(ctx, retryConn, metadata)
+	})
+	if retryConn.failed {
+		return N.ReportHandshakeFailure(conn, err)
+	}
+	return err
+}
+
+func (r *Router) newPacketConnectionWithRetry(ctx context.Context, routeCtx context.Context, conn N.PacketConn, metadata adapter.InboundContext, detour adapter.Outbound, retry []string, record *accessRecord, tracker adapter.Tracker) error {
+	retryConn := &retryPacketConn{PacketConn: conn}
+	err := r.retry0(ctx, routeCtx, N.NetworkUDP, detour, retry, &retryConn.retryHandshake, record, tracker, func(ctx context.Context, detour adapter.Outbound) error {
+		return detour.NewPacketConnection(ctx, retryConn, metadata)
+	})
+	if retryConn.failed {
+		return N.ReportHandshakeFailure(conn, err)
+	}
+	return err
+}
+
+// retry0 hands the connection to the alternate outbounds in order as long as the previous one failed
+// before the handshake completed, so that no payload has been consumed yet.
+// The rule chain only applies to the matched outbound.
+func (r *Router) retry0(ctx context.Context, routeCtx context.Context, network string, detour adapter.Outbound, retry []string, handshake *retryHandshake, record *accessRecord, tracker adapter.Tracker, newConnection func(ctx context.Context, detour adapter.Outbound) error) error {
+	err := newConnection(ctx, detour)
+	tried := []string{detour.Tag()}
+	for _, tag := range retry {
+		if !handshake.failed || routeCtx.Err() != nil {
+			break
+		}
+		if common.Contains(tried, tag) {
+			continue
+		}
+		if contextOutbound, loaded := outbound.TagFromContext(routeCtx); loaded && contextOutbound == tag {
+			continue
+		}
+		fallback, loaded := r.Outbound(tag)
+		if !loaded || !common.Contains(fallback.Network(), network) {
+			continue
+		}
+		r.logger.ErrorContext(ctx, E.Cause(err, "outbound/", detour.Type(), "[", detour.Tag(), "]"), ", retrying with outbound/", fallback.Type(), "[", tag, "]")
+		tried = append(tried, tag)
+		detour = fallback
+		handshake.failed = false
+		if record != nil {
+			record.Outbound = tag
+			record.Chain = r.outboundChain(fallback)
+		}
+		if tracker != nil {
+			tracker.UpdateChain(r.outboundChain(fallback))
+		}
+		err = newConnection(outbound.ContextWithTag(routeCtx, tag), fallback)
+	}
+	return err
+}
+
+// retryHandshake holds back handshake failures, which would make the inbound reply an error to the client,
+// until no alternate outbound is left.
+type retryHandshake struct {
+	failed bool
+}
+
+func (h *retryHandshake) HandshakeFailure(err error) error {
+	h.failed = true
+	return nil
+}
+
+type retryConn struct {
+	net.Conn
+	retryHandshake
+}
+
+func (c *retryConn) HandshakeSuccess() error {
+	return N.ReportHandshakeSuccess(c.Conn)
+}
+
+func (c *retryConn) Upstream() any {
+	return c.Conn
+}
+
+func (c *retryConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *retryConn) WriterReplaceable() bool {
+	return true
+}
+
+type retryPacketConn struct {
+	N.PacketConn
+	retryHandshake
+}
+
+func (c *retryPacketConn) HandshakeSuccess() error {
+	return N.ReportHandshakeSuccess(c.PacketConn)
+}
+
+func (c *retryPacketConn) Upstream() any {
+	return c.PacketConn
+}
+
+func (c *retryPacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *retryPacketConn) WriterReplaceable() bool {
+	return true
+}
@@ -0,0 +1,221 @@
+package route
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	retryHandshakeFailure = iota
+	retryHandshakeSuccess
+	retryDataPathFailure
+	retrySilentFailure
+)
+
+// retryTestOutbound fails or succeeds the way a proxy outbound does:
+// handshake results are reported to the inbound connection before returning.
+type retryTestOutbound struct {
+	testOutbound
+	network []string
+	result  int
+	dialed  *[]string
+}
+
+func (o *retryTestOutbound) Network() []string {
+	if o.network != nil {
+		return o.network
+	}
+	return o.testOutbound.Network()
+}
+
+func (o *retryTestOutbound) handle(conn any) error {
+	*o.dialed = append(*o.dialed, o.tag)
+	err := E.New("failed by ", o.tag)
+	switch o.result {
+	case retryHandshakeFailure:
+		N.ReportHandshakeFailure(conn, err)
+		return err
+	case retryHandshakeSuccess:
+		return N.ReportHandshakeSuccess(conn)
+	case retryDataPathFailure:
+		N.ReportHandshakeSuccess(conn)
+		return err
+	default:
+		return err
+	}
+}
+
+func (o *retryTestOutbound) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return o.handle(conn)
+}
+
+func (o *retryTestOutbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return o.handle(conn)
+}
+
+// retryTestHandshake records the handshake result seen by the inbound.
+type retryTestHandshake struct {
+	success bool
+	failure error
+}
+
+func (h *retryTestHandshake) HandshakeSuccess() error {
+	h.success = true
+	return nil
+}
+
+func (h *retryTestHandshake) HandshakeFailure(err error) error {
+	h.failure = err
+	return nil
+}
+
+type retryTestConn struct {
+	net.Conn
+	retryTestHandshake
+}
+
+type retryTestPacketConn struct {
+	N.PacketConn
+	retryTestHandshake
+}
+
+func newRetryTestRouter(dialed *[]string, outbounds ...*retryTestOutbound) *Router {
+	router := &Router{
+		logger:        log.NewNOPFactory().Logger(),
+		outboundByTag: make(map[string]adapter.Outbound),
+	}
+	for _, outbound := range outbounds {
+		outbound.dialed = dialed
+		router.outboundByTag[outbound.tag] = outbound
+	}
+	return router
+}
+
+func newRetryTestOutbound(tag string, result int) *retryTestOutbound {
+	return &retryTestOutbound{testOutbound: testOutbound{tag: tag}, result: result}
+}
+
+func TestRetryConnection(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		outbounds []*retryTestOutbound
+		retry     []string
+		dialed    []string
+		outbound  string
+		success   bool
+		failure   string
+	}{
+		{
+			name:      "failed dial moves to next",
+			outbounds: []*retryTestOutbound{newRetryTestOutbound("a", retryHandshakeFailure), newRetryTestOutbound("b", retryHandshakeFailure), newRetryTestOutbound("c", retryHandshakeSuccess)},
+			retry:     []string{"b", "c"},
+			dialed:    []string{"a", "b", "c"},
+			outbound:  "c",
+			success:   true,
+		},
+		{
+			name:      "stops after handshake success",
+			outbounds: []*retryTestOutbound{newRetryTestOutbound("a", retryHandshakeSuccess), newRetryTestOutbound("b", retryHandshakeSuccess)},
+			retry:     []string{"b"},
+			dialed:    []string{"a"},
+			outbound:  "a",
+			success:   true,
+		},
+		{
+			name:      "stops once data path started",
+			outbounds: []*retryTestOutbound{newRetryTestOutbound("a", retryDataPathFailure), newRetryTestOutbound("b", retryHandshakeSuccess)},
+			retry:     []string{"b"},
+			dialed:    []string{"a"},
+			outbound:  "a",
+			success:   true,
+		},
+		{
+			name:      "stops on failure without handshake",
+			outbounds: []*retryTestOutbound{newRetryTestOutbound("a", retrySilentFailure), newRetryTestOutbound("b", retryHandshakeSuccess)},
+			retry:     []string{"b"},
+			dialed:    []string{"a"},
+			outbound:  "a",
+		},
+		{
+			name:      "all failed",
+			outbounds: []*retryTestOutbound{newRetryTestOutbound("a", retryHandshakeFailure), newRetryTestOutbound("b", retryHandshakeFailure)},
+			retry:     []string{"b"},
+			dialed:    []string{"a", "b"},
+			outbound:  "b",
+			failure:   "failed by b",
+		},
+		{
+			name: "skips unusable outbounds",
+			outbounds: []*retryTestOutbound{
+				newRetryTestOutbound("a", retryHandshakeFailure),
+				{testOutbound: testOutbound{tag: "udp"}, network: []string{N.NetworkUDP}, result: retryHandshakeSuccess},
+				newRetryTestOutbound("b", retryHandshakeSuccess),
+			},
+			retry:    []string{"a", "missing", "udp", "b"},
+			dialed:   []string{"a", "b"},
+			outbound: "b",
+			success:  true,
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			var dialed []string
+			router := newRetryTestRouter(&dialed, testCase.outbounds...)
+			record := newAccessRecord(context.Background())
+			conn := &retryTestConn{}
+			err := router.newConnectionWithRetry(context.Background(), context.Background(), conn, adapter.InboundContext{}, testCase.outbounds[0], testCase.retry, record, nil)
+			require.Equal(t, testCase.dialed, dialed)
+			require.Equal(t, testCase.success, conn.success)
+			if testCase.failure != "" {
+				require.EqualError(t, err, testCase.failure)
+				require.EqualError(t, conn.failure, testCase.failure)
+			} else {
+				require.NoError(t, conn.failure)
+			}
+			if testCase.outbound != "a" {
+				require.Equal(t, testCase.outbound, record.Outbound)
+				require.Equal(t, []string{testCase.outbound}, record.Chain)
+			} else {
+				require.Empty(t, record.Outbound)
+			}
+		})
+	}
+}
+
+func TestRetryPacketConnection(t *testing.T) {
+	t.Parallel()
+	var dialed []string
+	first := newRetryTestOutbound("a", retryHandshakeFailure)
+	router := newRetryTestRouter(&dialed, first, newRetryTestOutbound("b", retryHandshakeSuccess))
+	conn := &retryTestPacketConn{}
+	err := router.newPacketConnectionWithRetry(context.Background(), context.Background(), conn, adapter.InboundContext{}, first, []string{"b"}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, dialed)
+	require.True(t, conn.success)
+	require.NoError(t, conn.failure)
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+	var dialed []string
+	first := newRetryTestOutbound("a", retryHandshakeFailure)
+	router := newRetryTestRouter(&dialed, first, newRetryTestOutbound("b", retryHandshakeSuccess))
+	routeCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	conn := &retryTestConn{}
+	err := router.newConnectionWithRetry(routeCtx, routeCtx, conn, adapter.InboundContext{}, first, []string{"b"}, nil, nil)
+	require.EqualError(t, err, "failed by a")
+	require.Equal(t, []string{"a"}, dialed)
+	require.EqualError(t, conn.failure, "failed by a")
+}
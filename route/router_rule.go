@@ -79,7 +79,19 @@ func isProcessDNSRule(rule option.DefaultDNSRule) bool {
 }
 
 func isProcessHeadlessRule(rule option.DefaultHeadlessRule) bool {
-	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.PackageName) > 0
+	return len(rule.ProcessName) > 0 || len(rule.ProcessPath) > 0 || len(rule.PackageName) > 0 || len(rule.User) > 0 || len(rule.UserID) > 0
+}
+
+func isNeighborRule(rule option.DefaultRule) bool {
+	return len(rule.SourceMACAddress) > 0 || len(rule.SourceHostname) > 0
+}
+
+func isHostnameRule(rule option.DefaultRule) bool {
+	return len(rule.SourceHostname) > 0
+}
+
+func isInboundInterfaceRule(rule option.DefaultRule) bool {
+	return len(rule.InboundInterface) > 0
 }
 
 func notPrivateNode(code string) bool {
@@ -87,13 +99,13 @@ func notPrivateNode(code string) bool {
 }
 
 func isWIFIRule(rule option.DefaultRule) bool {
-	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0
+	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0 || len(rule.NetworkType) > 0
 }
 
 func isWIFIDNSRule(rule option.DefaultDNSRule) bool {
-	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0
+	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0 || len(rule.NetworkType) > 0
 }
 
 func isWIFIHeadlessRule(rule option.DefaultHeadlessRule) bool {
-	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0
+	return len(rule.WIFISSID) > 0 || len(rule.WIFIBSSID) > 0 || len(rule.NetworkType) > 0
 }
@@ -20,6 +20,8 @@ type abstractDefaultRule struct {
 	ruleSetItem             RuleItem
 	invert                  bool
 	outbound                string
+	chain                   []string
+	retry                   []string
 }
 
 func (r *abstractDefaultRule) Type() string {
@@ -128,6 +130,14 @@ func (r *abstractDefaultRule) Outbound() string {
 	return r.outbound
 }
 
+func (r *abstractDefaultRule) Chain() []string {
+	return r.chain
+}
+
+func (r *abstractDefaultRule) Retry() []string {
+	return r.retry
+}
+
 func (r *abstractDefaultRule) String() string {
 	if !r.invert {
 		return strings.Join(F.MapToString(r.allItems), " ")
@@ -141,6 +151,8 @@ type abstractLogicalRule struct {
 	mode     string
 	invert   bool
 	outbound string
+	chain    []string
+	retry    []string
 }
 
 func (r *abstractLogicalRule) Type() string {
@@ -204,6 +216,14 @@ func (r *abstractLogicalRule) Outbound() string {
 	return r.outbound
 }
 
+func (r *abstractLogicalRule) Chain() []string {
+	return r.chain
+}
+
+func (r *abstractLogicalRule) Retry() []string {
+	return r.retry
+}
+
 func (r *abstractLogicalRule) String() string {
 	var op string
 	switch r.mode {
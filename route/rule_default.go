@@ -47,6 +47,8 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		abstractDefaultRule{
 			invert:   options.Invert,
 			outbound: options.Outbound,
+			chain:    options.Chain,
+			retry:    options.Retry,
 		},
 	}
 	if len(options.Inbound) > 0 {
@@ -54,6 +56,11 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.InboundInterface) > 0 {
+		item := NewInboundInterfaceItem(options.InboundInterface)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if options.IPVersion > 0 {
 		switch options.IPVersion {
 		case 4, 6:
@@ -79,6 +86,39 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.ALPN) > 0 {
+		item := NewALPNItem(options.ALPN)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.TLSFingerprint) > 0 {
+		item := NewTLSFingerprintItem(options.TLSFingerprint)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPMethod) > 0 {
+		item := NewHTTPMethodItem(options.HTTPMethod)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPPath) > 0 {
+		item := NewHTTPPathItem(options.HTTPPath)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.UserAgent) > 0 {
+		item := NewUserAgentItem(options.UserAgent)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.UserAgentRegex) > 0 {
+		item, err := NewUserAgentRegexItem(options.UserAgentRegex)
+		if err != nil {
+			return nil, E.Cause(err, "user_agent_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Domain) > 0 || len(options.DomainSuffix) > 0 {
 		item := NewDomainItem(options.Domain, options.DomainSuffix)
 		rule.destinationAddressItems = append(rule.destinationAddressItems, item)
@@ -125,6 +165,19 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceMACAddress) > 0 {
+		item, err := NewSourceMACAddressItem(options.SourceMACAddress)
+		if err != nil {
+			return nil, E.Cause(err, "source_mac_address")
+		}
+		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceHostname) > 0 {
+		item := NewSourceHostnameItem(options.SourceHostname)
+		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.IPCIDR) > 0 {
 		item, err := NewIPCIDRItem(false, options.IPCIDR)
 		if err != nil {
@@ -194,6 +247,22 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if options.Schedule != nil {
+		item, err := NewScheduleItem(router, *options.Schedule)
+		if err != nil {
+			return nil, E.Cause(err, "schedule")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.NetworkType) > 0 {
+		item, err := NewNetworkTypeItem(router, options.NetworkType)
+		if err != nil {
+			return nil, E.Cause(err, "network_type")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.WIFISSID) > 0 {
 		item := NewWIFISSIDItem(router, options.WIFISSID)
 		rule.items = append(rule.items, item)
@@ -224,6 +293,8 @@ func NewLogicalRule(router adapter.Router, logger log.ContextLogger, options opt
 			rules:    make([]adapter.HeadlessRule, len(options.Rules)),
 			invert:   options.Invert,
 			outbound: options.Outbound,
+			chain:    options.Chain,
+			retry:    options.Retry,
 		},
 	}
 	switch options.Mode {
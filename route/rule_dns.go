@@ -185,6 +185,22 @@ func NewDefaultDNSRule(router adapter.Router, logger log.ContextLogger, options
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if options.Schedule != nil {
+		item, err := NewScheduleItem(router, *options.Schedule)
+		if err != nil {
+			return nil, E.Cause(err, "schedule")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.NetworkType) > 0 {
+		item, err := NewNetworkTypeItem(router, options.NetworkType)
+		if err != nil {
+			return nil, E.Cause(err, "network_type")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.WIFISSID) > 0 {
 		item := NewWIFISSIDItem(router, options.WIFISSID)
 		rule.items = append(rule.items, item)
@@ -0,0 +1,42 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*ALPNItem)(nil)
+
+type ALPNItem struct {
+	alpnList []string
+	alpnMap  map[string]bool
+}
+
+func NewALPNItem(alpnList []string) *ALPNItem {
+	alpnMap := make(map[string]bool)
+	for _, alpn := range alpnList {
+		alpnMap[alpn] = true
+	}
+	return &ALPNItem{
+		alpnList: alpnList,
+		alpnMap:  alpnMap,
+	}
+}
+
+func (r *ALPNItem) Match(metadata *adapter.InboundContext) bool {
+	for _, alpn := range metadata.ALPN {
+		if r.alpnMap[alpn] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ALPNItem) String() string {
+	if len(r.alpnList) == 1 {
+		return F.ToString("alpn=", r.alpnList[0])
+	}
+	return F.ToString("alpn=[", strings.Join(r.alpnList, " "), "]")
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestALPNItem(t *testing.T) {
+	t.Parallel()
+	item := NewALPNItem([]string{"h2", "h3"})
+	require.True(t, item.Match(&adapter.InboundContext{ALPN: []string{"h2", "http/1.1"}}))
+	require.True(t, item.Match(&adapter.InboundContext{ALPN: []string{"h3"}}))
+	require.False(t, item.Match(&adapter.InboundContext{ALPN: []string{"http/1.1"}}))
+	require.False(t, item.Match(&adapter.InboundContext{ALPN: []string{"H2"}}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, "alpn=[h2 h3]", item.String())
+	require.Equal(t, "alpn=h2", NewALPNItem([]string{"h2"}).String())
+}
@@ -21,6 +21,9 @@ func NewClashModeItem(router adapter.Router, mode string) *ClashModeItem {
 }
 
 func (r *ClashModeItem) Match(metadata *adapter.InboundContext) bool {
+	if r.router == nil {
+		return false
+	}
 	clashServer := r.router.ClashServer()
 	if clashServer == nil {
 		return false
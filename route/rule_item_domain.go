@@ -50,6 +50,13 @@ func NewRawDomainItem(matcher *domain.Matcher) *DomainItem {
 	}
 }
 
+func NewRawDomainSuffixItem(matcher *domain.Matcher) *DomainItem {
+	return &DomainItem{
+		matcher,
+		"domain_suffix=<binary>",
+	}
+}
+
 func (r *DomainItem) Match(metadata *adapter.InboundContext) bool {
 	var domainHost string
 	if metadata.Domain != "" {
@@ -0,0 +1,37 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*HTTPMethodItem)(nil)
+
+type HTTPMethodItem struct {
+	methods   []string
+	methodMap map[string]bool
+}
+
+func NewHTTPMethodItem(methods []string) *HTTPMethodItem {
+	methodMap := make(map[string]bool)
+	for _, method := range methods {
+		methodMap[strings.ToUpper(method)] = true
+	}
+	return &HTTPMethodItem{
+		methods:   methods,
+		methodMap: methodMap,
+	}
+}
+
+func (r *HTTPMethodItem) Match(metadata *adapter.InboundContext) bool {
+	return metadata.HTTPMethod != "" && r.methodMap[metadata.HTTPMethod]
+}
+
+func (r *HTTPMethodItem) String() string {
+	if len(r.methods) == 1 {
+		return F.ToString("http_method=", r.methods[0])
+	}
+	return F.ToString("http_method=[", strings.Join(r.methods, " "), "]")
+}
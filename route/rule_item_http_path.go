@@ -0,0 +1,37 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*HTTPPathItem)(nil)
+
+type HTTPPathItem struct {
+	prefixes []string
+}
+
+func NewHTTPPathItem(prefixes []string) *HTTPPathItem {
+	return &HTTPPathItem{prefixes}
+}
+
+func (r *HTTPPathItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.HTTPPath == "" {
+		return false
+	}
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(metadata.HTTPPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPPathItem) String() string {
+	if len(r.prefixes) == 1 {
+		return F.ToString("http_path=", r.prefixes[0])
+	}
+	return F.ToString("http_path=[", strings.Join(r.prefixes, " "), "]")
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPPathItem(t *testing.T) {
+	t.Parallel()
+	item := NewHTTPPathItem([]string{"/api/", "/static"})
+	require.True(t, item.Match(&adapter.InboundContext{HTTPPath: "/api/v1/users"}))
+	require.True(t, item.Match(&adapter.InboundContext{HTTPPath: "/static.css"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPPath: "/api"}))
+	require.False(t, item.Match(&adapter.InboundContext{HTTPPath: "/v1/api/"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewHTTPPathItem([]string{""}).Match(&adapter.InboundContext{}))
+	require.Equal(t, "http_path=[/api/ /static]", item.String())
+}
@@ -0,0 +1,37 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*InboundInterfaceItem)(nil)
+
+type InboundInterfaceItem struct {
+	interfaces   []string
+	interfaceMap map[string]bool
+}
+
+func NewInboundInterfaceItem(interfaces []string) *InboundInterfaceItem {
+	interfaceMap := make(map[string]bool)
+	for _, interfaceName := range interfaces {
+		interfaceMap[interfaceName] = true
+	}
+	return &InboundInterfaceItem{
+		interfaces:   interfaces,
+		interfaceMap: interfaceMap,
+	}
+}
+
+func (r *InboundInterfaceItem) Match(metadata *adapter.InboundContext) bool {
+	return metadata.InboundInterface != "" && r.interfaceMap[metadata.InboundInterface]
+}
+
+func (r *InboundInterfaceItem) String() string {
+	if len(r.interfaces) == 1 {
+		return F.ToString("inbound_interface=", r.interfaces[0])
+	}
+	return F.ToString("inbound_interface=[", strings.Join(r.interfaces, " "), "]")
+}
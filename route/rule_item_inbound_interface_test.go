@@ -0,0 +1,22 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboundInterfaceItem(t *testing.T) {
+	t.Parallel()
+	item := NewInboundInterfaceItem([]string{"br-lan", "wlan0"})
+	require.True(t, item.Match(&adapter.InboundContext{InboundInterface: "br-lan"}))
+	require.True(t, item.Match(&adapter.InboundContext{InboundInterface: "wlan0"}))
+	require.False(t, item.Match(&adapter.InboundContext{InboundInterface: "br-lan0"}))
+	require.False(t, item.Match(&adapter.InboundContext{InboundInterface: "eth0"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewInboundInterfaceItem([]string{""}).Match(&adapter.InboundContext{}))
+	require.Equal(t, "inbound_interface=[br-lan wlan0]", item.String())
+	require.Equal(t, "inbound_interface=br-lan", NewInboundInterfaceItem([]string{"br-lan"}).String())
+}
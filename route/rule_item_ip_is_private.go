@@ -1,8 +1,6 @@
 package route
 
 import (
-	"net/netip"
-
 	"github.com/sagernet/sing-box/adapter"
 	N "github.com/sagernet/sing/common/network"
 )
@@ -18,12 +16,10 @@ func NewIPIsPrivateItem(isSource bool) *IPIsPrivateItem {
 }
 
 func (r *IPIsPrivateItem) Match(metadata *adapter.InboundContext) bool {
-	var destination netip.Addr
-	if r.isSource {
-		destination = metadata.Source.Addr
-	} else {
-		destination = metadata.Destination.Addr
+	if r.isSource || metadata.QueryType != 0 || metadata.IPCIDRMatchSource {
+		return metadata.Source.Addr.IsValid() && !N.IsPublicAddr(metadata.Source.Addr)
 	}
+	destination := metadata.Destination.Addr
 	if destination.IsValid() && !N.IsPublicAddr(destination) {
 		return true
 	}
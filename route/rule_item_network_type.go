@@ -0,0 +1,88 @@
+package route
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*NetworkTypeItem)(nil)
+
+type NetworkTypeItem struct {
+	router         adapter.Router
+	networkTypes   []string
+	networkTypeMap map[string]bool
+}
+
+func NewNetworkTypeItem(router adapter.Router, networkTypes []string) (*NetworkTypeItem, error) {
+	networkTypeMap := make(map[string]bool)
+	for _, networkType := range networkTypes {
+		switch networkType {
+		case C.NetworkTypeWIFI, C.NetworkTypeCellular, C.NetworkTypeEthernet, C.NetworkTypeOther:
+		default:
+			return nil, E.New("unknown network type: ", networkType)
+		}
+		networkTypeMap[networkType] = true
+	}
+	return &NetworkTypeItem{
+		router:         router,
+		networkTypes:   networkTypes,
+		networkTypeMap: networkTypeMap,
+	}, nil
+}
+
+func (r *NetworkTypeItem) Match(metadata *adapter.InboundContext) bool {
+	return r.networkTypeMap[r.networkType(metadata)]
+}
+
+func (r *NetworkTypeItem) networkType(metadata *adapter.InboundContext) string {
+	var wifiState adapter.WIFIState
+	if metadata.WIFIState != nil {
+		wifiState = *metadata.WIFIState
+	} else if r.router != nil {
+		wifiState = r.router.WIFIState()
+	}
+	if wifiState.SSID != "" || wifiState.BSSID != "" {
+		return C.NetworkTypeWIFI
+	}
+	if r.router == nil {
+		return C.NetworkTypeOther
+	}
+	interfaceMonitor := r.router.InterfaceMonitor()
+	if interfaceMonitor == nil {
+		return C.NetworkTypeOther
+	}
+	return networkTypeFromInterfaceName(interfaceMonitor.DefaultInterfaceName(netip.IPv4Unspecified()))
+}
+
+// networkTypeFromInterfaceName guesses the network type from well-known interface name prefixes.
+func networkTypeFromInterfaceName(interfaceName string) string {
+	switch {
+	case interfaceName == "":
+		return C.NetworkTypeOther
+	case strings.HasPrefix(interfaceName, "rmnet"),
+		strings.HasPrefix(interfaceName, "ccmni"),
+		strings.HasPrefix(interfaceName, "pdp_ip"),
+		strings.HasPrefix(interfaceName, "wwan"),
+		strings.HasPrefix(interfaceName, "clat"):
+		return C.NetworkTypeCellular
+	case strings.HasPrefix(interfaceName, "wl"):
+		return C.NetworkTypeWIFI
+	case strings.HasPrefix(interfaceName, "eth"),
+		strings.HasPrefix(interfaceName, "en"):
+		return C.NetworkTypeEthernet
+	default:
+		return C.NetworkTypeOther
+	}
+}
+
+func (r *NetworkTypeItem) String() string {
+	if len(r.networkTypes) == 1 {
+		return F.ToString("network_type=", r.networkTypes[0])
+	}
+	return F.ToString("network_type=[", strings.Join(r.networkTypes, " "), "]")
+}
@@ -0,0 +1,152 @@
+package route
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*ScheduleItem)(nil)
+
+type ScheduleItem struct {
+	router     adapter.Router
+	location   *time.Location
+	weekdays   map[time.Weekday]bool
+	timeRanges []scheduleTimeRange
+	options    option.ScheduleOptions
+}
+
+// scheduleTimeRange is a range of minutes of a day, end is exclusive and may be less than start when crossing midnight.
+type scheduleTimeRange struct {
+	start int
+	end   int
+}
+
+func NewScheduleItem(router adapter.Router, options option.ScheduleOptions) (*ScheduleItem, error) {
+	if len(options.Weekday) == 0 && len(options.Time) == 0 {
+		return nil, E.New("missing weekday or time")
+	}
+	location := time.Local
+	if options.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(options.TimeZone)
+		if err != nil {
+			return nil, E.Cause(err, "load timezone")
+		}
+	}
+	var weekdays map[time.Weekday]bool
+	if len(options.Weekday) > 0 {
+		weekdays = make(map[time.Weekday]bool)
+		for _, weekdayString := range options.Weekday {
+			weekday, err := parseWeekday(weekdayString)
+			if err != nil {
+				return nil, err
+			}
+			weekdays[weekday] = true
+		}
+	}
+	timeRanges := make([]scheduleTimeRange, 0, len(options.Time))
+	for _, timeRangeString := range options.Time {
+		timeRange, err := parseScheduleTimeRange(timeRangeString)
+		if err != nil {
+			return nil, E.Cause(err, "parse time range ", timeRangeString)
+		}
+		timeRanges = append(timeRanges, timeRange)
+	}
+	return &ScheduleItem{
+		router:     router,
+		location:   location,
+		weekdays:   weekdays,
+		timeRanges: timeRanges,
+		options:    options,
+	}, nil
+}
+
+func parseWeekday(weekdayString string) (time.Weekday, error) {
+	lowerString := strings.ToLower(weekdayString)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if lowerString == name || lowerString == name[:3] {
+			return weekday, nil
+		}
+	}
+	return 0, E.New("unknown weekday: ", weekdayString)
+}
+
+func parseScheduleTimeRange(timeRangeString string) (scheduleTimeRange, error) {
+	startString, endString, found := strings.Cut(timeRangeString, "-")
+	if !found {
+		return scheduleTimeRange{}, E.New("missing '-'")
+	}
+	start, err := parseMinuteOfDay(strings.TrimSpace(startString))
+	if err != nil {
+		return scheduleTimeRange{}, err
+	}
+	end, err := parseMinuteOfDay(strings.TrimSpace(endString))
+	if err != nil {
+		return scheduleTimeRange{}, err
+	}
+	if start == end {
+		return scheduleTimeRange{}, E.New("empty time range")
+	}
+	return scheduleTimeRange{start, end}, nil
+}
+
+func parseMinuteOfDay(timeString string) (int, error) {
+	if timeString == "24:00" {
+		return 24 * 60, nil
+	}
+	clock, err := time.Parse("15:04", timeString)
+	if err != nil {
+		return 0, E.New("bad time: ", timeString)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// Match checks weekdays against the day a time range starts,
+// so the part of a range crossing midnight belongs to the previous day.
+func (r *ScheduleItem) Match(metadata *adapter.InboundContext) bool {
+	now := r.router.TimeFunc()().In(r.location)
+	weekday := now.Weekday()
+	if len(r.timeRanges) == 0 {
+		return r.matchWeekday(weekday)
+	}
+	previousWeekday := (weekday + 6) % 7
+	minute := now.Hour()*60 + now.Minute()
+	for _, timeRange := range r.timeRanges {
+		if timeRange.start < timeRange.end {
+			if minute >= timeRange.start && minute < timeRange.end && r.matchWeekday(weekday) {
+				return true
+			}
+		} else if minute >= timeRange.start && r.matchWeekday(weekday) || minute < timeRange.end && r.matchWeekday(previousWeekday) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ScheduleItem) matchWeekday(weekday time.Weekday) bool {
+	return r.weekdays == nil || r.weekdays[weekday]
+}
+
+func (r *ScheduleItem) String() string {
+	var descriptions []string
+	if len(r.options.Weekday) == 1 {
+		descriptions = append(descriptions, "weekday="+r.options.Weekday[0])
+	} else if len(r.options.Weekday) > 1 {
+		descriptions = append(descriptions, "weekday=["+strings.Join(r.options.Weekday, " ")+"]")
+	}
+	if len(r.options.Time) == 1 {
+		descriptions = append(descriptions, "time="+r.options.Time[0])
+	} else if len(r.options.Time) > 1 {
+		descriptions = append(descriptions, "time=["+strings.Join(r.options.Time, " ")+"]")
+	}
+	if r.options.TimeZone != "" {
+		descriptions = append(descriptions, "timezone="+r.options.TimeZone)
+	}
+	return F.ToString("schedule=(", strings.Join(descriptions, " "), ")")
+}
@@ -0,0 +1,118 @@
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scheduleTestRouter struct {
+	adapter.Router
+	now time.Time
+}
+
+func (r *scheduleTestRouter) TimeFunc() func() time.Time {
+	return func() time.Time {
+		return r.now
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	t.Parallel()
+	for weekdayString, weekday := range map[string]time.Weekday{
+		"sunday": time.Sunday,
+		"Mon":    time.Monday,
+		"TUE":    time.Tuesday,
+		"Friday": time.Friday,
+		"sat":    time.Saturday,
+	} {
+		parsed, err := parseWeekday(weekdayString)
+		require.NoError(t, err, weekdayString)
+		require.Equal(t, weekday, parsed, weekdayString)
+	}
+	for _, weekdayString := range []string{"", "fr", "fridays", "weekend"} {
+		_, err := parseWeekday(weekdayString)
+		require.Error(t, err, weekdayString)
+	}
+}
+
+func TestParseScheduleTimeRange(t *testing.T) {
+	t.Parallel()
+	for timeRangeString, timeRange := range map[string]scheduleTimeRange{
+		"09:00-18:00":    {9 * 60, 18 * 60},
+		"22:00-06:00":    {22 * 60, 6 * 60},
+		" 00:00 - 24:00": {0, 24 * 60},
+		"23:30-00:15":    {23*60 + 30, 15},
+	} {
+		parsed, err := parseScheduleTimeRange(timeRangeString)
+		require.NoError(t, err, timeRangeString)
+		require.Equal(t, timeRange, parsed, timeRangeString)
+	}
+	for _, timeRangeString := range []string{"", "09:00", "09:00-", "9-18", "25:00-26:00", "09:00-09:00", "00:00-24:01"} {
+		_, err := parseScheduleTimeRange(timeRangeString)
+		require.Error(t, err, timeRangeString)
+	}
+}
+
+func TestNewScheduleItemInvalid(t *testing.T) {
+	t.Parallel()
+	router := &scheduleTestRouter{}
+	for _, options := range []option.ScheduleOptions{
+		{},
+		{Weekday: []string{"someday"}},
+		{Time: []string{"18:00"}},
+		{Time: []string{"09:00-18:00"}, TimeZone: "Nowhere/Nothing"},
+	} {
+		_, err := NewScheduleItem(router, options)
+		require.Error(t, err, options)
+	}
+}
+
+func TestScheduleItemMatch(t *testing.T) {
+	t.Parallel()
+	// 2024-01-05 is a Friday.
+	friday := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, 1, 5+day, hour, minute, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		name    string
+		options option.ScheduleOptions
+		now     time.Time
+		match   bool
+	}{
+		{"weekday list", option.ScheduleOptions{Weekday: []string{"mon", "fri"}}, friday(0, 12, 0), true},
+		{"weekday list other day", option.ScheduleOptions{Weekday: []string{"mon", "fri"}}, friday(1, 12, 0), false},
+		{"weekday list monday", option.ScheduleOptions{Weekday: []string{"mon", "fri"}}, friday(3, 0, 0), true},
+		{"time only", option.ScheduleOptions{Time: []string{"09:00-18:00"}}, friday(2, 9, 0), true},
+		{"time end exclusive", option.ScheduleOptions{Time: []string{"09:00-18:00"}}, friday(2, 18, 0), false},
+		{"time before start", option.ScheduleOptions{Time: []string{"09:00-18:00"}}, friday(2, 8, 59), false},
+		{"time until midnight", option.ScheduleOptions{Time: []string{"20:00-24:00"}}, friday(0, 23, 59), true},
+		{"time list", option.ScheduleOptions{Time: []string{"09:00-12:00", "13:00-18:00"}}, friday(0, 12, 30), false},
+		{"time list second", option.ScheduleOptions{Time: []string{"09:00-12:00", "13:00-18:00"}}, friday(0, 13, 30), true},
+		{"weekday and time", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"09:00-18:00"}}, friday(0, 10, 0), true},
+		{"weekday and time other day", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"09:00-18:00"}}, friday(-1, 10, 0), false},
+		{"overnight start", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(0, 22, 0), true},
+		{"overnight after midnight", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(1, 1, 59), true},
+		{"overnight end exclusive", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(1, 2, 0), false},
+		{"overnight early same day", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(0, 1, 0), false},
+		{"overnight next start", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(1, 22, 0), false},
+		{"overnight outside", option.ScheduleOptions{Weekday: []string{"fri"}, Time: []string{"22:00-02:00"}}, friday(0, 12, 0), false},
+		{"overnight week wrap", option.ScheduleOptions{Weekday: []string{"sat"}, Time: []string{"22:00-02:00"}}, friday(2, 1, 0), true},
+		{"overnight without weekday", option.ScheduleOptions{Time: []string{"22:00-02:00"}}, friday(0, 1, 0), true},
+		{"timezone", option.ScheduleOptions{Weekday: []string{"sat"}, Time: []string{"07:00-09:00"}, TimeZone: "Asia/Shanghai"}, friday(0, 23, 30), true},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			router := &scheduleTestRouter{now: testCase.now}
+			item, err := NewScheduleItem(router, testCase.options)
+			require.NoError(t, err)
+			require.Equal(t, testCase.match, item.Match(&adapter.InboundContext{}))
+		})
+	}
+}
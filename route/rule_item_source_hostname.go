@@ -0,0 +1,41 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*SourceHostnameItem)(nil)
+
+type SourceHostnameItem struct {
+	hostnames []string
+}
+
+func NewSourceHostnameItem(hostnames []string) *SourceHostnameItem {
+	return &SourceHostnameItem{hostnames}
+}
+
+// Match matches the full hostname or its first label, so `nas` matches both `nas` and `nas.lan`.
+func (r *SourceHostnameItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceHostname == "" {
+		return false
+	}
+	for _, hostname := range r.hostnames {
+		if strings.EqualFold(metadata.SourceHostname, hostname) {
+			return true
+		}
+		if len(metadata.SourceHostname) > len(hostname) && metadata.SourceHostname[len(hostname)] == '.' && strings.EqualFold(metadata.SourceHostname[:len(hostname)], hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SourceHostnameItem) String() string {
+	if len(r.hostnames) == 1 {
+		return F.ToString("source_hostname=", r.hostnames[0])
+	}
+	return F.ToString("source_hostname=[", strings.Join(r.hostnames, " "), "]")
+}
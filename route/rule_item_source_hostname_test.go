@@ -0,0 +1,31 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceHostnameItem(t *testing.T) {
+	t.Parallel()
+	item := NewSourceHostnameItem([]string{"my-phone", "nas.lan"})
+	for hostname, match := range map[string]bool{
+		"my-phone":          true,
+		"My-Phone":          true,
+		"my-phone.lan":      true,
+		"my-phone.home.lan": true,
+		"nas.lan":           true,
+		"NAS.LAN":           true,
+		"nas":               false,
+		"nas.lan.home":      true,
+		"my-phone2":         false,
+		"my":                false,
+		"other.my-phone":    false,
+		"":                  false,
+	} {
+		require.Equal(t, match, item.Match(&adapter.InboundContext{SourceHostname: hostname}), hostname)
+	}
+	require.Equal(t, "source_hostname=[my-phone nas.lan]", item.String())
+}
@@ -0,0 +1,43 @@
+package route
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*SourceMACAddressItem)(nil)
+
+type SourceMACAddressItem struct {
+	addressList []string
+	addressMap  map[string]bool
+}
+
+func NewSourceMACAddressItem(addressList []string) (*SourceMACAddressItem, error) {
+	addressMap := make(map[string]bool)
+	for _, addressString := range addressList {
+		address, err := net.ParseMAC(addressString)
+		if err != nil {
+			return nil, E.Cause(err, "parse MAC address ", addressString)
+		}
+		addressMap[address.String()] = true
+	}
+	return &SourceMACAddressItem{
+		addressList: addressList,
+		addressMap:  addressMap,
+	}, nil
+}
+
+func (r *SourceMACAddressItem) Match(metadata *adapter.InboundContext) bool {
+	return metadata.SourceHardwareAddr != nil && r.addressMap[metadata.SourceHardwareAddr.String()]
+}
+
+func (r *SourceMACAddressItem) String() string {
+	if len(r.addressList) == 1 {
+		return F.ToString("source_mac_address=", r.addressList[0])
+	}
+	return F.ToString("source_mac_address=[", strings.Join(r.addressList, " "), "]")
+}
@@ -0,0 +1,28 @@
+package route
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceMACAddressItem(t *testing.T) {
+	t.Parallel()
+	item, err := NewSourceMACAddressItem([]string{"AA:BB:CC:DD:EE:01", "aa-bb-cc-dd-ee-02"})
+	require.NoError(t, err)
+	for _, address := range []string{"aa:bb:cc:dd:ee:01", "AA:BB:CC:DD:EE:02"} {
+		hardwareAddr, err := net.ParseMAC(address)
+		require.NoError(t, err)
+		require.True(t, item.Match(&adapter.InboundContext{SourceHardwareAddr: hardwareAddr}), address)
+	}
+	hardwareAddr, err := net.ParseMAC("aa:bb:cc:dd:ee:03")
+	require.NoError(t, err)
+	require.False(t, item.Match(&adapter.InboundContext{SourceHardwareAddr: hardwareAddr}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.Equal(t, "source_mac_address=[AA:BB:CC:DD:EE:01 aa-bb-cc-dd-ee-02]", item.String())
+	_, err = NewSourceMACAddressItem([]string{"aa:bb:cc"})
+	require.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSFingerprintItem(t *testing.T) {
+	t.Parallel()
+	const (
+		ja3 = "cd08e31494f9531f560d64c695473da9"
+		ja4 = "t13d1516h2_8daaf6152771_02713d6af862"
+	)
+	item := NewTLSFingerprintItem([]string{"CD08E31494F9531F560D64C695473DA9", ja4})
+	require.True(t, item.Match(&adapter.InboundContext{JA3: ja3}))
+	require.True(t, item.Match(&adapter.InboundContext{JA4: ja4}))
+	require.True(t, item.Match(&adapter.InboundContext{JA3: "00000000000000000000000000000000", JA4: ja4}))
+	require.False(t, item.Match(&adapter.InboundContext{JA3: "00000000000000000000000000000000", JA4: "t13d1516h2_000000000000_000000000000"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewTLSFingerprintItem([]string{""}).Match(&adapter.InboundContext{}))
+}
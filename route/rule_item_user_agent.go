@@ -0,0 +1,77 @@
+package route
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*UserAgentItem)(nil)
+
+type UserAgentItem struct {
+	keywords []string
+}
+
+func NewUserAgentItem(keywords []string) *UserAgentItem {
+	return &UserAgentItem{keywords}
+}
+
+func (r *UserAgentItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.UserAgent == "" {
+		return false
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(metadata.UserAgent, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserAgentItem) String() string {
+	if len(r.keywords) == 1 {
+		return F.ToString("user_agent=", r.keywords[0])
+	}
+	return F.ToString("user_agent=[", strings.Join(r.keywords, " "), "]")
+}
+
+var _ RuleItem = (*UserAgentRegexItem)(nil)
+
+type UserAgentRegexItem struct {
+	expressions []string
+	matchers    []*regexp.Regexp
+}
+
+func NewUserAgentRegexItem(expressions []string) (*UserAgentRegexItem, error) {
+	matchers := make([]*regexp.Regexp, 0, len(expressions))
+	for i, regex := range expressions {
+		matcher, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, E.Cause(err, "parse expression ", i)
+		}
+		matchers = append(matchers, matcher)
+	}
+	return &UserAgentRegexItem{expressions, matchers}, nil
+}
+
+func (r *UserAgentRegexItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.UserAgent == "" {
+		return false
+	}
+	for _, matcher := range r.matchers {
+		if matcher.MatchString(metadata.UserAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserAgentRegexItem) String() string {
+	if len(r.expressions) == 1 {
+		return F.ToString("user_agent_regex=", r.expressions[0])
+	}
+	return F.ToString("user_agent_regex=[", strings.Join(r.expressions, " "), "]")
+}
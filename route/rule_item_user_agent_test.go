@@ -0,0 +1,33 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentItem(t *testing.T) {
+	t.Parallel()
+	item := NewUserAgentItem([]string{"curl/", "Firefox"})
+	require.True(t, item.Match(&adapter.InboundContext{UserAgent: "curl/8.4.0"}))
+	require.True(t, item.Match(&adapter.InboundContext{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"}))
+	require.False(t, item.Match(&adapter.InboundContext{UserAgent: "Wget/1.21"}))
+	require.False(t, item.Match(&adapter.InboundContext{UserAgent: "firefox"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, NewUserAgentItem([]string{""}).Match(&adapter.InboundContext{}))
+	require.Equal(t, "user_agent=[curl/ Firefox]", item.String())
+}
+
+func TestUserAgentRegexItem(t *testing.T) {
+	t.Parallel()
+	item, err := NewUserAgentRegexItem([]string{`^curl/8\.`, `(?i)firefox`})
+	require.NoError(t, err)
+	require.True(t, item.Match(&adapter.InboundContext{UserAgent: "curl/8.4.0"}))
+	require.True(t, item.Match(&adapter.InboundContext{UserAgent: "FIREFOX"}))
+	require.False(t, item.Match(&adapter.InboundContext{UserAgent: "curl/7.88.1"}))
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	_, err = NewUserAgentRegexItem([]string{"("})
+	require.Error(t, err)
+}
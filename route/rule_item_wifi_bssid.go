@@ -28,6 +28,12 @@ func NewWIFIBSSIDItem(router adapter.Router, bssidList []string) *WIFIBSSIDItem
 }
 
 func (r *WIFIBSSIDItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.WIFIState != nil {
+		return r.bssidMap[metadata.WIFIState.BSSID]
+	}
+	if r.router == nil {
+		return false
+	}
 	return r.bssidMap[r.router.WIFIState().BSSID]
 }
 
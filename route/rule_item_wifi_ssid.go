@@ -28,6 +28,12 @@ func NewWIFISSIDItem(router adapter.Router, ssidList []string) *WIFISSIDItem {
 }
 
 func (r *WIFISSIDItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.WIFIState != nil {
+		return r.ssidMap[metadata.WIFIState.SSID]
+	}
+	if r.router == nil {
+		return false
+	}
 	return r.ssidMap[r.router.WIFIState().SSID]
 }
 
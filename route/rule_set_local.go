@@ -2,9 +2,11 @@ package route
 
 import (
 	"context"
+	"io"
 	"os"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -20,28 +22,20 @@ type LocalRuleSet struct {
 }
 
 func NewLocalRuleSet(router adapter.Router, options option.RuleSet) (*LocalRuleSet, error) {
-	setFile, err := os.Open(options.LocalOptions.Path)
-	if err != nil {
-		return nil, err
-	}
-	var plainRuleSet option.PlainRuleSet
-	switch options.Format {
-	case C.RuleSetFormatSource, "":
-		var compat option.PlainRuleSetCompat
-		decoder := json.NewDecoder(json.NewCommentFilter(setFile))
-		decoder.DisallowUnknownFields()
-		err = decoder.Decode(&compat)
-		if err != nil {
-			return nil, err
+	var (
+		plainRuleSet option.PlainRuleSet
+		err          error
+	)
+	if options.Type == C.RuleSetTypeInline {
+		if len(options.InlineOptions.Rules) == 0 {
+			return nil, E.New("empty inline rule-set")
 		}
-		plainRuleSet = compat.Upgrade()
-	case C.RuleSetFormatBinary:
-		plainRuleSet, err = srs.Read(setFile, false)
+		plainRuleSet = options.InlineOptions
+	} else {
+		plainRuleSet, err = readLocalRuleSet(options)
 		if err != nil {
 			return nil, err
 		}
-	default:
-		return nil, E.New("unknown rule set format: ", options.Format)
 	}
 	rules := make([]adapter.HeadlessRule, len(plainRuleSet.Rules))
 	for i, ruleOptions := range plainRuleSet.Rules {
@@ -56,6 +50,35 @@ func NewLocalRuleSet(router adapter.Router, options option.RuleSet) (*LocalRuleS
 	return &LocalRuleSet{rules, metadata}, nil
 }
 
+func readLocalRuleSet(options option.RuleSet) (option.PlainRuleSet, error) {
+	setFile, err := os.Open(options.LocalOptions.Path)
+	if err != nil {
+		return option.PlainRuleSet{}, err
+	}
+	defer setFile.Close()
+	switch options.Format {
+	case C.RuleSetFormatSource, "":
+		var compat option.PlainRuleSetCompat
+		decoder := json.NewDecoder(json.NewCommentFilter(setFile))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&compat)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		return compat.Upgrade(), nil
+	case C.RuleSetFormatBinary:
+		return srs.Read(setFile, false)
+	case C.RuleSetFormatClash, C.RuleSetFormatAdGuard, C.RuleSetFormatDnsmasq, C.RuleSetFormatHosts:
+		content, err := io.ReadAll(setFile)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		return convertor.Convert(content, options.Format)
+	default:
+		return option.PlainRuleSet{}, E.New("unknown rule set format: ", options.Format)
+	}
+}
+
 func (s *LocalRuleSet) Match(metadata *adapter.InboundContext) bool {
 	for _, rule := range s.rules {
 		if rule.Match(metadata) {
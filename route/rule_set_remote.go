@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -140,6 +141,11 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 		if err != nil {
 			return err
 		}
+	case C.RuleSetFormatClash, C.RuleSetFormatAdGuard, C.RuleSetFormatDnsmasq, C.RuleSetFormatHosts:
+		plainRuleSet, err = convertor.Convert(content, s.options.Format)
+		if err != nil {
+			return err
+		}
 	default:
 		return E.New("unknown rule set format: ", s.options.Format)
 	}
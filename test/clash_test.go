@@ -38,6 +38,7 @@ const (
 	ImageShadowTLS             = "ghcr.io/ihciah/shadow-tls:latest"
 	ImageXRayCore              = "teddysun/xray:latest"
 	ImageShadowsocksLegacy     = "mritd/shadowsocks:latest"
+	ImageShadowsocksR          = "teddysun/shadowsocks-r:latest"
 	ImageTUICServer            = "kilvn/tuic-server:latest"
 	ImageTUICClient            = "kilvn/tuic-client:latest"
 )
@@ -55,6 +56,7 @@ var allImages = []string{
 	ImageShadowTLS,
 	ImageXRayCore,
 	ImageShadowsocksLegacy,
+	ImageShadowsocksR,
 	ImageTUICServer,
 	ImageTUICClient,
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/netip"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestShadowsocksRInbound(t *testing.T) {
+	startDockerContainer(t, DockerOptions{
+		Image:      ImageShadowsocksR,
+		EntryPoint: "python",
+		Ports:      []uint16{serverPort, clientPort},
+		Cmd:        []string{"/usr/local/shadowsocks/local.py", "-c", "/etc/shadowsocks-r/config.json"},
+		Bind: map[string]string{
+			"shadowsocksr-client.json": "/etc/shadowsocks-r/config.json",
+		},
+	})
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeShadowsocksR,
+				ShadowsocksROptions: option.ShadowsocksRInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: serverPort,
+					},
+					Method:   "aes-256-cfb",
+					Password: "password0",
+					Obfs:     "tls1.2_ticket_auth",
+					Protocol: "auth_chain_a",
+					Users: []option.ShadowsocksRUser{
+						{
+							Name:     "sekai",
+							UserID:   1024,
+							Password: "password1",
+						},
+					},
+				},
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+}
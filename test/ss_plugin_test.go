@@ -18,6 +18,20 @@ func TestShadowsocksObfs(t *testing.T) {
 	}
 }
 
+func TestShadowsocksObfsInbound(t *testing.T) {
+	for _, mode := range []string{
+		"http", "tls",
+	} {
+		t.Run("obfs-server "+mode, func(t *testing.T) {
+			testShadowsocksPluginSelf(t, "obfs-server", "obfs-local", "obfs="+mode)
+		})
+	}
+}
+
+func TestShadowsocksV2RayPluginInbound(t *testing.T) {
+	testShadowsocksPluginSelf(t, "v2ray-plugin", "v2ray-plugin", "mux=0")
+}
+
 // Since I can't test this on m1 mac (rosetta error: bss_size overflow), I don't care about it
 func _TestShadowsocksV2RayPlugin(t *testing.T) {
 	testShadowsocksPlugin(t, "v2ray-plugin", "", "--plugin v2ray-plugin --plugin-opts=server")
@@ -62,3 +76,65 @@ func testShadowsocksPlugin(t *testing.T, name string, opts string, args string)
 	})
 	testSuitSimple(t, clientPort, testPort)
 }
+
+func testShadowsocksPluginSelf(t *testing.T, serverName string, clientName string, opts string) {
+	method := "chacha20-ietf-poly1305"
+	password := "FzcLbKs2dY9mhL"
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				MixedOptions: option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeShadowsocks,
+				ShadowsocksOptions: option.ShadowsocksInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: serverPort,
+					},
+					Method:        method,
+					Password:      password,
+					Plugin:        serverName,
+					PluginOptions: opts,
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+			},
+			{
+				Type: C.TypeShadowsocks,
+				Tag:  "ss-out",
+				ShadowsocksOptions: option.ShadowsocksOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					Method:        method,
+					Password:      password,
+					Plugin:        clientName,
+					PluginOptions: opts,
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					DefaultOptions: option.DefaultRule{
+						Inbound:  []string{"mixed-in"},
+						Outbound: "ss-out",
+					},
+				},
+			},
+		},
+	})
+	testSuitSimple(t, clientPort, testPort)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestV2RayKCPSelf(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		testV2RayTransportNOTLSSelf(t, &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeKCP,
+		})
+	})
+	t.Run("tls", func(t *testing.T) {
+		testV2RayTransportSelf(t, &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeKCP,
+		})
+	})
+	for _, headerType := range []string{"none", "srtp", "utp", "wechat-video", "dtls", "wireguard"} {
+		for _, seed := range []string{"", "sing-box"} {
+			headerType, seed := headerType, seed
+			t.Run("obfs-"+headerType+"-seed="+seed, func(t *testing.T) {
+				testV2RayTransportNOTLSSelf(t, &option.V2RayTransportOptions{
+					Type: C.V2RayTransportTypeKCP,
+					KCPOptions: option.V2RayKCPOptions{
+						HeaderType: headerType,
+						Seed:       seed,
+					},
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestV2RaySplitHTTPSelf(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		testV2RayTransportNOTLSSelf(t, &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeSplitHTTP,
+		})
+	})
+	t.Run("tls", func(t *testing.T) {
+		testV2RayTransportSelf(t, &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeSplitHTTP,
+			SplitHTTPOptions: option.V2RaySplitHTTPOptions{
+				Path: "/split",
+			},
+		})
+	})
+}
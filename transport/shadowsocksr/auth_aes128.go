@@ -0,0 +1,208 @@
+package shadowsocksr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"hash"
+	"io"
+	"math"
+	mRand "math/rand"
+	"net"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	authAES128UnitLength = 8100
+	tcpMSS               = 1460
+	bufferSize           = 32 * 1024
+)
+
+type authAES128Conn struct {
+	net.Conn
+	protocol *protocolContext
+	hash     func() hash.Hash
+	salt     string
+	userKey  []byte
+	recvID   uint32
+	packID   uint32
+	payload  []byte
+}
+
+func (c *authAES128Conn) serverHandshake(iv []byte) (uint32, bool, error) {
+	header := make([]byte, 31)
+	_, err := io.ReadFull(c.Conn, header[:7])
+	if err != nil {
+		return 0, false, E.Cause(err, "read ", c.salt, " header")
+	}
+	macKey := concat(iv, c.protocol.key)
+	if !hmac.Equal(hmacSum(c.hash, macKey, header[:1])[:6], header[1:7]) {
+		return 0, false, E.New(c.salt, ": bad check head")
+	}
+	_, err = io.ReadFull(c.Conn, header[7:])
+	if err != nil {
+		return 0, false, E.Cause(err, "read ", c.salt, " header")
+	}
+	if !hmac.Equal(hmacSum(c.hash, macKey, header[7:27])[:4], header[27:31]) {
+		return 0, false, E.New(c.salt, ": bad header hmac")
+	}
+	userID := binary.LittleEndian.Uint32(header[7:11])
+	password, authenticated, err := c.protocol.userPassword(userID)
+	if err != nil {
+		return 0, false, E.Cause(err, c.salt)
+	}
+	if authenticated {
+		userHash := c.hash()
+		userHash.Write(password)
+		c.userKey = userHash.Sum(nil)
+	} else {
+		c.userKey = password
+	}
+	head, err := decryptAuthHead(c.userKey, c.salt, header[11:27])
+	if err != nil {
+		return 0, false, err
+	}
+	length := int(binary.LittleEndian.Uint16(head[12:14]))
+	randomLength := int(binary.LittleEndian.Uint16(head[14:16]))
+	if length < 31+randomLength+4 {
+		return 0, false, E.New(c.salt, ": bad header length")
+	}
+	data := make([]byte, length)
+	copy(data, header)
+	_, err = io.ReadFull(c.Conn, data[31:])
+	if err != nil {
+		return 0, false, E.Cause(err, "read ", c.salt, " header")
+	}
+	if !hmac.Equal(hmacSum(c.hash, c.userKey, data[:length-4])[:4], data[length-4:]) {
+		return 0, false, E.New(c.salt, ": bad checksum")
+	}
+	err = c.protocol.checkTimestamp(binary.LittleEndian.Uint32(head[:4]))
+	if err != nil {
+		return 0, false, E.Cause(err, c.salt)
+	}
+	if !c.protocol.clients.insert(userID, binary.LittleEndian.Uint32(head[4:8]), binary.LittleEndian.Uint32(head[8:12])) {
+		return 0, false, E.New(c.salt, ": replayed connection")
+	}
+	c.payload = data[31+randomLength : length-4]
+	c.recvID = 1
+	c.packID = 1
+	return userID, authenticated, nil
+}
+
+func (c *authAES128Conn) Read(p []byte) (n int, err error) {
+	for len(c.payload) == 0 {
+		err = c.readPacket()
+		if err != nil {
+			return
+		}
+	}
+	n = copy(p, c.payload)
+	c.payload = c.payload[n:]
+	return
+}
+
+func (c *authAES128Conn) readPacket() error {
+	var header [4]byte
+	_, err := io.ReadFull(c.Conn, header[:])
+	if err != nil {
+		return err
+	}
+	macKey := packetIDKey(c.userKey, c.recvID)
+	if !hmac.Equal(hmacSum(c.hash, macKey, header[:2])[:2], header[2:]) {
+		return E.New(c.salt, ": bad packet length checksum")
+	}
+	length := int(binary.LittleEndian.Uint16(header[:2]))
+	if length >= 8192 || length < 7 {
+		return E.New(c.salt, ": bad packet length")
+	}
+	data := make([]byte, length)
+	copy(data, header[:])
+	_, err = io.ReadFull(c.Conn, data[4:])
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(hmacSum(c.hash, macKey, data[:length-4])[:4], data[length-4:]) {
+		return E.New(c.salt, ": bad packet checksum")
+	}
+	c.recvID++
+	position := int(data[4])
+	if position < 255 {
+		position += 4
+	} else {
+		position = int(binary.LittleEndian.Uint16(data[5:7])) + 4
+	}
+	if position > length-4 {
+		return E.New(c.salt, ": bad packet padding")
+	}
+	c.payload = data[position : length-4]
+	return nil
+}
+
+func (c *authAES128Conn) Write(p []byte) (int, error) {
+	var data bytes.Buffer
+	for remaining := p; len(remaining) > 0; {
+		size := len(remaining)
+		if size > authAES128UnitLength {
+			size = authAES128UnitLength
+		}
+		c.packData(&data, remaining[:size], len(p))
+		remaining = remaining[size:]
+	}
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *authAES128Conn) packData(buffer *bytes.Buffer, data []byte, fullLength int) {
+	randomLength := authAES128RandomLength(len(data), fullLength)
+	var padding []byte
+	if randomLength < 128 {
+		padding = make([]byte, randomLength+1)
+		padding[0] = byte(randomLength + 1)
+		rand.Read(padding[1:])
+	} else {
+		padding = make([]byte, randomLength+1)
+		padding[0] = 255
+		binary.LittleEndian.PutUint16(padding[1:], uint16(randomLength+1))
+		rand.Read(padding[3:])
+	}
+	length := 4 + len(padding) + len(data) + 4
+	macKey := packetIDKey(c.userKey, c.packID)
+	start := buffer.Len()
+	binary.Write(buffer, binary.LittleEndian, uint16(length))
+	buffer.Write(hmacSum(c.hash, macKey, buffer.Bytes()[start:start+2])[:2])
+	buffer.Write(padding)
+	buffer.Write(data)
+	buffer.Write(hmacSum(c.hash, macKey, buffer.Bytes()[start:])[:4])
+	c.packID++
+}
+
+func authAES128RandomLength(length int, fullLength int) int {
+	if fullLength >= bufferSize {
+		return 0
+	}
+	reverseLength := tcpMSS - length - 9
+	if reverseLength == 0 {
+		return 0
+	}
+	if reverseLength < 0 {
+		if reverseLength > -tcpMSS {
+			return trapezoidRandomInt(reverseLength+tcpMSS, -0.3)
+		}
+		return mRand.Intn(32)
+	}
+	if length > 900 {
+		return mRand.Intn(reverseLength)
+	}
+	return trapezoidRandomInt(reverseLength, -0.3)
+}
+
+func trapezoidRandomInt(max int, d float64) int {
+	s := mRand.Float64()
+	a := 1 - d
+	return int((math.Sqrt(a*a+4*d*s) - a) / (2 * d) * float64(max))
+}
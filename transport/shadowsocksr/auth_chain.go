@@ -0,0 +1,211 @@
+package shadowsocksr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const authChainMaxPacketLength = 4096
+
+type authChainConn struct {
+	net.Conn
+	protocol       *protocolContext
+	userKey        []byte
+	recvID         uint32
+	packID         uint32
+	clientOverhead int
+	lastClientHash []byte
+	lastServerHash []byte
+	randomClient   xorShift128Plus
+	randomServer   xorShift128Plus
+	decrypter      *rc4.Cipher
+	encrypter      *rc4.Cipher
+	payload        []byte
+}
+
+func (c *authChainConn) serverHandshake(iv []byte) (uint32, bool, error) {
+	header := make([]byte, 36)
+	_, err := io.ReadFull(c.Conn, header[:12])
+	if err != nil {
+		return 0, false, E.Cause(err, "read auth_chain_a header")
+	}
+	c.lastClientHash = hmacSum(md5.New, concat(iv, c.protocol.key), header[:4])
+	if !hmac.Equal(c.lastClientHash[:8], header[4:12]) {
+		return 0, false, E.New("auth_chain_a: bad check head")
+	}
+	_, err = io.ReadFull(c.Conn, header[12:])
+	if err != nil {
+		return 0, false, E.Cause(err, "read auth_chain_a header")
+	}
+	userID := binary.LittleEndian.Uint32(header[12:16]) ^ binary.LittleEndian.Uint32(c.lastClientHash[8:12])
+	var authenticated bool
+	c.userKey, authenticated, err = c.protocol.userPassword(userID)
+	if err != nil {
+		return 0, false, E.Cause(err, "auth_chain_a")
+	}
+	c.lastServerHash = hmacSum(md5.New, c.userKey, header[12:32])
+	if !hmac.Equal(c.lastServerHash[:4], header[32:36]) {
+		return 0, false, E.New("auth_chain_a: bad header hmac")
+	}
+	head, err := decryptAuthHead(c.userKey, ProtocolAuthChainA, header[16:32])
+	if err != nil {
+		return 0, false, err
+	}
+	c.clientOverhead = int(binary.LittleEndian.Uint16(head[12:14]))
+	err = c.protocol.checkTimestamp(binary.LittleEndian.Uint32(head[:4]))
+	if err != nil {
+		return 0, false, E.Cause(err, "auth_chain_a")
+	}
+	if !c.protocol.clients.insert(userID, binary.LittleEndian.Uint32(head[4:8]), binary.LittleEndian.Uint32(head[8:12])) {
+		return 0, false, E.New("auth_chain_a: replayed connection")
+	}
+	rc4Key := legacyKey(base64.StdEncoding.EncodeToString(c.userKey)+base64.StdEncoding.EncodeToString(c.lastClientHash), 16)
+	c.decrypter, _ = rc4.NewCipher(rc4Key)
+	c.encrypter, _ = rc4.NewCipher(rc4Key)
+	c.recvID = 1
+	c.packID = 1
+	return userID, authenticated, nil
+}
+
+func (c *authChainConn) Read(p []byte) (n int, err error) {
+	for len(c.payload) == 0 {
+		err = c.readPacket()
+		if err != nil {
+			return
+		}
+	}
+	n = copy(p, c.payload)
+	c.payload = c.payload[n:]
+	return
+}
+
+func (c *authChainConn) readPacket() error {
+	var header [2]byte
+	_, err := io.ReadFull(c.Conn, header[:])
+	if err != nil {
+		return err
+	}
+	dataLength := int(binary.LittleEndian.Uint16(header[:]) ^ binary.LittleEndian.Uint16(c.lastClientHash[14:16]))
+	randomLength := authChainRandomLength(dataLength, c.lastClientHash, &c.randomClient)
+	length := dataLength + randomLength
+	if length >= authChainMaxPacketLength {
+		return E.New("auth_chain_a: bad packet length")
+	}
+	data := make([]byte, length+4)
+	copy(data, header[:])
+	_, err = io.ReadFull(c.Conn, data[2:])
+	if err != nil {
+		return err
+	}
+	clientHash := hmacSum(md5.New, packetIDKey(c.userKey, c.recvID), data[:length+2])
+	if !hmac.Equal(clientHash[:2], data[length+2:]) {
+		return E.New("auth_chain_a: bad packet checksum")
+	}
+	c.recvID++
+	position := 2
+	if dataLength > 0 && randomLength > 0 {
+		position += authChainRandomStartPosition(randomLength, &c.randomClient)
+	}
+	c.payload = data[position : position+dataLength]
+	c.decrypter.XORKeyStream(c.payload, c.payload)
+	c.lastClientHash = clientHash
+	return nil
+}
+
+func (c *authChainConn) Write(p []byte) (int, error) {
+	var data bytes.Buffer
+	unitLength := tcpMSS - c.clientOverhead
+	remaining := p
+	if c.packID == 1 {
+		// the first packet tells the client the server mss
+		remaining = append(binary.LittleEndian.AppendUint16(nil, tcpMSS), p...)
+	}
+	for len(remaining) > 0 {
+		size := len(remaining)
+		if size > unitLength {
+			size = unitLength
+		}
+		c.packData(&data, remaining[:size])
+		remaining = remaining[size:]
+	}
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *authChainConn) packData(buffer *bytes.Buffer, data []byte) {
+	encrypted := make([]byte, len(data))
+	c.encrypter.XORKeyStream(encrypted, data)
+	randomLength := authChainRandomLength(len(encrypted), c.lastServerHash, &c.randomServer)
+	padding := make([]byte, randomLength)
+	rand.Read(padding)
+	var startPosition int
+	if randomLength > 0 {
+		startPosition = authChainRandomStartPosition(randomLength, &c.randomServer)
+	}
+	start := buffer.Len()
+	binary.Write(buffer, binary.LittleEndian, uint16(len(encrypted))^binary.LittleEndian.Uint16(c.lastServerHash[14:16]))
+	buffer.Write(padding[:startPosition])
+	buffer.Write(encrypted)
+	buffer.Write(padding[startPosition:])
+	c.lastServerHash = hmacSum(md5.New, packetIDKey(c.userKey, c.packID), buffer.Bytes()[start:])
+	buffer.Write(c.lastServerHash[:2])
+	c.packID++
+}
+
+func authChainRandomLength(length int, lastHash []byte, random *xorShift128Plus) int {
+	if length > 1440 {
+		return 0
+	}
+	random.initFromBinLength(lastHash, length)
+	switch {
+	case length > 1300:
+		return int(random.next() % 31)
+	case length > 900:
+		return int(random.next() % 127)
+	case length > 400:
+		return int(random.next() % 521)
+	default:
+		return int(random.next() % 1021)
+	}
+}
+
+func authChainRandomStartPosition(length int, random *xorShift128Plus) int {
+	return int(random.next() % 8589934609 % uint64(length))
+}
+
+type xorShift128Plus struct {
+	v0, v1 uint64
+}
+
+func (r *xorShift128Plus) next() uint64 {
+	x := r.v0
+	y := r.v1
+	r.v0 = y
+	x ^= x << 23
+	x ^= y ^ (x >> 17) ^ (y >> 26)
+	r.v1 = x
+	return x + y
+}
+
+func (r *xorShift128Plus) initFromBinLength(data []byte, length int) {
+	var seed [16]byte
+	copy(seed[:], data)
+	binary.LittleEndian.PutUint16(seed[:], uint16(length))
+	r.v0 = binary.LittleEndian.Uint64(seed[:8])
+	r.v1 = binary.LittleEndian.Uint64(seed[8:])
+	for i := 0; i < 4; i++ {
+		r.next()
+	}
+}
@@ -0,0 +1,146 @@
+package shadowsocksr
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"io"
+	"net"
+
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+const MethodNone = "none"
+
+type streamMethod struct {
+	keyLength int
+	ivLength  int
+	encrypter func(key []byte, iv []byte) (cipher.Stream, error)
+	decrypter func(key []byte, iv []byte) (cipher.Stream, error)
+}
+
+var methods = map[string]streamMethod{
+	MethodNone:      {16, 0, nil, nil},
+	"aes-128-ctr":   {16, 16, newCTR, newCTR},
+	"aes-192-ctr":   {24, 16, newCTR, newCTR},
+	"aes-256-ctr":   {32, 16, newCTR, newCTR},
+	"aes-128-cfb":   {16, 16, newCFBEncrypter, newCFBDecrypter},
+	"aes-192-cfb":   {24, 16, newCFBEncrypter, newCFBDecrypter},
+	"aes-256-cfb":   {32, 16, newCFBEncrypter, newCFBDecrypter},
+	"rc4-md5":       {16, 16, newRC4MD5, newRC4MD5},
+	"chacha20-ietf": {32, 12, newChaCha20, newChaCha20},
+}
+
+func newCTR(key []byte, iv []byte) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewCTR(block, iv), nil
+}
+
+func newCFBEncrypter(key []byte, iv []byte) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewCFBEncrypter(block, iv), nil
+}
+
+func newCFBDecrypter(key []byte, iv []byte) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewCFBDecrypter(block, iv), nil
+}
+
+func newRC4MD5(key []byte, iv []byte) (cipher.Stream, error) {
+	hash := md5.New()
+	hash.Write(key)
+	hash.Write(iv)
+	return rc4.NewCipher(hash.Sum(nil))
+}
+
+func newChaCha20(key []byte, iv []byte) (cipher.Stream, error) {
+	return chacha20.NewUnauthenticatedCipher(key, iv)
+}
+
+// legacyKey is the OpenSSL EVP_BytesToKey derivation with MD5 used by legacy shadowsocks.
+func legacyKey(password string, keyLength int) []byte {
+	var (
+		key  []byte
+		last []byte
+	)
+	for len(key) < keyLength {
+		hash := md5.New()
+		hash.Write(last)
+		hash.Write([]byte(password))
+		last = hash.Sum(nil)
+		key = append(key, last...)
+	}
+	return key[:keyLength]
+}
+
+type streamConn struct {
+	net.Conn
+	method      streamMethod
+	key         []byte
+	readIV      []byte
+	readStream  cipher.Stream
+	writeStream cipher.Stream
+}
+
+func (c *streamConn) readClientIV() error {
+	if c.method.ivLength == 0 {
+		return nil
+	}
+	c.readIV = make([]byte, c.method.ivLength)
+	_, err := io.ReadFull(c.Conn, c.readIV)
+	if err != nil {
+		return E.Cause(err, "read iv")
+	}
+	c.readStream, err = c.method.decrypter(c.key, c.readIV)
+	return err
+}
+
+func (c *streamConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if c.readStream != nil && n > 0 {
+		c.readStream.XORKeyStream(p[:n], p[:n])
+	}
+	return
+}
+
+func (c *streamConn) Write(p []byte) (n int, err error) {
+	if c.method.ivLength == 0 {
+		return c.Conn.Write(p)
+	}
+	var iv []byte
+	if c.writeStream == nil {
+		iv = make([]byte, c.method.ivLength)
+		_, err = rand.Read(iv)
+		if err != nil {
+			return
+		}
+		c.writeStream, err = c.method.encrypter(c.key, iv)
+		if err != nil {
+			return
+		}
+	}
+	buffer := buf.NewSize(len(iv) + len(p))
+	defer buffer.Release()
+	data := buffer.Extend(len(iv) + len(p))
+	copy(data, iv)
+	c.writeStream.XORKeyStream(data[len(iv):], p)
+	_, err = c.Conn.Write(data)
+	if err != nil {
+		return
+	}
+	return len(p), nil
+}
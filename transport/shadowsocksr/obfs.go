@@ -0,0 +1,309 @@
+package shadowsocksr
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	mRand "math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/replay"
+)
+
+const (
+	ObfsPlain            = "plain"
+	ObfsHTTPSimple       = "http_simple"
+	ObfsTLS12TicketAuth  = "tls1.2_ticket_auth"
+	maxTimeDifference    = 24 * time.Hour
+	tlsApplicationRecord = 0x17
+)
+
+var tlsVersion = []byte{0x03, 0x03}
+
+type obfsContext struct {
+	key      []byte
+	timeFunc func() time.Time
+	replay   replay.Filter
+}
+
+func newObfsConn(name string, conn net.Conn, obfs *obfsContext) (net.Conn, error) {
+	switch name {
+	case "", ObfsPlain:
+		return conn, nil
+	case ObfsHTTPSimple:
+		return &httpSimpleConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+	case ObfsTLS12TicketAuth:
+		return &tlsTicketAuthConn{Conn: conn, obfs: obfs}, nil
+	default:
+		return nil, E.New("unsupported obfs: ", name)
+	}
+}
+
+type httpSimpleConn struct {
+	net.Conn
+	reader        io.Reader
+	readHeader    bool
+	writtenHeader bool
+}
+
+func (c *httpSimpleConn) Read(p []byte) (int, error) {
+	if !c.readHeader {
+		c.readHeader = true
+		err := c.readRequest()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return c.reader.Read(p)
+}
+
+func (c *httpSimpleConn) readRequest() error {
+	bufReader := c.reader.(*bufio.Reader)
+	method, err := bufReader.Peek(5)
+	if err != nil {
+		return E.Cause(err, "read obfs request")
+	}
+	if !bytes.HasPrefix(method, []byte("GET ")) && !bytes.HasPrefix(method, []byte("POST ")) {
+		// not an http header, run on the original protocol
+		c.writtenHeader = true
+		return nil
+	}
+	request, err := http.ReadRequest(bufReader)
+	if err != nil {
+		return E.Cause(err, "read obfs request")
+	}
+	payload, err := decodeHTTPSimplePath(request.RequestURI)
+	if err != nil {
+		return E.Cause(err, "bad obfs request")
+	}
+	c.reader = io.MultiReader(bytes.NewReader(payload), request.Body, bufReader)
+	return nil
+}
+
+func decodeHTTPSimplePath(path string) ([]byte, error) {
+	items := strings.Split(path, "%")
+	if len(items) < 2 {
+		return nil, E.New("missing payload")
+	}
+	var payload []byte
+	for _, item := range items[1:] {
+		var stop bool
+		if len(item) < 2 {
+			item = "0" + item
+			stop = true
+		} else if len(item) > 2 {
+			item = item[:2]
+			stop = true
+		}
+		data, err := hex.DecodeString(item)
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, data...)
+		if stop {
+			break
+		}
+	}
+	return payload, nil
+}
+
+func (c *httpSimpleConn) Write(p []byte) (int, error) {
+	if !c.writtenHeader {
+		c.writtenHeader = true
+		response := "HTTP/1.1 200 OK\r\n" +
+			"Connection: keep-alive\r\n" +
+			"Content-Encoding: gzip\r\n" +
+			"Content-Type: text/html\r\n" +
+			"Date: " + time.Now().UTC().Format(http.TimeFormat) + "\r\n" +
+			"Server: nginx\r\n" +
+			"Vary: Accept-Encoding\r\n\r\n"
+		_, err := c.Conn.Write(append([]byte(response), p...))
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+type tlsTicketAuthConn struct {
+	net.Conn
+	obfs      *obfsContext
+	clientID  []byte
+	handshake bool
+	remain    int
+}
+
+func (c *tlsTicketAuthConn) Read(p []byte) (int, error) {
+	if !c.handshake {
+		err := c.serverHandshake()
+		if err != nil {
+			return 0, err
+		}
+		c.handshake = true
+	}
+	for c.remain == 0 {
+		var header [5]byte
+		_, err := io.ReadFull(c.Conn, header[:])
+		if err != nil {
+			return 0, err
+		}
+		if header[0] != tlsApplicationRecord || !bytes.Equal(header[1:3], tlsVersion) {
+			return 0, E.New("bad obfs application data")
+		}
+		c.remain = int(binary.BigEndian.Uint16(header[3:]))
+	}
+	if len(p) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.Conn.Read(p)
+	c.remain -= n
+	return n, err
+}
+
+func (c *tlsTicketAuthConn) serverHandshake() error {
+	var header [5]byte
+	_, err := io.ReadFull(c.Conn, header[:])
+	if err != nil {
+		return E.Cause(err, "read obfs client hello")
+	}
+	if !bytes.Equal(header[:3], []byte{0x16, 0x03, 0x01}) {
+		return E.New("bad obfs client hello")
+	}
+	hello := make([]byte, binary.BigEndian.Uint16(header[3:]))
+	_, err = io.ReadFull(c.Conn, hello)
+	if err != nil {
+		return E.Cause(err, "read obfs client hello")
+	}
+	// handshake type, length, version, verify id and session id length
+	if len(hello) < 4+2+32+1 || hello[0] != 1 || hello[1] != 0 ||
+		int(binary.BigEndian.Uint16(hello[2:])) != len(hello)-4 ||
+		!bytes.Equal(hello[4:6], tlsVersion) {
+		return E.New("bad obfs client hello")
+	}
+	verifyID := hello[6:38]
+	sessionIDLength := int(hello[38])
+	if sessionIDLength < 32 || len(hello) < 39+sessionIDLength {
+		return E.New("bad obfs client hello: wrong session id length")
+	}
+	c.clientID = hello[39 : 39+sessionIDLength]
+	if !hmac.Equal(c.hmac(verifyID[:22])[:10], verifyID[22:]) {
+		return E.New("bad obfs client hello: authentication failed")
+	}
+	timeDifference := time.Duration(int32(uint32(c.obfs.timeFunc().Unix())-binary.BigEndian.Uint32(verifyID))) * time.Second
+	if timeDifference < -maxTimeDifference || timeDifference > maxTimeDifference {
+		return E.New("bad obfs client hello: wrong timestamp")
+	}
+	if !c.obfs.replay.Check(verifyID[:22]) {
+		return E.New("bad obfs client hello: replayed")
+	}
+	_, err = c.Conn.Write(c.serverHello())
+	if err != nil {
+		return err
+	}
+
+	// change cipher spec and finished
+	var finished [11]byte
+	_, err = io.ReadFull(c.Conn, finished[:])
+	if err != nil {
+		return E.Cause(err, "read obfs finished")
+	}
+	if !bytes.Equal(finished[:6], []byte{0x14, 0x03, 0x03, 0x00, 0x01, 0x01}) ||
+		!bytes.Equal(finished[6:10], []byte{0x16, 0x03, 0x03, 0x00}) {
+		return E.New("bad obfs finished")
+	}
+	finishedLength := int(finished[10])
+	if finishedLength < 10 {
+		return E.New("bad obfs finished")
+	}
+	verifyData := make([]byte, 11+finishedLength)
+	copy(verifyData, finished[:])
+	_, err = io.ReadFull(c.Conn, verifyData[11:])
+	if err != nil {
+		return E.Cause(err, "read obfs finished")
+	}
+	verifyLength := len(verifyData) - 10
+	if !hmac.Equal(c.hmac(verifyData[:verifyLength])[:10], verifyData[verifyLength:]) {
+		return E.New("bad obfs finished: authentication failed")
+	}
+	return nil
+}
+
+func (c *tlsTicketAuthConn) hmac(data []byte) []byte {
+	hash := hmac.New(sha1.New, append(append([]byte{}, c.obfs.key...), c.clientID...))
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+func (c *tlsTicketAuthConn) serverHello() []byte {
+	var hello bytes.Buffer
+	hello.Write(tlsVersion)
+	hello.Write(c.authData())
+	hello.WriteByte(0x20)
+	hello.Write(c.clientID)
+	hello.Write([]byte{0xc0, 0x2f, 0x00, 0x00, 0x05, 0xff, 0x01, 0x00, 0x01, 0x00})
+
+	var data bytes.Buffer
+	writeTLSRecord(&data, 0x16, append([]byte{0x02, 0x00, byte(hello.Len() >> 8), byte(hello.Len())}, hello.Bytes()...))
+	if mRand.Intn(8) < 1 {
+		ticket := make([]byte, (mRand.Intn(164))*2+64)
+		rand.Read(ticket)
+		newSessionTicket := append([]byte{0x04, 0x00, byte(len(ticket) >> 8), byte(len(ticket))}, ticket...)
+		writeTLSRecord(&data, 0x16, newSessionTicket)
+	}
+	writeTLSRecord(&data, 0x14, []byte{0x01})
+	finishedLength := 32
+	if mRand.Intn(2) == 0 {
+		finishedLength = 40
+	}
+	data.Write([]byte{0x16, 0x03, 0x03, 0x00, byte(finishedLength)})
+	random := make([]byte, finishedLength-10)
+	rand.Read(random)
+	data.Write(random)
+	data.Write(c.hmac(data.Bytes())[:10])
+	return data.Bytes()
+}
+
+func (c *tlsTicketAuthConn) authData() []byte {
+	data := make([]byte, 32)
+	binary.BigEndian.PutUint32(data, uint32(c.obfs.timeFunc().Unix()))
+	rand.Read(data[4:22])
+	copy(data[22:], c.hmac(data[:22])[:10])
+	return data
+}
+
+func (c *tlsTicketAuthConn) Write(p []byte) (int, error) {
+	var data bytes.Buffer
+	for remaining := p; len(remaining) > 0; {
+		size := len(remaining)
+		if size > 2048 {
+			size = mRand.Intn(4096) + 100
+			if size > len(remaining) {
+				size = len(remaining)
+			}
+		}
+		writeTLSRecord(&data, tlsApplicationRecord, remaining[:size])
+		remaining = remaining[size:]
+	}
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func writeTLSRecord(buffer *bytes.Buffer, recordType byte, data []byte) {
+	buffer.WriteByte(recordType)
+	buffer.Write(tlsVersion)
+	binary.Write(buffer, binary.BigEndian, uint16(len(data)))
+	buffer.Write(data)
+}
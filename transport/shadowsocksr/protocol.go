@@ -0,0 +1,187 @@
+package shadowsocksr
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+	"net"
+	"sync"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	ProtocolOrigin        = "origin"
+	ProtocolAuthAES128MD5 = "auth_aes128_md5"
+	ProtocolAuthAES128SHA = "auth_aes128_sha1"
+	ProtocolAuthChainA    = "auth_chain_a"
+)
+
+type protocolContext struct {
+	key      []byte
+	timeFunc func() time.Time
+	users    func(userID uint32) ([]byte, bool)
+	multi    bool
+	clients  *clientFilter
+}
+
+type protocolConn interface {
+	net.Conn
+	serverHandshake(iv []byte) (userID uint32, authenticated bool, err error)
+}
+
+func newProtocolConn(name string, conn net.Conn, protocol *protocolContext) (protocolConn, error) {
+	switch name {
+	case "", ProtocolOrigin:
+		return &originConn{conn}, nil
+	case ProtocolAuthAES128MD5:
+		return &authAES128Conn{Conn: conn, protocol: protocol, hash: md5.New, salt: ProtocolAuthAES128MD5}, nil
+	case ProtocolAuthAES128SHA:
+		return &authAES128Conn{Conn: conn, protocol: protocol, hash: sha1.New, salt: ProtocolAuthAES128SHA}, nil
+	case ProtocolAuthChainA:
+		return &authChainConn{Conn: conn, protocol: protocol}, nil
+	default:
+		return nil, E.New("unsupported protocol: ", name)
+	}
+}
+
+type originConn struct {
+	net.Conn
+}
+
+func (c *originConn) serverHandshake(iv []byte) (uint32, bool, error) {
+	return 0, false, nil
+}
+
+func hmacSum(hashFunc func() hash.Hash, key []byte, data ...[]byte) []byte {
+	mac := hmac.New(hashFunc, key)
+	for _, item := range data {
+		mac.Write(item)
+	}
+	return mac.Sum(nil)
+}
+
+func concat(data ...[]byte) []byte {
+	var result []byte
+	for _, item := range data {
+		result = append(result, item...)
+	}
+	return result
+}
+
+func packetIDKey(userKey []byte, id uint32) []byte {
+	return binary.LittleEndian.AppendUint32(concat(userKey), id)
+}
+
+// decryptAuthHead decrypts the 16-byte header block of auth_* protocols,
+// which is encrypted by aes-128-cbc with a zero iv.
+func decryptAuthHead(userKey []byte, salt string, block []byte) ([]byte, error) {
+	aesCipher, err := aes.NewCipher(legacyKey(base64.StdEncoding.EncodeToString(userKey)+salt, 16))
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 16)
+	aesCipher.Decrypt(head, block)
+	return head, nil
+}
+
+// userPassword returns the password of the user, or the server key
+// if no users are configured.
+func (p *protocolContext) userPassword(userID uint32) ([]byte, bool, error) {
+	password, loaded := p.users(userID)
+	if loaded {
+		return password, true, nil
+	}
+	if p.multi {
+		return nil, false, E.New("unknown user id: ", userID)
+	}
+	return p.key, false, nil
+}
+
+func (p *protocolContext) checkTimestamp(timestamp uint32) error {
+	timeDifference := time.Duration(int32(timestamp-uint32(p.timeFunc().Unix()))) * time.Second
+	if timeDifference < -maxTimeDifference || timeDifference > maxTimeDifference {
+		return E.New("wrong timestamp")
+	}
+	return nil
+}
+
+// clientFilter rejects replayed connections by tracking the connection id
+// window of each client, in the same way as the reference implementation.
+type clientFilter struct {
+	access    sync.Mutex
+	lastClean time.Time
+	clients   map[clientKey]*clientQueue
+}
+
+type clientKey struct {
+	userID   uint32
+	clientID uint32
+}
+
+type clientQueue struct {
+	front      uint32
+	back       uint32
+	alloc      map[uint32]bool
+	lastUpdate time.Time
+}
+
+const clientQueueTimeout = 3 * time.Minute
+
+func newClientFilter() *clientFilter {
+	return &clientFilter{
+		lastClean: time.Now(),
+		clients:   make(map[clientKey]*clientQueue),
+	}
+}
+
+func (f *clientFilter) insert(userID uint32, clientID uint32, connectionID uint32) bool {
+	f.access.Lock()
+	defer f.access.Unlock()
+	now := time.Now()
+	if now.Sub(f.lastClean) > clientQueueTimeout {
+		for key, queue := range f.clients {
+			if now.Sub(queue.lastUpdate) > clientQueueTimeout {
+				delete(f.clients, key)
+			}
+		}
+		f.lastClean = now
+	}
+	key := clientKey{userID, clientID}
+	queue, loaded := f.clients[key]
+	if !loaded {
+		queue = &clientQueue{}
+		f.clients[key] = queue
+	}
+	if !loaded || now.Sub(queue.lastUpdate) > clientQueueTimeout {
+		queue.reset(connectionID)
+	}
+	queue.lastUpdate = now
+	if connectionID < queue.front || connectionID > queue.front+0x4000 || queue.alloc[connectionID] {
+		return false
+	}
+	if queue.back <= connectionID {
+		queue.back = connectionID + 1
+	}
+	queue.alloc[connectionID] = true
+	for queue.alloc[queue.front] || queue.front+0x1000 < queue.back {
+		delete(queue.alloc, queue.front)
+		queue.front++
+	}
+	return true
+}
+
+func (q *clientQueue) reset(connectionID uint32) {
+	if connectionID > 64 {
+		q.front = connectionID - 64
+	} else {
+		q.front = 0
+	}
+	q.back = connectionID + 1
+	q.alloc = make(map[uint32]bool)
+}
@@ -0,0 +1,133 @@
+package shadowsocksr
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing/common/auth"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/replay"
+)
+
+type Handler interface {
+	N.TCPConnectionHandler
+	E.Handler
+}
+
+type Service[K comparable] struct {
+	method      streamMethod
+	key         []byte
+	obfs        string
+	protocol    string
+	obfsContext *obfsContext
+	clients     *clientFilter
+	timeFunc    func() time.Time
+	users       map[uint32]serviceUser[K]
+	handler     Handler
+}
+
+type serviceUser[K comparable] struct {
+	user     K
+	password []byte
+}
+
+func NewService[K comparable](method string, password string, obfs string, protocol string, timeFunc func() time.Time, handler Handler) (*Service[K], error) {
+	streamMethod, loaded := methods[method]
+	if !loaded {
+		return nil, E.New("unsupported method: ", method)
+	}
+	switch obfs {
+	case "", ObfsPlain, ObfsHTTPSimple, ObfsTLS12TicketAuth:
+	default:
+		return nil, E.New("unsupported obfs: ", obfs)
+	}
+	switch protocol {
+	case "", ProtocolOrigin, ProtocolAuthAES128MD5, ProtocolAuthAES128SHA, ProtocolAuthChainA:
+	default:
+		return nil, E.New("unsupported protocol: ", protocol)
+	}
+	if timeFunc == nil {
+		timeFunc = time.Now
+	}
+	key := legacyKey(password, streamMethod.keyLength)
+	return &Service[K]{
+		method:   streamMethod,
+		key:      key,
+		obfs:     obfs,
+		protocol: protocol,
+		obfsContext: &obfsContext{
+			key:      key,
+			timeFunc: timeFunc,
+			replay:   replay.NewSimple(maxTimeDifference),
+		},
+		clients:  newClientFilter(),
+		timeFunc: timeFunc,
+		users:    make(map[uint32]serviceUser[K]),
+		handler:  handler,
+	}, nil
+}
+
+var ErrUserExists = E.New("user already exists")
+
+// UpdateUsers sets the users identified by the uid and password in the client protocol_param.
+func (s *Service[K]) UpdateUsers(userList []K, idList []uint32, passwordList []string) error {
+	switch s.protocol {
+	case ProtocolAuthAES128MD5, ProtocolAuthAES128SHA, ProtocolAuthChainA:
+	default:
+		if len(userList) > 0 {
+			return E.New("multi-user requires an auth_* protocol")
+		}
+	}
+	users := make(map[uint32]serviceUser[K])
+	for i, user := range userList {
+		if _, loaded := users[idList[i]]; loaded {
+			return E.Extend(ErrUserExists, "uid ", idList[i])
+		}
+		users[idList[i]] = serviceUser[K]{user, []byte(passwordList[i])}
+	}
+	s.users = users
+	return nil
+}
+
+func (s *Service[K]) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	obfsConn, err := newObfsConn(s.obfs, conn, s.obfsContext)
+	if err != nil {
+		return err
+	}
+	stream := &streamConn{Conn: obfsConn, method: s.method, key: s.key}
+	err = stream.readClientIV()
+	if err != nil {
+		return err
+	}
+	users := s.users
+	protocolConn, err := newProtocolConn(s.protocol, stream, &protocolContext{
+		key:      s.key,
+		timeFunc: s.timeFunc,
+		users: func(userID uint32) ([]byte, bool) {
+			user, loaded := users[userID]
+			return user.password, loaded
+		},
+		multi:   len(users) > 0,
+		clients: s.clients,
+	})
+	if err != nil {
+		return err
+	}
+	userID, authenticated, err := protocolConn.serverHandshake(stream.readIV)
+	if err != nil {
+		return err
+	}
+	if authenticated {
+		ctx = auth.ContextWithUser(ctx, users[userID].user)
+	}
+	destination, err := M.SocksaddrSerializer.ReadAddrPort(protocolConn)
+	if err != nil {
+		return E.Cause(err, "read destination")
+	}
+	metadata.Protocol = "shadowsocksr"
+	metadata.Destination = destination
+	return s.handler.NewConnection(ctx, protocolConn, metadata)
+}
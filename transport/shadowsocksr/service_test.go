@@ -0,0 +1,513 @@
+package shadowsocksr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing/common/auth"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMethod = "aes-128-ctr"
+
+var testDestination = M.ParseSocksaddr("example.com:443")
+
+type testResult struct {
+	destination   M.Socksaddr
+	user          int
+	authenticated bool
+}
+
+type testHandler struct {
+	results chan testResult
+}
+
+func (h *testHandler) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	user, authenticated := auth.UserFromContext[int](ctx)
+	h.results <- testResult{metadata.Destination, user, authenticated}
+	_, err := io.Copy(conn, conn)
+	return err
+}
+
+func (h *testHandler) NewError(ctx context.Context, err error) {
+}
+
+func newTestService(t *testing.T, obfs string, protocol string) (*Service[int], *testHandler) {
+	handler := &testHandler{make(chan testResult, 2)}
+	service, err := NewService[int](testMethod, "password", obfs, protocol, nil, handler)
+	require.NoError(t, err)
+	if protocol != ProtocolOrigin {
+		require.NoError(t, service.UpdateUsers([]int{7}, []uint32{1}, []string{"user-password"}))
+	}
+	return service, handler
+}
+
+// serve runs a server connection and returns the pipe end for the client.
+func serve(service *Service[int]) (net.Conn, chan error) {
+	clientConn, serverConn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- service.NewConnection(context.Background(), serverConn, M.Metadata{})
+		serverConn.Close()
+	}()
+	return clientConn, done
+}
+
+type testClient struct {
+	key          []byte
+	obfs         string
+	protocol     string
+	userID       uint32
+	userPassword []byte
+	clientID     uint32
+	connectionID uint32
+	tamperHello  func(verifyID []byte)
+	tamperHeader func(header []byte)
+}
+
+func newTestClient(service *Service[int]) *testClient {
+	var clientID [4]byte
+	rand.Read(clientID[:])
+	return &testClient{
+		key:          service.key,
+		obfs:         service.obfs,
+		protocol:     service.protocol,
+		userID:       1,
+		userPassword: []byte("user-password"),
+		clientID:     binary.LittleEndian.Uint32(clientID[:]),
+		connectionID: 1,
+	}
+}
+
+// dial wraps the connection with the client side of the configured obfs and protocol
+// and writes the destination.
+func (c *testClient) dial(conn net.Conn) (net.Conn, error) {
+	switch c.obfs {
+	case ObfsHTTPSimple:
+		conn = &testHTTPSimpleClient{Conn: conn, reader: bufio.NewReader(conn)}
+	case ObfsTLS12TicketAuth:
+		conn = &testTLSTicketAuthClient{Conn: conn, client: c}
+	}
+	stream := newTestStream(conn, c.key)
+	destination := new(bytes.Buffer)
+	M.SocksaddrSerializer.WriteAddrPort(destination, testDestination)
+	switch c.protocol {
+	case ProtocolAuthAES128MD5:
+		return c.dialAuthAES128(stream, md5.New, destination.Bytes())
+	case ProtocolAuthAES128SHA:
+		return c.dialAuthAES128(stream, sha1.New, destination.Bytes())
+	case ProtocolAuthChainA:
+		return c.dialAuthChain(stream, destination.Bytes())
+	default:
+		_, err := stream.Write(destination.Bytes())
+		return stream, err
+	}
+}
+
+func (c *testClient) authHead(userKey []byte, overhead uint16, length uint16, randomLength uint16) []byte {
+	head := make([]byte, 16)
+	binary.LittleEndian.PutUint32(head, uint32(time.Now().Unix()))
+	binary.LittleEndian.PutUint32(head[4:], c.clientID)
+	binary.LittleEndian.PutUint32(head[8:], c.connectionID)
+	if overhead > 0 {
+		binary.LittleEndian.PutUint16(head[12:], overhead)
+	} else {
+		binary.LittleEndian.PutUint16(head[12:], length)
+		binary.LittleEndian.PutUint16(head[14:], randomLength)
+	}
+	aesCipher, _ := aes.NewCipher(legacyKey(base64.StdEncoding.EncodeToString(userKey)+c.protocol, 16))
+	aesCipher.Encrypt(head, head)
+	return head
+}
+
+func (c *testClient) dialAuthAES128(stream *testStream, hashFunc func() hash.Hash, payload []byte) (net.Conn, error) {
+	userHash := hashFunc()
+	userHash.Write(c.userPassword)
+	userKey := userHash.Sum(nil)
+	const randomLength = 16
+	length := 31 + randomLength + len(payload) + 4
+	macKey := concat(stream.writeIV, c.key)
+	header := make([]byte, 7, length)
+	rand.Read(header[:1])
+	copy(header[1:], hmacSum(hashFunc, macKey, header[:1])[:6])
+	header = binary.LittleEndian.AppendUint32(header, c.userID)
+	header = append(header, c.authHead(userKey, 0, uint16(length), randomLength)...)
+	header = append(header, hmacSum(hashFunc, macKey, header[7:27])[:4]...)
+	header = append(header, make([]byte, randomLength)...)
+	header = append(header, payload...)
+	header = append(header, hmacSum(hashFunc, userKey, header)[:4]...)
+	if c.tamperHeader != nil {
+		c.tamperHeader(header)
+	}
+	_, err := stream.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	return &authAES128Conn{
+		Conn:    stream,
+		hash:    hashFunc,
+		salt:    c.protocol,
+		userKey: userKey,
+		recvID:  1,
+		packID:  1,
+	}, nil
+}
+
+func (c *testClient) dialAuthChain(stream *testStream, payload []byte) (net.Conn, error) {
+	header := make([]byte, 4, 36)
+	rand.Read(header)
+	clientHash := hmacSum(md5.New, concat(stream.writeIV, c.key), header)
+	header = append(header, clientHash[:8]...)
+	header = binary.LittleEndian.AppendUint32(header, c.userID^binary.LittleEndian.Uint32(clientHash[8:12]))
+	header = append(header, c.authHead(c.userPassword, 36, 0, 0)...)
+	serverHash := hmacSum(md5.New, c.userPassword, header[12:32])
+	header = append(header, serverHash[:4]...)
+	if c.tamperHeader != nil {
+		c.tamperHeader(header)
+	}
+	_, err := stream.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	rc4Key := legacyKey(base64.StdEncoding.EncodeToString(c.userPassword)+base64.StdEncoding.EncodeToString(clientHash), 16)
+	conn := &testAuthChainClient{authChainConn: &authChainConn{
+		Conn:    stream,
+		userKey: c.userPassword,
+		recvID:  1,
+		packID:  1,
+		// the directions are swapped on the client side
+		lastClientHash: serverHash,
+		lastServerHash: clientHash,
+	}}
+	conn.decrypter, _ = rc4.NewCipher(rc4Key)
+	conn.encrypter, _ = rc4.NewCipher(rc4Key)
+	_, err = conn.Write(payload)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+type testAuthChainClient struct {
+	*authChainConn
+	readMSS bool
+}
+
+func (c *testAuthChainClient) Read(p []byte) (int, error) {
+	if !c.readMSS {
+		var mss [2]byte
+		_, err := io.ReadFull(c.authChainConn, mss[:])
+		if err != nil {
+			return 0, err
+		}
+		c.readMSS = true
+	}
+	return c.authChainConn.Read(p)
+}
+
+func (c *testAuthChainClient) Write(p []byte) (int, error) {
+	var data bytes.Buffer
+	for remaining := p; len(remaining) > 0; {
+		size := len(remaining)
+		if size > 1000 {
+			size = 1000
+		}
+		c.packData(&data, remaining[:size])
+		remaining = remaining[size:]
+	}
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type testStream struct {
+	net.Conn
+	key         []byte
+	writeIV     []byte
+	writtenIV   bool
+	writeStream cipher.Stream
+	readStream  cipher.Stream
+}
+
+func newTestStream(conn net.Conn, key []byte) *testStream {
+	writeIV := make([]byte, 16)
+	rand.Read(writeIV)
+	writeStream, _ := newCTR(key, writeIV)
+	return &testStream{Conn: conn, key: key, writeIV: writeIV, writeStream: writeStream}
+}
+
+func (c *testStream) Read(p []byte) (int, error) {
+	if c.readStream == nil {
+		readIV := make([]byte, 16)
+		_, err := io.ReadFull(c.Conn, readIV)
+		if err != nil {
+			return 0, err
+		}
+		c.readStream, _ = newCTR(c.key, readIV)
+	}
+	n, err := c.Conn.Read(p)
+	c.readStream.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+func (c *testStream) Write(p []byte) (int, error) {
+	var data []byte
+	if !c.writtenIV {
+		data = append(data, c.writeIV...)
+		c.writtenIV = true
+	}
+	encrypted := make([]byte, len(p))
+	c.writeStream.XORKeyStream(encrypted, p)
+	_, err := c.Conn.Write(append(data, encrypted...))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type testHTTPSimpleClient struct {
+	net.Conn
+	reader        *bufio.Reader
+	readHeader    bool
+	writtenHeader bool
+}
+
+func (c *testHTTPSimpleClient) Read(p []byte) (int, error) {
+	if !c.readHeader {
+		for {
+			line, err := c.reader.ReadString('\n')
+			if err != nil {
+				return 0, err
+			}
+			if line == "\r\n" {
+				break
+			}
+		}
+		c.readHeader = true
+	}
+	return c.reader.Read(p)
+}
+
+func (c *testHTTPSimpleClient) Write(p []byte) (int, error) {
+	if c.writtenHeader {
+		return c.Conn.Write(p)
+	}
+	c.writtenHeader = true
+	headLength := 24
+	if headLength > len(p) {
+		headLength = len(p)
+	}
+	var request bytes.Buffer
+	request.WriteString("GET /")
+	for _, b := range p[:headLength] {
+		request.WriteString("%" + hex.EncodeToString([]byte{b}))
+	}
+	request.WriteString(" HTTP/1.1\r\nHost: www.example.com\r\nUser-Agent: curl/8.4.0\r\n\r\n")
+	request.Write(p[headLength:])
+	_, err := c.Conn.Write(request.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type testTLSTicketAuthClient struct {
+	net.Conn
+	client    *testClient
+	handshake bool
+	remain    int
+}
+
+func (c *testTLSTicketAuthClient) clientHandshake() error {
+	server := &tlsTicketAuthConn{obfs: &obfsContext{key: c.client.key}, clientID: make([]byte, 32)}
+	rand.Read(server.clientID)
+	verifyID := make([]byte, 32)
+	binary.BigEndian.PutUint32(verifyID, uint32(time.Now().Unix()))
+	rand.Read(verifyID[4:22])
+	copy(verifyID[22:], server.hmac(verifyID[:22])[:10])
+	if c.client.tamperHello != nil {
+		c.client.tamperHello(verifyID)
+	}
+	hello := append(append([]byte{}, tlsVersion...), verifyID...)
+	hello = append(hello, 0x20)
+	hello = append(hello, server.clientID...)
+	hello = append(hello, 0x00, 0x02, 0xc0, 0x2f, 0x01, 0x00)
+	var data bytes.Buffer
+	data.Write([]byte{0x16, 0x03, 0x01})
+	binary.Write(&data, binary.BigEndian, uint16(len(hello)+4))
+	data.Write([]byte{0x01, 0x00})
+	binary.Write(&data, binary.BigEndian, uint16(len(hello)))
+	data.Write(hello)
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return err
+	}
+	for changeCipherSpec := false; ; {
+		var header [5]byte
+		_, err = io.ReadFull(c.Conn, header[:])
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(io.Discard, c.Conn, int64(binary.BigEndian.Uint16(header[3:])))
+		if err != nil {
+			return err
+		}
+		if changeCipherSpec {
+			break
+		}
+		changeCipherSpec = header[0] == 0x14
+	}
+	finished := []byte{0x14, 0x03, 0x03, 0x00, 0x01, 0x01, 0x16, 0x03, 0x03, 0x00, 32}
+	finished = append(finished, make([]byte, 22)...)
+	finished = append(finished, server.hmac(finished)[:10]...)
+	_, err = c.Conn.Write(finished)
+	return err
+}
+
+func (c *testTLSTicketAuthClient) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		var header [5]byte
+		_, err := io.ReadFull(c.Conn, header[:])
+		if err != nil {
+			return 0, err
+		}
+		c.remain = int(binary.BigEndian.Uint16(header[3:]))
+	}
+	if len(p) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.Conn.Read(p)
+	c.remain -= n
+	return n, err
+}
+
+func (c *testTLSTicketAuthClient) Write(p []byte) (int, error) {
+	if !c.handshake {
+		err := c.clientHandshake()
+		if err != nil {
+			return 0, err
+		}
+		c.handshake = true
+	}
+	var data bytes.Buffer
+	writeTLSRecord(&data, tlsApplicationRecord, p)
+	_, err := c.Conn.Write(data.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func TestServiceRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, protocol := range []string{ProtocolOrigin, ProtocolAuthAES128MD5, ProtocolAuthAES128SHA, ProtocolAuthChainA} {
+		for _, obfs := range []string{ObfsPlain, ObfsHTTPSimple, ObfsTLS12TicketAuth} {
+			protocol, obfs := protocol, obfs
+			t.Run(protocol+"/"+obfs, func(t *testing.T) {
+				t.Parallel()
+				service, handler := newTestService(t, obfs, protocol)
+				clientConn, done := serve(service)
+				conn, err := newTestClient(service).dial(clientConn)
+				require.NoError(t, err)
+				result := <-handler.results
+				require.Equal(t, testDestination, result.destination)
+				require.Equal(t, protocol != ProtocolOrigin, result.authenticated)
+				if result.authenticated {
+					require.Equal(t, 7, result.user)
+				}
+				message := make([]byte, 10000)
+				rand.Read(message)
+				writeErr := make(chan error, 1)
+				go func() {
+					_, err := conn.Write(message)
+					writeErr <- err
+				}()
+				response := make([]byte, len(message))
+				_, err = io.ReadFull(conn, response)
+				require.NoError(t, err)
+				require.Equal(t, message, response)
+				require.NoError(t, <-writeErr)
+				require.NoError(t, clientConn.Close())
+				require.NoError(t, <-done)
+			})
+		}
+	}
+}
+
+func TestServiceReplay(t *testing.T) {
+	t.Parallel()
+	for _, protocol := range []string{ProtocolAuthAES128MD5, ProtocolAuthChainA} {
+		service, handler := newTestService(t, ObfsPlain, protocol)
+		client := newTestClient(service)
+		clientConn, done := serve(service)
+		_, err := client.dial(clientConn)
+		require.NoError(t, err)
+		<-handler.results
+		clientConn.Close()
+		<-done
+		clientConn, done = serve(service)
+		client.dial(clientConn)
+		require.ErrorContains(t, <-done, "replayed connection", protocol)
+		clientConn.Close()
+		client.connectionID++
+		clientConn, done = serve(service)
+		_, err = client.dial(clientConn)
+		require.NoError(t, err, protocol)
+		<-handler.results
+		clientConn.Close()
+		<-done
+	}
+}
+
+func TestServiceBadHMAC(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		protocol string
+		obfs     string
+		tamper   func(client *testClient)
+		err      string
+	}{
+		{ProtocolAuthAES128MD5, ObfsPlain, func(client *testClient) {
+			client.tamperHeader = func(header []byte) { header[27] ^= 1 }
+		}, "bad header hmac"},
+		{ProtocolAuthAES128SHA, ObfsPlain, func(client *testClient) {
+			client.tamperHeader = func(header []byte) { header[len(header)-1] ^= 1 }
+		}, "bad checksum"},
+		{ProtocolAuthChainA, ObfsPlain, func(client *testClient) {
+			client.tamperHeader = func(header []byte) { header[32] ^= 1 }
+		}, "bad header hmac"},
+		{ProtocolAuthChainA, ObfsPlain, func(client *testClient) {
+			client.userPassword = []byte("wrong-password")
+		}, "bad header hmac"},
+		{ProtocolOrigin, ObfsTLS12TicketAuth, func(client *testClient) {
+			client.tamperHello = func(verifyID []byte) { verifyID[31] ^= 1 }
+		}, "authentication failed"},
+	} {
+		service, _ := newTestService(t, testCase.obfs, testCase.protocol)
+		client := newTestClient(service)
+		testCase.tamper(client)
+		clientConn, done := serve(service)
+		client.dial(clientConn)
+		require.ErrorContains(t, <-done, testCase.err, testCase.protocol)
+		clientConn.Close()
+	}
+}
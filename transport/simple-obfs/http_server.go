@@ -0,0 +1,76 @@
+package obfs
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HTTPObfsServer is the server side of shadowsocks http simple-obfs implementation
+type HTTPObfsServer struct {
+	net.Conn
+	reader        io.Reader
+	key           string
+	firstRequest  bool
+	firstResponse bool
+}
+
+func (ho *HTTPObfsServer) Read(b []byte) (int, error) {
+	if ho.firstRequest {
+		ho.firstRequest = false
+		bufReader := bufio.NewReader(ho.Conn)
+		request, err := http.ReadRequest(bufReader)
+		if err != nil {
+			return 0, E.Cause(err, "read obfs request")
+		}
+		if !strings.EqualFold(request.Header.Get("Upgrade"), "websocket") {
+			return 0, E.New("bad obfs request: missing websocket upgrade")
+		}
+		ho.key = request.Header.Get("Sec-WebSocket-Key")
+		ho.reader = io.MultiReader(request.Body, bufReader)
+	}
+	return ho.reader.Read(b)
+}
+
+func (ho *HTTPObfsServer) Write(b []byte) (int, error) {
+	if ho.firstResponse {
+		ho.firstResponse = false
+		accept := sha1.Sum([]byte(ho.key + webSocketGUID))
+		response := fmt.Sprintf("HTTP/1.1 101 Switching Protocols\r\n"+
+			"Server: nginx/1.%d.%d\r\n"+
+			"Date: %s\r\n"+
+			"Upgrade: websocket\r\n"+
+			"Connection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: %s\r\n\r\n",
+			rand.Int()%11, rand.Int()%12,
+			time.Now().UTC().Format(http.TimeFormat),
+			base64.StdEncoding.EncodeToString(accept[:]),
+		)
+		_, err := ho.Conn.Write(append([]byte(response), b...))
+		if err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return ho.Conn.Write(b)
+}
+
+// NewHTTPObfsServer return a HTTPObfsServer
+func NewHTTPObfsServer(conn net.Conn) net.Conn {
+	return &HTTPObfsServer{
+		Conn:          conn,
+		firstRequest:  true,
+		firstResponse: true,
+	}
+}
@@ -0,0 +1,240 @@
+package obfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	B "github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	recordTypeChangeCipherSpec = 0x14
+	recordTypeHandshake        = 0x16
+	recordTypeApplicationData  = 0x17
+
+	extensionSessionTicket = 0x0023
+)
+
+// TLSObfsServer is the server side of shadowsocks tls simple-obfs implementation
+type TLSObfsServer struct {
+	net.Conn
+	sessionID     []byte
+	buf           []byte
+	offset        int
+	remain        int
+	firstRequest  bool
+	firstResponse bool
+}
+
+func (to *TLSObfsServer) Read(b []byte) (int, error) {
+	if to.buf != nil {
+		n := copy(b, to.buf[to.offset:])
+		to.offset += n
+		if to.offset == len(to.buf) {
+			to.buf = nil
+		}
+		return n, nil
+	}
+
+	if to.remain > 0 {
+		length := to.remain
+		if length > len(b) {
+			length = len(b)
+		}
+
+		n, err := io.ReadFull(to.Conn, b[:length])
+		to.remain -= n
+		return n, err
+	}
+
+	if to.firstRequest {
+		to.firstRequest = false
+		payload, err := to.readClientHello()
+		if err != nil {
+			return 0, err
+		}
+		n := copy(b, payload)
+		if n < len(payload) {
+			to.buf = payload
+			to.offset = n
+		}
+		return n, nil
+	}
+
+	for {
+		header := make([]byte, 5)
+		_, err := io.ReadFull(to.Conn, header)
+		if err != nil {
+			return 0, err
+		}
+		length := int(binary.BigEndian.Uint16(header[3:]))
+		switch header[0] {
+		case recordTypeApplicationData:
+		case recordTypeChangeCipherSpec, recordTypeHandshake:
+			// simple-obfs clients send a fake ChangeCipherSpec and Finished before data
+			_, err = io.CopyN(io.Discard, to.Conn, int64(length))
+			if err != nil {
+				return 0, err
+			}
+			continue
+		default:
+			return 0, E.New("bad obfs record type: ", header[0])
+		}
+		if length > len(b) {
+			n, err := to.Conn.Read(b)
+			if err != nil {
+				return n, err
+			}
+			to.remain = length - n
+			return n, nil
+		}
+		return io.ReadFull(to.Conn, b[:length])
+	}
+}
+
+func (to *TLSObfsServer) readClientHello() ([]byte, error) {
+	header := make([]byte, 5)
+	_, err := io.ReadFull(to.Conn, header)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != recordTypeHandshake {
+		return nil, E.New("bad obfs client hello: unexpected record type ", header[0])
+	}
+	record := make([]byte, binary.BigEndian.Uint16(header[3:]))
+	_, err = io.ReadFull(to.Conn, record)
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(record)
+	// handshake type, length, version and random
+	if len(record) < 39 || record[0] != 1 {
+		return nil, E.New("bad obfs client hello")
+	}
+	reader.Seek(38, io.SeekStart)
+	sessionIDLen, _ := reader.ReadByte()
+	if sessionIDLen != 32 {
+		return nil, E.New("bad obfs client hello: unexpected session id length ", sessionIDLen)
+	}
+	to.sessionID = make([]byte, sessionIDLen)
+	_, err = io.ReadFull(reader, to.sessionID)
+	if err != nil {
+		return nil, E.Cause(err, "bad obfs client hello")
+	}
+	var cipherSuitesLen uint16
+	err = binary.Read(reader, binary.BigEndian, &cipherSuitesLen)
+	if err != nil {
+		return nil, E.Cause(err, "bad obfs client hello")
+	}
+	reader.Seek(int64(cipherSuitesLen), io.SeekCurrent)
+	compressionLen, err := reader.ReadByte()
+	if err != nil {
+		return nil, E.Cause(err, "bad obfs client hello")
+	}
+	reader.Seek(int64(compressionLen)+2, io.SeekCurrent)
+	for reader.Len() >= 4 {
+		var extensionType, extensionLen uint16
+		binary.Read(reader, binary.BigEndian, &extensionType)
+		binary.Read(reader, binary.BigEndian, &extensionLen)
+		if int(extensionLen) > reader.Len() {
+			break
+		}
+		if extensionType == extensionSessionTicket {
+			payload := make([]byte, extensionLen)
+			reader.Read(payload)
+			return payload, nil
+		}
+		reader.Seek(int64(extensionLen), io.SeekCurrent)
+	}
+	return nil, E.New("bad obfs client hello: missing session ticket")
+}
+
+func (to *TLSObfsServer) Write(b []byte) (int, error) {
+	length := len(b)
+	for i := 0; i < length; i += chunkSize {
+		end := i + chunkSize
+		if end > length {
+			end = length
+		}
+
+		n, err := to.write(b[i:end])
+		if err != nil {
+			return n, err
+		}
+	}
+	return length, nil
+}
+
+func (to *TLSObfsServer) write(b []byte) (int, error) {
+	if to.firstResponse {
+		helloMsg := makeServerHelloMsg(b, to.sessionID)
+		_, err := to.Conn.Write(helloMsg)
+		to.firstResponse = false
+		return len(b), err
+	}
+
+	buf := B.NewSize(5 + len(b))
+	defer buf.Release()
+	buf.Write([]byte{recordTypeApplicationData, 0x03, 0x03})
+	binary.Write(buf, binary.BigEndian, uint16(len(b)))
+	buf.Write(b)
+	_, err := to.Conn.Write(buf.Bytes())
+	return len(b), err
+}
+
+// NewTLSObfsServer return a TLSObfsServer
+func NewTLSObfsServer(conn net.Conn) net.Conn {
+	return &TLSObfsServer{
+		Conn:          conn,
+		firstRequest:  true,
+		firstResponse: true,
+	}
+}
+
+func makeServerHelloMsg(data []byte, sessionID []byte) []byte {
+	random := make([]byte, 28)
+	rand.Read(random)
+
+	buf := &bytes.Buffer{}
+
+	// handshake, TLS 1.0 version, length
+	buf.WriteByte(recordTypeHandshake)
+	buf.Write([]byte{0x03, 0x01})
+	binary.Write(buf, binary.BigEndian, uint16(91))
+
+	// serverHello, length, TLS 1.2 version
+	buf.WriteByte(2)
+	buf.WriteByte(0)
+	binary.Write(buf, binary.BigEndian, uint16(87))
+	buf.Write([]byte{0x03, 0x03})
+
+	// random with timestamp, sid len, sid
+	binary.Write(buf, binary.BigEndian, uint32(time.Now().Unix()))
+	buf.Write(random)
+	buf.WriteByte(32)
+	buf.Write(sessionID)
+
+	// cipher suite, compression
+	buf.Write([]byte{0xcc, 0xa8, 0x00})
+
+	// extensions: renegotiation info, extended master secret, ec_point
+	binary.Write(buf, binary.BigEndian, uint16(15))
+	buf.Write([]byte{0xff, 0x01, 0x00, 0x01, 0x00})
+	buf.Write([]byte{0x00, 0x17, 0x00, 0x00})
+	buf.Write([]byte{0x00, 0x0b, 0x00, 0x02, 0x01, 0x00})
+
+	// change cipher spec
+	buf.Write([]byte{recordTypeChangeCipherSpec, 0x03, 0x03, 0x00, 0x01, 0x01})
+
+	// encrypted handshake carrying the first data
+	buf.Write([]byte{recordTypeHandshake, 0x03, 0x03})
+	binary.Write(buf, binary.BigEndian, uint16(len(data)))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
@@ -3,19 +3,26 @@ package sip003
 import (
 	"context"
 	"net"
+	"os"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/transport/simple-obfs"
+	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 )
 
-var _ Plugin = (*ObfsLocal)(nil)
+var (
+	_ Plugin                       = (*ObfsLocal)(nil)
+	_ adapter.V2RayServerTransport = (*ObfsServer)(nil)
+)
 
 func init() {
 	RegisterPlugin("obfs-local", newObfsLocal)
+	RegisterServerPlugin("obfs-server", newObfsServer)
 }
 
 func newObfsLocal(ctx context.Context, pluginOpts Args, router adapter.Router, dialer N.Dialer, serverAddr M.Socksaddr) (Plugin, error) {
@@ -60,3 +67,67 @@ func (o *ObfsLocal) DialContext(ctx context.Context) (net.Conn, error) {
 		return obfs.NewTLSObfs(conn, o.host), nil
 	}
 }
+
+func newObfsServer(ctx context.Context, logger log.ContextLogger, pluginOpts Args, handler adapter.V2RayServerTransportHandler) (adapter.V2RayServerTransport, error) {
+	plugin := &ObfsServer{
+		ctx:     ctx,
+		handler: handler,
+	}
+	mode := "http"
+	if obfsMode, loaded := pluginOpts.Get("obfs"); loaded {
+		mode = obfsMode
+	}
+	switch mode {
+	case "http":
+	case "tls":
+		plugin.tls = true
+	default:
+		return nil, E.New("unknown obfs mode ", mode)
+	}
+	return plugin, nil
+}
+
+type ObfsServer struct {
+	ctx      context.Context
+	handler  adapter.V2RayServerTransportHandler
+	tls      bool
+	listener net.Listener
+}
+
+func (o *ObfsServer) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (o *ObfsServer) Serve(listener net.Listener) error {
+	o.listener = listener
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go o.handleConn(conn)
+	}
+}
+
+func (o *ObfsServer) handleConn(conn net.Conn) {
+	if !o.tls {
+		conn = obfs.NewHTTPObfsServer(conn)
+	} else {
+		conn = obfs.NewTLSObfsServer(conn)
+	}
+	err := o.handler.NewConnection(o.ctx, conn, M.Metadata{
+		Source: M.SocksaddrFromNet(conn.RemoteAddr()),
+	})
+	if err != nil {
+		conn.Close()
+		o.handler.NewError(o.ctx, E.Cause(err, "process connection from ", conn.RemoteAddr()))
+	}
+}
+
+func (o *ObfsServer) ServePacket(listener net.PacketConn) error {
+	return os.ErrInvalid
+}
+
+func (o *ObfsServer) Close() error {
+	return common.Close(o.listener)
+}
@@ -0,0 +1,32 @@
+package sip003
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type ServerPluginConstructor func(ctx context.Context, logger log.ContextLogger, pluginArgs Args, handler adapter.V2RayServerTransportHandler) (adapter.V2RayServerTransport, error)
+
+var serverPlugins map[string]ServerPluginConstructor
+
+func RegisterServerPlugin(name string, constructor ServerPluginConstructor) {
+	if serverPlugins == nil {
+		serverPlugins = make(map[string]ServerPluginConstructor)
+	}
+	serverPlugins[name] = constructor
+}
+
+func CreateServerPlugin(ctx context.Context, logger log.ContextLogger, name string, pluginArgs string, handler adapter.V2RayServerTransportHandler) (adapter.V2RayServerTransport, error) {
+	pluginOptions, err := ParsePluginOptions(pluginArgs)
+	if err != nil {
+		return nil, E.Cause(err, "parse plugin_opts")
+	}
+	constructor, loaded := serverPlugins[name]
+	if !loaded {
+		return nil, E.New("server plugin not found: ", name)
+	}
+	return constructor(ctx, logger, pluginOptions, handler)
+}
@@ -2,15 +2,22 @@ package sip003
 
 import (
 	"context"
+	"encoding/binary"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/tls"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/transport/v2ray"
 	"github.com/sagernet/sing-vmess"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
@@ -18,6 +25,7 @@ import (
 
 func init() {
 	RegisterPlugin("v2ray-plugin", newV2RayPlugin)
+	RegisterServerPlugin("v2ray-plugin", newV2RayServerPlugin)
 }
 
 func newV2RayPlugin(ctx context.Context, pluginOpts Args, router adapter.Router, dialer N.Dialer, serverAddr M.Socksaddr) (Plugin, error) {
@@ -115,3 +123,145 @@ func (w *v2rayMuxWrapper) DialContext(ctx context.Context) (net.Conn, error) {
 	}
 	return vmess.NewMuxConnWrapper(conn, vmess.MuxDestination), nil
 }
+
+func newV2RayServerPlugin(ctx context.Context, logger log.ContextLogger, pluginOpts Args, handler adapter.V2RayServerTransportHandler) (adapter.V2RayServerTransport, error) {
+	var tlsOptions option.InboundTLSOptions
+	if _, loaded := pluginOpts.Get("tls"); loaded {
+		tlsOptions.Enabled = true
+	}
+
+	mode := "websocket"
+	if modeOpt, loaded := pluginOpts.Get("mode"); loaded {
+		mode = modeOpt
+	}
+
+	host := "cloudfront.com"
+	path := "/"
+
+	if hostOpt, loaded := pluginOpts.Get("host"); loaded {
+		host = hostOpt
+	}
+	tlsOptions.ServerName = host
+	if pathOpt, loaded := pluginOpts.Get("path"); loaded {
+		path = pathOpt
+	}
+
+	var transportOptions option.V2RayTransportOptions
+	switch mode {
+	case "websocket":
+		transportOptions = option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeWebsocket,
+			WebsocketOptions: option.V2RayWebsocketOptions{
+				Path: path,
+			},
+		}
+		// mux is a client only option in v2ray-plugin, the server accepts both
+		handler = &v2rayMuxHandler{handler}
+	case "quic":
+		transportOptions = option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeQUIC,
+		}
+		// QUIC always runs over TLS
+		tlsOptions.Enabled = true
+	default:
+		return nil, E.New("v2ray-plugin: unknown mode: " + mode)
+	}
+
+	var (
+		tlsConfig tls.ServerConfig
+		err       error
+	)
+	if tlsOptions.Enabled {
+		// same defaults as v2ray-plugin, which expects certificates issued by acme.sh
+		if certPath, loaded := pluginOpts.Get("cert"); loaded {
+			tlsOptions.CertificatePath = certPath
+		} else if homeDir, hErr := os.UserHomeDir(); hErr == nil {
+			tlsOptions.CertificatePath = filepath.Join(homeDir, ".acme.sh", host, "fullchain.cer")
+		}
+		if keyPath, loaded := pluginOpts.Get("key"); loaded {
+			tlsOptions.KeyPath = keyPath
+		} else if homeDir, hErr := os.UserHomeDir(); hErr == nil {
+			tlsOptions.KeyPath = filepath.Join(homeDir, ".acme.sh", host, host+".key")
+		}
+		tlsConfig, err = tls.NewServer(ctx, logger, tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transport, err := v2ray.NewServerTransport(ctx, transportOptions, tlsConfig, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v2rayServerPlugin{transport, tlsConfig}, nil
+}
+
+var _ adapter.V2RayServerTransport = (*v2rayServerPlugin)(nil)
+
+type v2rayServerPlugin struct {
+	adapter.V2RayServerTransport
+	tlsConfig tls.ServerConfig
+}
+
+func (p *v2rayServerPlugin) Start() error {
+	return common.Start(p.tlsConfig)
+}
+
+func (p *v2rayServerPlugin) Close() error {
+	return common.Close(p.V2RayServerTransport, p.tlsConfig)
+}
+
+var _ adapter.V2RayServerTransportHandler = (*v2rayMuxHandler)(nil)
+
+type v2rayMuxHandler struct {
+	adapter.V2RayServerTransportHandler
+}
+
+func (h *v2rayMuxHandler) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	header := buf.NewSize(v2rayMuxHeaderLen)
+	_, err := header.ReadFullFrom(conn, v2rayMuxHeaderLen)
+	if err != nil {
+		header.Release()
+		conn.Close()
+		return E.Cause(err, "read request")
+	}
+	isMux := isV2RayMuxHeader(header.Bytes())
+	conn = bufio.NewCachedConn(conn, header)
+	if !isMux {
+		return h.V2RayServerTransportHandler.NewConnection(ctx, conn, metadata)
+	}
+	defer conn.Close()
+	return vmess.HandleMuxConnection(ctx, conn, &v2rayMuxStreamHandler{h.V2RayServerTransportHandler, metadata.Source})
+}
+
+// v2rayMuxHeaderLen covers the frame length, session id, status, option and network of a Mux.Cool frame.
+const v2rayMuxHeaderLen = 7
+
+// isV2RayMuxHeader reports whether the connection starts with a Mux.Cool new session frame,
+// shadowsocks requests start with a random salt which rarely looks like one.
+func isV2RayMuxHeader(header []byte) bool {
+	length := binary.BigEndian.Uint16(header)
+	// network, port, address type and at most a 255 bytes domain
+	if length < 5 || length > 4+1+2+1+1+255 {
+		return false
+	}
+	if header[4] != vmess.StatusNew || header[5] > vmess.OptionData {
+		return false
+	}
+	return header[6] == vmess.NetworkTCP || header[6] == vmess.NetworkUDP
+}
+
+type v2rayMuxStreamHandler struct {
+	adapter.V2RayServerTransportHandler
+	source M.Socksaddr
+}
+
+func (h *v2rayMuxStreamHandler) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	// the mux destination is a placeholder, shadowsocks carries the real one
+	return h.V2RayServerTransportHandler.NewConnection(ctx, conn, M.Metadata{Source: h.source})
+}
+
+func (h *v2rayMuxStreamHandler) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata M.Metadata) error {
+	return E.New("v2ray-plugin: UDP mux is not supported")
+}
@@ -0,0 +1,47 @@
+package sip003
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-vmess"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV2RayMuxHeader(t *testing.T) {
+	t.Parallel()
+	for _, destination := range []M.Socksaddr{
+		vmess.MuxDestination,
+		M.ParseSocksaddr("1.1.1.1:53"),
+		M.ParseSocksaddr("[2001:db8::1]:443"),
+	} {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			vmess.NewMuxConnWrapper(clientConn, destination).Write([]byte("payload"))
+		}()
+		header := make([]byte, v2rayMuxHeaderLen)
+		_, err := io.ReadFull(serverConn, header)
+		require.NoError(t, err)
+		require.True(t, isV2RayMuxHeader(header), destination)
+		clientConn.Close()
+		serverConn.Close()
+	}
+}
+
+func TestV2RayMuxHeaderShadowsocks(t *testing.T) {
+	t.Parallel()
+	header := make([]byte, v2rayMuxHeaderLen)
+	var detected int
+	for i := 0; i < 10000; i++ {
+		_, err := rand.Read(header)
+		require.NoError(t, err)
+		if isV2RayMuxHeader(header) {
+			detected++
+		}
+	}
+	require.Zero(t, detected)
+}
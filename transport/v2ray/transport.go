@@ -9,6 +9,8 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/transport/v2rayhttp"
 	"github.com/sagernet/sing-box/transport/v2rayhttpupgrade"
+	"github.com/sagernet/sing-box/transport/v2raykcp"
+	"github.com/sagernet/sing-box/transport/v2raysplithttp"
 	"github.com/sagernet/sing-box/transport/v2raywebsocket"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
@@ -38,11 +40,25 @@ func NewServerTransport(ctx context.Context, options option.V2RayTransportOption
 		return NewGRPCServer(ctx, options.GRPCOptions, tlsConfig, handler)
 	case C.V2RayTransportTypeHTTPUpgrade:
 		return v2rayhttpupgrade.NewServer(ctx, options.HTTPUpgradeOptions, tlsConfig, handler)
+	case C.V2RayTransportTypeKCP:
+		return v2raykcp.NewServer(ctx, options.KCPOptions, tlsConfig, handler)
+	case C.V2RayTransportTypeSplitHTTP:
+		return v2raysplithttp.NewServer(ctx, options.SplitHTTPOptions, tlsConfig, handler)
 	default:
 		return nil, E.New("unknown transport type: " + options.Type)
 	}
 }
 
+// SharedSession reports whether connections of the transport may share an underlying connection.
+func SharedSession(options option.V2RayTransportOptions) bool {
+	switch options.Type {
+	case C.V2RayTransportTypeHTTP, C.V2RayTransportTypeGRPC, C.V2RayTransportTypeQUIC, C.V2RayTransportTypeSplitHTTP:
+		return true
+	default:
+		return false
+	}
+}
+
 func NewClientTransport(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayTransportOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
 	if options.Type == "" {
 		return nil, nil
@@ -61,6 +77,10 @@ func NewClientTransport(ctx context.Context, dialer N.Dialer, serverAddr M.Socks
 		return NewQUICClient(ctx, dialer, serverAddr, options.QUICOptions, tlsConfig)
 	case C.V2RayTransportTypeHTTPUpgrade:
 		return v2rayhttpupgrade.NewClient(ctx, dialer, serverAddr, options.HTTPUpgradeOptions, tlsConfig)
+	case C.V2RayTransportTypeKCP:
+		return v2raykcp.NewClient(ctx, dialer, serverAddr, options.KCPOptions, tlsConfig)
+	case C.V2RayTransportTypeSplitHTTP:
+		return v2raysplithttp.NewClient(ctx, dialer, serverAddr, options.SplitHTTPOptions, tlsConfig)
 	default:
 		return nil, E.New("unknown transport type: " + options.Type)
 	}
@@ -0,0 +1,92 @@
+package v2raykcp
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+var globalConv = rand.Uint32()
+
+type Client struct {
+	ctx        context.Context
+	dialer     N.Dialer
+	serverAddr M.Socksaddr
+	tlsConfig  tls.Config
+	config     *kcpConfig
+	headerType string
+	seed       string
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayKCPOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	_, err = newPacketCodec(options.HeaderType, options.Seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		ctx:        ctx,
+		dialer:     dialer,
+		serverAddr: serverAddr,
+		tlsConfig:  tlsConfig,
+		config:     config,
+		headerType: options.HeaderType,
+		seed:       options.Seed,
+	}, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	codec, err := newPacketCodec(c.headerType, c.seed)
+	if err != nil {
+		return nil, err
+	}
+	udpConn, err := c.dialer.DialContext(ctx, N.NetworkUDP, c.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	conv := uint16(atomic.AddUint32(&globalConv, 1))
+	kcpConn := newConn(conv, c.config, codec, udpConn.LocalAddr(), udpConn.RemoteAddr(), func(b []byte) error {
+		_, wErr := udpConn.Write(b)
+		return wErr
+	}, func() {
+		udpConn.Close()
+	})
+	go c.loopInput(udpConn, codec, kcpConn)
+	if c.tlsConfig == nil {
+		return kcpConn, nil
+	}
+	tlsConn, err := tls.ClientHandshake(ctx, kcpConn, c.tlsConfig)
+	if err != nil {
+		kcpConn.terminate()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (c *Client) loopInput(udpConn net.Conn, codec *packetCodec, kcpConn *Conn) {
+	buffer := make([]byte, buf.UDPBufferSize)
+	for {
+		n, err := udpConn.Read(buffer)
+		if err != nil {
+			kcpConn.terminate()
+			return
+		}
+		segments := codec.decode(buffer[:n])
+		if len(segments) > 0 {
+			kcpConn.input(segments)
+		}
+	}
+}
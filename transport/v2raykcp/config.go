@@ -0,0 +1,75 @@
+package v2raykcp
+
+import (
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type kcpConfig struct {
+	mtu              uint32
+	tti              uint32
+	uplinkCapacity   uint32
+	downlinkCapacity uint32
+	congestion       bool
+	readBufferSize   uint32
+	writeBufferSize  uint32
+}
+
+func newConfig(options option.V2RayKCPOptions) (*kcpConfig, error) {
+	config := &kcpConfig{
+		mtu:              options.MTU,
+		tti:              options.TTI,
+		uplinkCapacity:   options.UplinkCapacity,
+		downlinkCapacity: options.DownlinkCapacity,
+		congestion:       options.Congestion,
+		readBufferSize:   options.ReadBufferSize,
+		writeBufferSize:  options.WriteBufferSize,
+	}
+	if config.mtu == 0 {
+		config.mtu = 1350
+	} else if config.mtu < 576 || config.mtu > 1460 {
+		return nil, E.New("invalid mtu: ", config.mtu)
+	}
+	if config.tti == 0 {
+		config.tti = 50
+	} else if config.tti < 10 || config.tti > 100 {
+		return nil, E.New("invalid tti: ", config.tti)
+	}
+	if config.uplinkCapacity == 0 {
+		config.uplinkCapacity = 5
+	}
+	if config.downlinkCapacity == 0 {
+		config.downlinkCapacity = 20
+	}
+	if config.readBufferSize == 0 {
+		config.readBufferSize = 2
+	}
+	if config.writeBufferSize == 0 {
+		config.writeBufferSize = 2
+	}
+	return config, nil
+}
+
+func (c *kcpConfig) sendingInFlightSize() uint32 {
+	size := c.uplinkCapacity * 1024 * 1024 / c.mtu / (1000 / c.tti)
+	if size < 8 {
+		size = 8
+	}
+	return size
+}
+
+func (c *kcpConfig) sendingBufferSize() uint32 {
+	return c.writeBufferSize * 1024 * 1024 / c.mtu
+}
+
+func (c *kcpConfig) receivingInFlightSize() uint32 {
+	size := c.downlinkCapacity * 1024 * 1024 / c.mtu / (1000 / c.tti)
+	if size < 8 {
+		size = 8
+	}
+	return size
+}
+
+func (c *kcpConfig) receivingBufferSize() uint32 {
+	return c.readBufferSize * 1024 * 1024 / c.mtu
+}
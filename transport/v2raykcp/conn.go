@@ -0,0 +1,680 @@
+package v2raykcp
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type connState int
+
+const (
+	stateActive connState = iota
+	stateReadyToClose
+	statePeerClosed
+	stateTerminating
+	statePeerTerminating
+	stateTerminated
+)
+
+type ackEntry struct {
+	number    uint32
+	timestamp uint32
+	nextFlush uint32
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+// Conn is a mKCP connection, compatible with the v2ray-core implementation.
+type Conn struct {
+	conv        uint16
+	config      *kcpConfig
+	codec       *packetCodec
+	writePacket func(b []byte) error
+	onClose     func()
+	localAddr   net.Addr
+	remoteAddr  net.Addr
+	startTime   time.Time
+	mss         int
+
+	access           sync.Mutex
+	state            connState
+	stateBeginTime   uint32
+	lastIncomingTime uint32
+	lastPingTime     uint32
+
+	srtt         uint32
+	rttVariation uint32
+	rto          uint32
+	rttUpdated   uint32
+
+	sendingWindow       []*dataSegment
+	sendingNextNumber   uint32
+	firstUnacknowledged uint32
+	remoteNextNumber    uint32
+	controlWindow       uint32
+	totalInFlightSize   uint32
+	firstUnackUpdated   bool
+	writeClosed         bool
+
+	receivingWindow     []*dataSegment
+	receivingNextNumber uint32
+	readLeftover        []byte
+	readClosed          bool
+	acks                []ackEntry
+	ackDirty            bool
+
+	readNotify    chan struct{}
+	writeNotify   chan struct{}
+	done          chan struct{}
+	readDeadline  deadline
+	writeDeadline deadline
+}
+
+func newConn(conv uint16, config *kcpConfig, codec *packetCodec, localAddr net.Addr, remoteAddr net.Addr, writePacket func(b []byte) error, onClose func()) *Conn {
+	receivingWindowSize := config.receivingInFlightSize()
+	if bufferSize := config.receivingBufferSize(); receivingWindowSize > bufferSize {
+		receivingWindowSize = bufferSize
+	}
+	conn := &Conn{
+		conv:             conv,
+		config:           config,
+		codec:            codec,
+		writePacket:      writePacket,
+		onClose:          onClose,
+		localAddr:        localAddr,
+		remoteAddr:       remoteAddr,
+		startTime:        time.Now(),
+		mss:              int(config.mtu) - codec.overhead() - dataSegmentOverhead,
+		rto:              100,
+		remoteNextNumber: 32,
+		controlWindow:    config.sendingInFlightSize(),
+		receivingWindow:  make([]*dataSegment, receivingWindowSize),
+		readNotify:       make(chan struct{}, 1),
+		writeNotify:      make(chan struct{}, 1),
+		done:             make(chan struct{}),
+		readDeadline:     makeDeadline(),
+		writeDeadline:    makeDeadline(),
+	}
+	go conn.updateLoop()
+	return conn
+}
+
+func (c *Conn) elapsed() uint32 {
+	return uint32(time.Since(c.startTime) / time.Millisecond)
+}
+
+func (c *Conn) updateLoop() {
+	ticker := time.NewTicker(time.Duration(c.config.tti) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		c.access.Lock()
+		switch c.state {
+		case stateReadyToClose, stateTerminating, stateTerminated:
+			c.access.Unlock()
+			return 0, io.EOF
+		}
+		n := c.readLocked(b)
+		if n > 0 {
+			c.access.Unlock()
+			return n, nil
+		}
+		state := c.state
+		c.access.Unlock()
+		if state == statePeerTerminating {
+			return 0, io.EOF
+		}
+		select {
+		case <-c.readNotify:
+		case <-c.readDeadline.wait():
+			return 0, os.ErrDeadlineExceeded
+		case <-c.done:
+		}
+	}
+}
+
+func (c *Conn) readLocked(b []byte) int {
+	var n int
+	for n < len(b) {
+		if len(c.readLeftover) > 0 {
+			copied := copy(b[n:], c.readLeftover)
+			c.readLeftover = c.readLeftover[copied:]
+			n += copied
+			continue
+		}
+		windowSize := uint32(len(c.receivingWindow))
+		slot := c.receivingNextNumber % windowSize
+		seg := c.receivingWindow[slot]
+		if seg == nil {
+			break
+		}
+		c.receivingWindow[slot] = nil
+		c.receivingNextNumber++
+		c.ackDirty = true
+		c.readLeftover = seg.payload
+	}
+	return n
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	var n int
+	for {
+		c.access.Lock()
+		if c.state != stateActive {
+			c.access.Unlock()
+			return n, io.ErrClosedPipe
+		}
+		bufferSize := int(c.config.sendingBufferSize())
+		for n < len(b) && len(c.sendingWindow) < bufferSize {
+			size := len(b) - n
+			if size > c.mss {
+				size = c.mss
+			}
+			c.sendingWindow = append(c.sendingWindow, &dataSegment{
+				conv:    c.conv,
+				number:  c.sendingNextNumber,
+				payload: append([]byte(nil), b[n:n+size]...),
+			})
+			c.sendingNextNumber++
+			n += size
+		}
+		c.access.Unlock()
+		if n == len(b) {
+			return n, nil
+		}
+		select {
+		case <-c.writeNotify:
+		case <-c.writeDeadline.wait():
+			return n, os.ErrDeadlineExceeded
+		case <-c.done:
+		}
+	}
+}
+
+func (c *Conn) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	switch c.state {
+	case stateReadyToClose, stateTerminating, stateTerminated:
+		return net.ErrClosed
+	case stateActive:
+		c.setState(stateReadyToClose)
+	case statePeerClosed:
+		c.setState(stateTerminating)
+	case statePeerTerminating:
+		c.setState(stateTerminated)
+	}
+	return nil
+}
+
+func (c *Conn) terminate() {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.state != stateTerminated {
+		c.setState(stateTerminated)
+	}
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) setState(state connState) {
+	c.state = state
+	c.stateBeginTime = c.elapsed()
+	switch state {
+	case stateReadyToClose:
+		c.closeRead()
+	case statePeerClosed, statePeerTerminating:
+		c.closeWrite()
+	case stateTerminating:
+		c.closeRead()
+		c.closeWrite()
+	case stateTerminated:
+		c.closeRead()
+		c.closeWrite()
+		close(c.done)
+		if c.onClose != nil {
+			go c.onClose()
+		}
+	}
+	signal(c.readNotify)
+	signal(c.writeNotify)
+}
+
+func (c *Conn) closeRead() {
+	if c.readClosed {
+		return
+	}
+	c.readClosed = true
+	for i := range c.receivingWindow {
+		c.receivingWindow[i] = nil
+	}
+	c.readLeftover = nil
+}
+
+func (c *Conn) closeWrite() {
+	if c.writeClosed {
+		return
+	}
+	c.writeClosed = true
+	c.sendingWindow = nil
+}
+
+func (c *Conn) output(seg segment) {
+	_ = c.writePacket(c.codec.encode(seg))
+}
+
+func (c *Conn) input(segments []segment) {
+	current := c.elapsed()
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.state == stateTerminated {
+		return
+	}
+	c.lastIncomingTime = current
+	for _, seg := range segments {
+		if seg.conversation() != c.conv {
+			break
+		}
+		switch seg := seg.(type) {
+		case *dataSegment:
+			c.handleOption(seg.option)
+			c.processDataSegment(seg)
+			signal(c.readNotify)
+		case *ackSegment:
+			c.handleOption(seg.option)
+			c.processAckSegment(current, seg)
+			signal(c.writeNotify)
+		case *cmdOnlySegment:
+			c.handleOption(seg.option)
+			if seg.cmd == commandTerminate {
+				switch c.state {
+				case stateActive, statePeerClosed:
+					c.setState(statePeerTerminating)
+				case stateReadyToClose:
+					c.setState(stateTerminating)
+				case stateTerminating:
+					c.setState(stateTerminated)
+				}
+			}
+			if seg.option == segmentOptionClose || seg.cmd == commandTerminate {
+				signal(c.readNotify)
+				signal(c.writeNotify)
+			}
+			c.processReceivingNext(seg.receivingNext)
+			c.clearAcks(seg.sendingNext)
+			c.updatePeerRTO(seg.peerRTO, current)
+		}
+		if c.state == stateTerminated {
+			return
+		}
+	}
+}
+
+func (c *Conn) handleOption(option segmentOption) {
+	if option&segmentOptionClose != segmentOptionClose {
+		return
+	}
+	switch c.state {
+	case stateReadyToClose:
+		c.setState(stateTerminating)
+	case stateActive:
+		c.setState(statePeerClosed)
+	}
+}
+
+func (c *Conn) processDataSegment(seg *dataSegment) {
+	windowSize := uint32(len(c.receivingWindow))
+	if seg.number-c.receivingNextNumber >= windowSize {
+		return
+	}
+	c.clearAcks(seg.sendingNext)
+	c.addAck(seg.number, seg.timestamp)
+	if c.readClosed {
+		return
+	}
+	slot := seg.number % windowSize
+	if c.receivingWindow[slot] == nil {
+		c.receivingWindow[slot] = seg
+	}
+}
+
+func (c *Conn) addAck(number uint32, timestamp uint32) {
+	for i := range c.acks {
+		if c.acks[i].number == number {
+			c.acks[i].timestamp = timestamp
+			c.acks[i].nextFlush = 0
+			c.ackDirty = true
+			return
+		}
+	}
+	c.acks = append(c.acks, ackEntry{number: number, timestamp: timestamp})
+	c.ackDirty = true
+}
+
+func (c *Conn) clearAcks(sendingNext uint32) {
+	var removed bool
+	acks := c.acks[:0]
+	for _, entry := range c.acks {
+		if entry.number < sendingNext {
+			removed = true
+			continue
+		}
+		acks = append(acks, entry)
+	}
+	c.acks = acks
+	if removed {
+		c.ackDirty = true
+	}
+}
+
+func (c *Conn) processAckSegment(current uint32, seg *ackSegment) {
+	if c.writeClosed {
+		return
+	}
+	if c.remoteNextNumber < seg.receivingWindow {
+		c.remoteNextNumber = seg.receivingWindow
+	}
+	c.processReceivingNext(seg.receivingNext)
+	if len(seg.numbers) == 0 {
+		return
+	}
+	var maxAck uint32
+	var maxAckRemoved bool
+	for _, number := range seg.numbers {
+		removed := c.removeSegment(number)
+		if maxAck < number {
+			maxAck = number
+			maxAckRemoved = removed
+		}
+	}
+	c.updateFirstUnacknowledged()
+	if maxAckRemoved {
+		fastAck := c.rto / 3
+		for _, pending := range c.sendingWindow {
+			if pending.number >= maxAck {
+				break
+			}
+			if pending.transmit > 0 && pending.timeout > fastAck {
+				pending.timeout -= fastAck
+			}
+		}
+		if current-seg.timestamp < 10000 {
+			c.updateRTT(current-seg.timestamp, current)
+		}
+	}
+}
+
+func (c *Conn) removeSegment(number uint32) bool {
+	for i, seg := range c.sendingWindow {
+		if seg.number == number {
+			c.sendingWindow = append(c.sendingWindow[:i], c.sendingWindow[i+1:]...)
+			return true
+		} else if seg.number > number {
+			break
+		}
+	}
+	return false
+}
+
+func (c *Conn) processReceivingNext(receivingNext uint32) {
+	var index int
+	for index < len(c.sendingWindow) && c.sendingWindow[index].number < receivingNext {
+		index++
+	}
+	if index > 0 {
+		c.sendingWindow = c.sendingWindow[index:]
+	}
+	c.updateFirstUnacknowledged()
+}
+
+func (c *Conn) updateFirstUnacknowledged() {
+	firstUnacknowledged := c.sendingNextNumber
+	if len(c.sendingWindow) > 0 {
+		firstUnacknowledged = c.sendingWindow[0].number
+	}
+	if c.firstUnacknowledged != firstUnacknowledged {
+		c.firstUnacknowledged = firstUnacknowledged
+		c.firstUnackUpdated = true
+		signal(c.writeNotify)
+	}
+}
+
+func (c *Conn) updateRTT(rtt uint32, current uint32) {
+	if rtt > 0x7FFFFFFF {
+		return
+	}
+	if c.srtt == 0 {
+		c.srtt = rtt
+		c.rttVariation = rtt / 2
+	} else {
+		delta := rtt - c.srtt
+		if c.srtt > rtt {
+			delta = c.srtt - rtt
+		}
+		c.rttVariation = (3*c.rttVariation + delta) / 4
+		c.srtt = (7*c.srtt + rtt) / 8
+		if c.srtt < c.config.tti {
+			c.srtt = c.config.tti
+		}
+	}
+	var rto uint32
+	if c.config.tti < 4*c.rttVariation {
+		rto = c.srtt + 4*c.rttVariation
+	} else {
+		rto = c.srtt + c.rttVariation
+	}
+	if rto > 10000 {
+		rto = 10000
+	}
+	c.rto = rto * 5 / 4
+	c.rttUpdated = current
+}
+
+func (c *Conn) updatePeerRTO(rto uint32, current uint32) {
+	if current-c.rttUpdated < 3000 {
+		return
+	}
+	c.rttUpdated = current
+	c.rto = rto
+}
+
+func (c *Conn) flush() {
+	current := c.elapsed()
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.state == stateTerminated {
+		return
+	}
+	if c.state == stateActive && current-c.lastIncomingTime >= 30000 {
+		c.setState(stateReadyToClose)
+	}
+	if c.state == stateReadyToClose && len(c.sendingWindow) == 0 {
+		c.setState(stateTerminating)
+	}
+	if c.state == stateTerminating {
+		c.ping(current, commandTerminate)
+		if current-c.stateBeginTime > 8000 {
+			c.setState(stateTerminated)
+		}
+		return
+	}
+	if c.state == statePeerTerminating && current-c.stateBeginTime > 4000 {
+		c.setState(stateTerminating)
+	}
+	if c.state == stateReadyToClose && current-c.stateBeginTime > 15000 {
+		c.setState(stateTerminating)
+	}
+	c.flushAcks(current)
+	c.flushSending(current)
+	if current-c.lastPingTime >= 3000 {
+		c.ping(current, commandPing)
+	}
+}
+
+func (c *Conn) closeOption() segmentOption {
+	if c.state == stateReadyToClose {
+		return segmentOptionClose
+	}
+	return 0
+}
+
+func (c *Conn) ping(current uint32, cmd command) {
+	c.output(&cmdOnlySegment{
+		conv:          c.conv,
+		cmd:           cmd,
+		option:        c.closeOption(),
+		sendingNext:   c.firstUnacknowledged,
+		receivingNext: c.receivingNextNumber,
+		peerRTO:       c.rto,
+	})
+	c.lastPingTime = current
+}
+
+func (c *Conn) newAckSegment() *ackSegment {
+	return &ackSegment{
+		conv:            c.conv,
+		option:          c.closeOption(),
+		receivingWindow: c.receivingNextNumber + uint32(len(c.receivingWindow)),
+		receivingNext:   c.receivingNextNumber,
+	}
+}
+
+func (c *Conn) flushAcks(current uint32) {
+	seg := c.newAckSegment()
+	var candidates []uint32
+	for i := range c.acks {
+		entry := &c.acks[i]
+		if entry.nextFlush > current {
+			if len(candidates) < ackNumberLimit {
+				candidates = append(candidates, entry.number)
+			}
+			continue
+		}
+		seg.numbers = append(seg.numbers, entry.number)
+		seg.putTimestamp(entry.timestamp)
+		timeout := c.rto / 2
+		if timeout < 20 {
+			timeout = 20
+		}
+		entry.nextFlush = current + timeout
+		if seg.isFull() {
+			c.output(seg)
+			seg = c.newAckSegment()
+			c.ackDirty = false
+		}
+	}
+	if c.ackDirty || len(seg.numbers) > 0 {
+		for _, number := range candidates {
+			if seg.isFull() {
+				break
+			}
+			seg.numbers = append(seg.numbers, number)
+		}
+		c.output(seg)
+		c.ackDirty = false
+	}
+}
+
+func (c *Conn) flushSending(current uint32) {
+	if c.writeClosed {
+		return
+	}
+	inFlightSize := c.config.sendingInFlightSize()
+	cwnd := c.firstUnacknowledged + inFlightSize
+	if cwnd > c.remoteNextNumber {
+		cwnd = c.remoteNextNumber
+	}
+	if c.config.congestion && cwnd > c.firstUnacknowledged+c.controlWindow {
+		cwnd = c.firstUnacknowledged + c.controlWindow
+	}
+	if len(c.sendingWindow) > 0 {
+		var lost, inFlight uint32
+		option := c.closeOption()
+		for _, seg := range c.sendingWindow {
+			if seg.number >= cwnd {
+				break
+			}
+			if seg.transmit > 0 && current-seg.timeout >= 0x7FFFFFFF {
+				continue
+			}
+			if seg.transmit > 0 {
+				lost++
+			}
+			seg.timeout = current + c.rto
+			seg.timestamp = current
+			seg.sendingNext = c.firstUnacknowledged
+			seg.option = option
+			seg.transmit++
+			c.output(seg)
+			inFlight++
+		}
+		if c.config.congestion && inFlight > 0 && c.totalInFlightSize != 0 {
+			c.onPacketLoss(lost * 100 / c.totalInFlightSize)
+		}
+		c.totalInFlightSize = inFlight
+		c.firstUnackUpdated = false
+	}
+	if c.firstUnackUpdated {
+		c.ping(current, commandPing)
+		c.firstUnackUpdated = false
+	}
+}
+
+func (c *Conn) onPacketLoss(lossRate uint32) {
+	if c.srtt == 0 {
+		return
+	}
+	if lossRate >= 15 {
+		c.controlWindow = 3 * c.controlWindow / 4
+	} else if lossRate <= 5 {
+		c.controlWindow += c.controlWindow / 4
+	}
+	if c.controlWindow < 16 {
+		c.controlWindow = 16
+	}
+	if maxWindow := 2 * c.config.sendingInFlightSize(); c.controlWindow > maxWindow {
+		c.controlWindow = maxWindow
+	}
+}
+
+func signal(notify chan struct{}) {
+	select {
+	case notify <- struct{}{}:
+	default:
+	}
+}
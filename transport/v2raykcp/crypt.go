@@ -0,0 +1,148 @@
+package v2raykcp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+var _ cipher.AEAD = (*simpleAuthenticator)(nil)
+
+// simpleAuthenticator is the legacy FNV-1a based obfuscation used by mKCP when no seed is configured.
+type simpleAuthenticator struct{}
+
+func (*simpleAuthenticator) NonceSize() int {
+	return 0
+}
+
+func (*simpleAuthenticator) Overhead() int {
+	return 6
+}
+
+func (*simpleAuthenticator) Seal(dst, nonce, plain, extra []byte) []byte {
+	offset := len(dst)
+	dst = append(dst, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(dst[offset+4:], uint16(len(plain)))
+	dst = append(dst, plain...)
+	fnvHash := fnv.New32a()
+	common.Must1(fnvHash.Write(dst[offset+4:]))
+	fnvHash.Sum(dst[offset:offset])
+	for i := offset + 4; i < len(dst); i++ {
+		dst[i] ^= dst[i-4]
+	}
+	return dst
+}
+
+func (*simpleAuthenticator) Open(dst, nonce, cipherText, extra []byte) ([]byte, error) {
+	if len(cipherText) < 6 {
+		return nil, E.New("invalid auth")
+	}
+	text := make([]byte, len(cipherText))
+	copy(text, cipherText)
+	for i := len(text) - 1; i >= 4; i-- {
+		text[i] ^= text[i-4]
+	}
+	fnvHash := fnv.New32a()
+	common.Must1(fnvHash.Write(text[4:]))
+	if binary.BigEndian.Uint32(text) != fnvHash.Sum32() {
+		return nil, E.New("invalid auth")
+	}
+	length := binary.BigEndian.Uint16(text[4:])
+	if len(text)-6 != int(length) {
+		return nil, E.New("invalid auth")
+	}
+	return append(dst, text[6:]...), nil
+}
+
+func newSecurity(seed string) (cipher.AEAD, error) {
+	if seed == "" {
+		return &simpleAuthenticator{}, nil
+	}
+	hashedSeed := sha256.Sum256([]byte(seed))
+	block, err := aes.NewCipher(hashedSeed[:16])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// packetCodec converts between mKCP segments and UDP payloads.
+type packetCodec struct {
+	headerAccess sync.Mutex
+	header       packetHeader
+	security     cipher.AEAD
+}
+
+func newPacketCodec(headerType string, seed string) (*packetCodec, error) {
+	header, err := newPacketHeader(headerType)
+	if err != nil {
+		return nil, err
+	}
+	security, err := newSecurity(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &packetCodec{header: header, security: security}, nil
+}
+
+func (c *packetCodec) headerSize() int {
+	if c.header == nil {
+		return 0
+	}
+	return c.header.size()
+}
+
+func (c *packetCodec) overhead() int {
+	return c.headerSize() + c.security.NonceSize() + c.security.Overhead()
+}
+
+func (c *packetCodec) encode(seg segment) []byte {
+	payload := make([]byte, seg.byteSize())
+	seg.serialize(payload)
+	headerSize := c.headerSize()
+	nonceSize := c.security.NonceSize()
+	packet := make([]byte, headerSize+nonceSize, c.overhead()+len(payload))
+	if c.header != nil {
+		c.headerAccess.Lock()
+		c.header.serialize(packet)
+		c.headerAccess.Unlock()
+	}
+	nonce := packet[headerSize:]
+	if nonceSize > 0 {
+		common.Must1(rand.Read(nonce))
+	}
+	return c.security.Seal(packet, nonce, payload, nil)
+}
+
+func (c *packetCodec) decode(packet []byte) []segment {
+	headerSize := c.headerSize()
+	if len(packet) <= headerSize {
+		return nil
+	}
+	packet = packet[headerSize:]
+	nonceSize := c.security.NonceSize()
+	if len(packet) <= nonceSize+c.security.Overhead() {
+		return nil
+	}
+	payload, err := c.security.Open(nil, packet[:nonceSize], packet[nonceSize:], nil)
+	if err != nil {
+		return nil
+	}
+	var segments []segment
+	for len(payload) > 0 {
+		seg, remaining := readSegment(payload)
+		if seg == nil {
+			break
+		}
+		segments = append(segments, seg)
+		payload = remaining
+	}
+	return segments
+}
@@ -0,0 +1,54 @@
+package v2raykcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, headerType := range []string{headerTypeNone, headerTypeSRTP, headerTypeUTP, headerTypeWechatVideo, headerTypeDTLS, headerTypeWireGuard} {
+		for _, seed := range []string{"", "sing-box"} {
+			headerType, seed := headerType, seed
+			t.Run(headerType+"/seed="+seed, func(t *testing.T) {
+				t.Parallel()
+				encoder, err := newPacketCodec(headerType, seed)
+				require.NoError(t, err)
+				decoder, err := newPacketCodec(headerType, seed)
+				require.NoError(t, err)
+				sent := &dataSegment{
+					conv:        1,
+					timestamp:   2,
+					number:      3,
+					sendingNext: 4,
+					payload:     []byte("hello world"),
+				}
+				packet := encoder.encode(sent)
+				require.Len(t, packet, encoder.overhead()+sent.byteSize())
+				segments := decoder.decode(packet)
+				require.Len(t, segments, 1)
+				received, isData := segments[0].(*dataSegment)
+				require.True(t, isData)
+				require.Equal(t, sent.conv, received.conv)
+				require.Equal(t, sent.timestamp, received.timestamp)
+				require.Equal(t, sent.number, received.number)
+				require.Equal(t, sent.sendingNext, received.sendingNext)
+				require.Equal(t, sent.payload, received.payload)
+			})
+		}
+	}
+}
+
+func TestSimpleAuthenticatorRejectsCorrupted(t *testing.T) {
+	t.Parallel()
+	var authenticator simpleAuthenticator
+	sealed := authenticator.Seal([]byte{0xFF, 0xFF}, nil, []byte("hello"), nil)
+	require.Equal(t, []byte{0xFF, 0xFF}, sealed[:2])
+	opened, err := authenticator.Open(nil, nil, sealed[2:], nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), opened)
+	sealed[len(sealed)-1] ^= 1
+	_, err = authenticator.Open(nil, nil, sealed[2:], nil)
+	require.Error(t, err)
+}
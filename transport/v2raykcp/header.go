@@ -0,0 +1,134 @@
+package v2raykcp
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	headerTypeNone        = "none"
+	headerTypeSRTP        = "srtp"
+	headerTypeUTP         = "utp"
+	headerTypeWechatVideo = "wechat-video"
+	headerTypeDTLS        = "dtls"
+	headerTypeWireGuard   = "wireguard"
+)
+
+// packetHeader is the fake header prepended to every mKCP packet, see v2ray-core transport/internet/headers.
+type packetHeader interface {
+	size() int
+	serialize(b []byte)
+}
+
+func newPacketHeader(headerType string) (packetHeader, error) {
+	switch headerType {
+	case "", headerTypeNone:
+		return nil, nil
+	case headerTypeSRTP:
+		return &srtpHeader{header: 0xB5E8, number: uint16(rand.Uint32())}, nil
+	case headerTypeUTP:
+		return &utpHeader{header: 1, connectionID: uint16(rand.Uint32())}, nil
+	case headerTypeWechatVideo:
+		return &wechatVideoHeader{sn: uint32(uint16(rand.Uint32()))}, nil
+	case headerTypeDTLS:
+		return &dtlsHeader{epoch: uint16(rand.Uint32()), length: 17}, nil
+	case headerTypeWireGuard:
+		return wireGuardHeader{}, nil
+	default:
+		return nil, E.New("unknown header type: ", headerType)
+	}
+}
+
+type srtpHeader struct {
+	header uint16
+	number uint16
+}
+
+func (h *srtpHeader) size() int {
+	return 4
+}
+
+func (h *srtpHeader) serialize(b []byte) {
+	h.number++
+	binary.BigEndian.PutUint16(b, h.header)
+	binary.BigEndian.PutUint16(b[2:], h.number)
+}
+
+type utpHeader struct {
+	header       byte
+	extension    byte
+	connectionID uint16
+}
+
+func (h *utpHeader) size() int {
+	return 4
+}
+
+func (h *utpHeader) serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, h.connectionID)
+	b[2] = h.header
+	b[3] = h.extension
+}
+
+type wechatVideoHeader struct {
+	sn uint32
+}
+
+func (h *wechatVideoHeader) size() int {
+	return 13
+}
+
+func (h *wechatVideoHeader) serialize(b []byte) {
+	h.sn++
+	b[0] = 0xa1
+	b[1] = 0x08
+	binary.BigEndian.PutUint32(b[2:], h.sn)
+	b[6] = 0x00
+	b[7] = 0x10
+	b[8] = 0x11
+	b[9] = 0x18
+	b[10] = 0x30
+	b[11] = 0x22
+	b[12] = 0x30
+}
+
+type dtlsHeader struct {
+	epoch    uint16
+	sequence uint32
+	length   uint16
+}
+
+func (h *dtlsHeader) size() int {
+	return 13
+}
+
+func (h *dtlsHeader) serialize(b []byte) {
+	b[0] = 23
+	b[1] = 254
+	b[2] = 253
+	binary.BigEndian.PutUint16(b[3:], h.epoch)
+	b[5] = 0
+	b[6] = 0
+	binary.BigEndian.PutUint32(b[7:], h.sequence)
+	h.sequence++
+	binary.BigEndian.PutUint16(b[11:], h.length)
+	h.length += 17
+	if h.length > 100 {
+		h.length -= 50
+	}
+}
+
+type wireGuardHeader struct{}
+
+func (wireGuardHeader) size() int {
+	return 4
+}
+
+func (wireGuardHeader) serialize(b []byte) {
+	b[0] = 0x04
+	b[1] = 0x00
+	b[2] = 0x00
+	b[3] = 0x00
+}
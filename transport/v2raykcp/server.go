@@ -0,0 +1,147 @@
+package v2raykcp
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+type connectionID struct {
+	remote string
+	conv   uint16
+}
+
+type Server struct {
+	ctx         context.Context
+	tlsConfig   tls.ServerConfig
+	handler     adapter.V2RayServerTransportHandler
+	config      *kcpConfig
+	codec       *packetCodec
+	udpListener net.PacketConn
+	access      sync.Mutex
+	conns       map[connectionID]*Conn
+}
+
+func NewServer(ctx context.Context, options option.V2RayKCPOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (adapter.V2RayServerTransport, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newPacketCodec(options.HeaderType, options.Seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		ctx:       ctx,
+		tlsConfig: tlsConfig,
+		handler:   handler,
+		config:    config,
+		codec:     codec,
+		conns:     make(map[connectionID]*Conn),
+	}, nil
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkUDP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	s.udpListener = listener
+	go s.loopInput()
+	return nil
+}
+
+func (s *Server) loopInput() {
+	buffer := make([]byte, buf.UDPBufferSize)
+	for {
+		n, addr, err := s.udpListener.ReadFrom(buffer)
+		if err != nil {
+			if !E.IsClosed(err) {
+				s.handler.NewError(s.ctx, E.Cause(err, "read packet"))
+			}
+			return
+		}
+		segments := s.codec.decode(buffer[:n])
+		if len(segments) == 0 {
+			continue
+		}
+		id := connectionID{remote: addr.String(), conv: segments[0].conversation()}
+		s.access.Lock()
+		conn, loaded := s.conns[id]
+		if !loaded {
+			if segments[0].command() == commandTerminate {
+				s.access.Unlock()
+				continue
+			}
+			conn = s.newConn(id, addr)
+			s.conns[id] = conn
+		}
+		s.access.Unlock()
+		conn.input(segments)
+		if !loaded {
+			go s.handleConn(conn, addr)
+		}
+	}
+}
+
+func (s *Server) newConn(id connectionID, addr net.Addr) *Conn {
+	var conn *Conn
+	conn = newConn(id.conv, s.config, s.codec, s.udpListener.LocalAddr(), addr, func(b []byte) error {
+		_, err := s.udpListener.WriteTo(b, addr)
+		return err
+	}, func() {
+		s.access.Lock()
+		if s.conns[id] == conn {
+			delete(s.conns, id)
+		}
+		s.access.Unlock()
+	})
+	return conn
+}
+
+func (s *Server) handleConn(conn *Conn, addr net.Addr) {
+	var (
+		netConn net.Conn = conn
+		err     error
+	)
+	if s.tlsConfig != nil {
+		netConn, err = tls.ServerHandshake(s.ctx, conn, s.tlsConfig)
+		if err != nil {
+			conn.terminate()
+			s.handler.NewError(s.ctx, E.Cause(err, "process connection from ", addr))
+			return
+		}
+	}
+	var metadata M.Metadata
+	metadata.Source = M.SocksaddrFromNet(addr).Unwrap()
+	s.handler.NewConnection(s.ctx, netConn, metadata)
+}
+
+func (s *Server) Close() error {
+	s.access.Lock()
+	conns := make([]*Conn, 0, len(s.conns))
+	for _, conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.access.Unlock()
+	for _, conn := range conns {
+		conn.terminate()
+	}
+	return common.Close(s.udpListener)
+}
@@ -0,0 +1,249 @@
+package v2raysplithttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	sHTTP "github.com/sagernet/sing/protocol/http"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/net/http2"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+type Client struct {
+	ctx                context.Context
+	transport          http.RoundTripper
+	requestURL         url.URL
+	host               string
+	headers            http.Header
+	padding            paddingRange
+	maxEachPostBytes   int
+	maxConcurrentPosts int
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RaySplitHTTPOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	padding, err := parsePaddingRange(options.XPaddingBytes)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper
+	if tlsConfig == nil {
+		transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, serverAddr)
+			},
+		}
+	} else {
+		if len(tlsConfig.NextProtos()) == 0 {
+			tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+		}
+		dialTLSContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, serverAddr)
+			if err != nil {
+				return nil, err
+			}
+			return tls.ClientHandshake(ctx, conn, tlsConfig)
+		}
+		if common.Contains(tlsConfig.NextProtos(), http2.NextProtoTLS) {
+			transport = &http2.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.STDConfig) (net.Conn, error) {
+					return dialTLSContext(ctx, network, addr)
+				},
+			}
+		} else {
+			transport = &http.Transport{
+				DialTLSContext: dialTLSContext,
+			}
+		}
+	}
+	var host string
+	if options.Host != "" {
+		host = options.Host
+	} else if tlsConfig != nil && tlsConfig.ServerName() != "" {
+		host = tlsConfig.ServerName()
+	} else {
+		host = serverAddr.String()
+	}
+	var requestURL url.URL
+	if tlsConfig == nil {
+		requestURL.Scheme = "http"
+	} else {
+		requestURL.Scheme = "https"
+	}
+	requestURL.Host = serverAddr.String()
+	err = sHTTP.URLSetPath(&requestURL, normalizePath(options.Path))
+	if err != nil {
+		return nil, E.Cause(err, "parse path")
+	}
+	return &Client{
+		ctx:                ctx,
+		transport:          transport,
+		requestURL:         requestURL,
+		host:               host,
+		headers:            options.Headers.Build(),
+		padding:            padding,
+		maxEachPostBytes:   maxEachPostBytes(options),
+		maxConcurrentPosts: maxConcurrentPosts(options),
+	}, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	requestURL := c.requestURL
+	err := sHTTP.URLSetPath(&requestURL, requestURL.Path+path)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	request.Host = c.host
+	for key, values := range c.headers {
+		request.Header[key] = values
+	}
+	refererURL := requestURL
+	refererURL.Host = c.host
+	refererURL.RawQuery = "x_padding=" + c.padding.generate()
+	request.Header.Set("Referer", refererURL.String())
+	return request, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	sessionID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	connCtx, cancel := context.WithCancel(ctx)
+	writer := &uploadWriter{
+		ctx:       connCtx,
+		cancel:    cancel,
+		client:    c,
+		sessionID: sessionID.String(),
+		semaphore: make(chan struct{}, c.maxConcurrentPosts),
+	}
+	request, err := c.newRequest(connCtx, http.MethodGet, writer.sessionID, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	conn := v2rayhttp.NewLateHTTPConn(writer)
+	go func() {
+		response, err := c.transport.RoundTrip(request)
+		if err != nil {
+			conn.Setup(nil, err)
+		} else if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			conn.Setup(nil, E.New("unexpected status: ", response.Status))
+		} else {
+			conn.Setup(response.Body, nil)
+		}
+	}()
+	return conn, nil
+}
+
+func (c *Client) Close() error {
+	v2rayhttp.CloseIdleConnections(c.transport)
+	return nil
+}
+
+type uploadWriter struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    *Client
+	sessionID string
+	semaphore chan struct{}
+	access    sync.Mutex
+	seq       uint64
+	err       error
+	closed    bool
+}
+
+func (w *uploadWriter) Write(b []byte) (int, error) {
+	var n int
+	for n < len(b) {
+		if err := w.loadError(); err != nil {
+			return n, err
+		}
+		size := len(b) - n
+		if size > w.client.maxEachPostBytes {
+			size = w.client.maxEachPostBytes
+		}
+		payload := bytes.Clone(b[n : n+size])
+		select {
+		case w.semaphore <- struct{}{}:
+		case <-w.ctx.Done():
+			return n, net.ErrClosed
+		}
+		w.access.Lock()
+		seq := w.seq
+		w.seq++
+		w.access.Unlock()
+		go w.post(seq, payload)
+		n += size
+	}
+	return n, nil
+}
+
+func (w *uploadWriter) post(seq uint64, payload []byte) {
+	defer func() {
+		<-w.semaphore
+	}()
+	request, err := w.client.newRequest(w.ctx, http.MethodPost, w.sessionID+"/"+strconv.FormatUint(seq, 10), bytes.NewReader(payload))
+	if err != nil {
+		w.setError(err)
+		return
+	}
+	request.ContentLength = int64(len(payload))
+	response, err := w.client.transport.RoundTrip(request)
+	if err != nil {
+		w.setError(E.Cause(err, "upload packet"))
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		w.setError(E.New("upload packet: unexpected status: ", response.Status))
+	}
+}
+
+func (w *uploadWriter) loadError() error {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.err
+}
+
+func (w *uploadWriter) setError(err error) {
+	w.access.Lock()
+	if w.err == nil && !w.closed {
+		w.err = err
+	}
+	w.access.Unlock()
+	w.Close()
+}
+
+func (w *uploadWriter) Close() error {
+	w.access.Lock()
+	if w.closed {
+		w.access.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.access.Unlock()
+	w.cancel()
+	return nil
+}
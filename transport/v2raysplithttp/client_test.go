@@ -0,0 +1,44 @@
+package v2raysplithttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDialContextCanceled(t *testing.T) {
+	t.Parallel()
+	requestDone := make(chan struct{})
+	testDone := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(requestDone)
+		case <-testDone:
+		}
+	}))
+	defer server.Close()
+	defer close(testDone)
+	client, err := NewClient(context.Background(), N.SystemDialer, M.ParseSocksaddr(server.Listener.Addr().String()), option.V2RaySplitHTTPOptions{}, nil)
+	require.NoError(t, err)
+	defer common.Close(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = client.DialContext(ctx)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-requestDone:
+	case <-time.After(time.Second):
+		t.Fatal("request not aborted")
+	}
+}
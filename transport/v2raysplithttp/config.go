@@ -0,0 +1,80 @@
+package v2raysplithttp
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	defaultMaxEachPostBytes   = 1000000
+	defaultMaxConcurrentPosts = 100
+	defaultPaddingFrom        = 100
+	defaultPaddingTo          = 1000
+)
+
+type paddingRange struct {
+	from int
+	to   int
+}
+
+func parsePaddingRange(value string) (paddingRange, error) {
+	if value == "" {
+		return paddingRange{defaultPaddingFrom, defaultPaddingTo}, nil
+	}
+	fromString, toString, isRange := strings.Cut(value, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(fromString))
+	if err != nil {
+		return paddingRange{}, E.Cause(err, "parse x_padding_bytes")
+	}
+	to := from
+	if isRange {
+		to, err = strconv.Atoi(strings.TrimSpace(toString))
+		if err != nil {
+			return paddingRange{}, E.Cause(err, "parse x_padding_bytes")
+		}
+	}
+	if from < 0 || to < from {
+		return paddingRange{}, E.New("invalid x_padding_bytes: ", value)
+	}
+	return paddingRange{from, to}, nil
+}
+
+func (r paddingRange) contains(length int) bool {
+	return length >= r.from && length <= r.to
+}
+
+func (r paddingRange) generate() string {
+	length := r.from
+	if r.to > r.from {
+		length += rand.Intn(r.to - r.from + 1)
+	}
+	return strings.Repeat("X", length)
+}
+
+func normalizePath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return path
+}
+
+func maxEachPostBytes(options option.V2RaySplitHTTPOptions) int {
+	if options.MaxEachPostBytes == 0 {
+		return defaultMaxEachPostBytes
+	}
+	return int(options.MaxEachPostBytes)
+}
+
+func maxConcurrentPosts(options option.V2RaySplitHTTPOptions) int {
+	if options.MaxConcurrentPosts == 0 {
+		return defaultMaxConcurrentPosts
+	}
+	return int(options.MaxConcurrentPosts)
+}
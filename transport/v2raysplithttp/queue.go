@@ -0,0 +1,106 @@
+package v2raysplithttp
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// uploadQueue reorders uploaded packets by their sequence number.
+type uploadQueue struct {
+	access     sync.Mutex
+	notify     chan struct{}
+	drained    chan struct{}
+	packets    map[uint64][]byte
+	nextSeq    uint64
+	current    []byte
+	maxPackets int
+	closed     bool
+}
+
+func newUploadQueue(maxPackets int) *uploadQueue {
+	return &uploadQueue{
+		notify:     make(chan struct{}, 1),
+		drained:    make(chan struct{}),
+		packets:    make(map[uint64][]byte),
+		maxPackets: maxPackets,
+	}
+}
+
+// Push buffers packets up to maxPackets ahead of the reader.
+// Packets further ahead wait for the reader to drain, so a fast uploader is slowed down instead of failing.
+func (q *uploadQueue) Push(ctx context.Context, seq uint64, payload []byte) error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	for {
+		if q.closed {
+			return io.ErrClosedPipe
+		}
+		if _, loaded := q.packets[seq]; loaded || seq < q.nextSeq {
+			return E.New("duplicate packet: ", seq)
+		}
+		if seq-q.nextSeq < uint64(q.maxPackets) {
+			break
+		}
+		drained := q.drained
+		q.access.Unlock()
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			q.access.Lock()
+			return ctx.Err()
+		}
+		q.access.Lock()
+	}
+	q.packets[seq] = payload
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *uploadQueue) Read(b []byte) (int, error) {
+	for {
+		q.access.Lock()
+		for len(q.current) == 0 {
+			payload, loaded := q.packets[q.nextSeq]
+			if !loaded {
+				break
+			}
+			delete(q.packets, q.nextSeq)
+			q.nextSeq++
+			q.current = payload
+			if !q.closed {
+				close(q.drained)
+				q.drained = make(chan struct{})
+			}
+		}
+		if len(q.current) > 0 {
+			n := copy(b, q.current)
+			q.current = q.current[n:]
+			q.access.Unlock()
+			return n, nil
+		}
+		closed := q.closed
+		q.access.Unlock()
+		if closed {
+			return 0, io.EOF
+		}
+		<-q.notify
+	}
+}
+
+func (q *uploadQueue) Close() error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.notify)
+	close(q.drained)
+	return nil
+}
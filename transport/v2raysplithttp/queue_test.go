@@ -0,0 +1,108 @@
+package v2raysplithttp
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadQueueReorder(t *testing.T) {
+	t.Parallel()
+	queue := newUploadQueue(4)
+	require.NoError(t, queue.Push(context.Background(), 2, []byte("c")))
+	require.NoError(t, queue.Push(context.Background(), 0, []byte("a")))
+	require.NoError(t, queue.Push(context.Background(), 1, []byte("b")))
+	require.Error(t, queue.Push(context.Background(), 1, []byte("b")))
+	require.Error(t, queue.Push(context.Background(), 2, []byte("c")))
+	content := make([]byte, 3)
+	_, err := io.ReadFull(queue, content)
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(content))
+	require.Error(t, queue.Push(context.Background(), 0, []byte("a")))
+}
+
+func TestUploadQueueFastUploader(t *testing.T) {
+	t.Parallel()
+	queue := newUploadQueue(2)
+	const packets = 100
+	pushErr := make(chan error, 1)
+	go func() {
+		for seq := uint64(0); seq < packets; seq++ {
+			err := queue.Push(context.Background(), seq, []byte{byte(seq)})
+			if err != nil {
+				pushErr <- err
+				return
+			}
+		}
+		pushErr <- nil
+	}()
+	content := make([]byte, packets)
+	_, err := io.ReadFull(queue, content)
+	require.NoError(t, err)
+	for i := range content {
+		require.Equal(t, byte(i), content[i])
+	}
+	require.NoError(t, <-pushErr)
+}
+
+func TestUploadQueuePushWaitsForReader(t *testing.T) {
+	t.Parallel()
+	queue := newUploadQueue(2)
+	require.NoError(t, queue.Push(context.Background(), 0, []byte("a")))
+	require.NoError(t, queue.Push(context.Background(), 1, []byte("b")))
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- queue.Push(context.Background(), 2, []byte("c"))
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push not blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	content := make([]byte, 1)
+	_, err := queue.Read(content)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(content))
+	select {
+	case err = <-pushed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("push not resumed")
+	}
+}
+
+func TestUploadQueuePushCanceled(t *testing.T) {
+	t.Parallel()
+	queue := newUploadQueue(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, queue.Push(ctx, 1, []byte("b")), context.DeadlineExceeded)
+	require.NoError(t, queue.Push(context.Background(), 0, []byte("a")))
+}
+
+func TestUploadQueueClose(t *testing.T) {
+	t.Parallel()
+	queue := newUploadQueue(1)
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- queue.Push(context.Background(), 1, []byte("b"))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, queue.Push(context.Background(), 0, []byte("a")))
+	require.NoError(t, queue.Close())
+	select {
+	case err := <-pushed:
+		require.ErrorIs(t, err, io.ErrClosedPipe)
+	case <-time.After(time.Second):
+		t.Fatal("push not woken by close")
+	}
+	content := make([]byte, 1)
+	_, err := queue.Read(content)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(content))
+	_, err = queue.Read(content)
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,247 @@
+package v2raysplithttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	aTLS "github.com/sagernet/sing/common/tls"
+	sHttp "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+type Server struct {
+	ctx                context.Context
+	tlsConfig          tls.ServerConfig
+	handler            adapter.V2RayServerTransportHandler
+	httpServer         *http.Server
+	h2Server           *http2.Server
+	h2cHandler         http.Handler
+	host               string
+	path               string
+	headers            http.Header
+	padding            paddingRange
+	noSSEHeader        bool
+	maxEachPostBytes   int
+	maxConcurrentPosts int
+	sessionAccess      sync.Mutex
+	sessions           map[string]*session
+}
+
+type session struct {
+	queue     *uploadQueue
+	connected chan struct{}
+}
+
+func NewServer(ctx context.Context, options option.V2RaySplitHTTPOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	padding, err := parsePaddingRange(options.XPaddingBytes)
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		ctx:                ctx,
+		tlsConfig:          tlsConfig,
+		handler:            handler,
+		h2Server:           &http2.Server{},
+		host:               options.Host,
+		path:               normalizePath(options.Path),
+		headers:            options.Headers.Build(),
+		padding:            padding,
+		noSSEHeader:        options.NoSSEHeader,
+		maxEachPostBytes:   maxEachPostBytes(options),
+		maxConcurrentPosts: maxConcurrentPosts(options),
+		sessions:           make(map[string]*session),
+	}
+	server.httpServer = &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: C.TCPTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	server.h2cHandler = h2c.NewHandler(server, server.h2Server)
+	return server, nil
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "PRI" && len(request.Header) == 0 && request.URL.Path == "*" && request.Proto == "HTTP/2.0" {
+		s.h2cHandler.ServeHTTP(writer, request)
+		return
+	}
+	host := request.Host
+	if len(s.host) > 0 && host != s.host {
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad host: ", host))
+		return
+	}
+	if !strings.HasPrefix(request.URL.Path, s.path) {
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad path: ", request.URL.Path))
+		return
+	}
+	var paddingLength int
+	if referrer := request.Header.Get("Referer"); referrer != "" {
+		if referrerURL, err := url.Parse(referrer); err == nil {
+			paddingLength = len(referrerURL.Query().Get("x_padding"))
+		}
+	} else {
+		paddingLength = len(request.URL.Query().Get("x_padding"))
+	}
+	if !s.padding.contains(paddingLength) {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.New("invalid padding length: ", paddingLength))
+		return
+	}
+	sessionID, seq, _ := strings.Cut(request.URL.Path[len(s.path):], "/")
+	if sessionID == "" {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.New("missing session id"))
+		return
+	}
+	for key, values := range s.headers {
+		for _, value := range values {
+			writer.Header().Set(key, value)
+		}
+	}
+	writer.Header().Set("X-Padding", s.padding.generate())
+	switch request.Method {
+	case http.MethodPost:
+		s.handleUpload(writer, request, sessionID, seq)
+	case http.MethodGet:
+		s.handleDownload(writer, request, sessionID)
+	default:
+		s.invalidRequest(writer, request, http.StatusMethodNotAllowed, E.New("bad method: ", request.Method))
+	}
+}
+
+func (s *Server) upsertSession(sessionID string) *session {
+	s.sessionAccess.Lock()
+	defer s.sessionAccess.Unlock()
+	currentSession, loaded := s.sessions[sessionID]
+	if loaded {
+		return currentSession
+	}
+	currentSession = &session{
+		queue:     newUploadQueue(s.maxConcurrentPosts),
+		connected: make(chan struct{}),
+	}
+	s.sessions[sessionID] = currentSession
+	go func() {
+		timer := time.NewTimer(C.TCPTimeout)
+		defer timer.Stop()
+		select {
+		case <-currentSession.connected:
+		case <-timer.C:
+			s.removeSession(sessionID, currentSession)
+		case <-s.ctx.Done():
+		}
+	}()
+	return currentSession
+}
+
+func (s *Server) removeSession(sessionID string, currentSession *session) {
+	s.sessionAccess.Lock()
+	if s.sessions[sessionID] == currentSession {
+		delete(s.sessions, sessionID)
+	}
+	s.sessionAccess.Unlock()
+	currentSession.queue.Close()
+}
+
+func (s *Server) handleUpload(writer http.ResponseWriter, request *http.Request, sessionID string, seq string) {
+	seqNumber, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.Cause(err, "bad seq: ", seq))
+		return
+	}
+	payload, err := io.ReadAll(io.LimitReader(request.Body, int64(s.maxEachPostBytes)+1))
+	if err != nil {
+		s.invalidRequest(writer, request, 0, E.Cause(err, "read upload"))
+		return
+	}
+	if len(payload) > s.maxEachPostBytes {
+		s.invalidRequest(writer, request, http.StatusRequestEntityTooLarge, E.New("too large upload"))
+		return
+	}
+	err = s.upsertSession(sessionID).queue.Push(request.Context(), seqNumber, payload)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusInternalServerError, E.Cause(err, "push upload"))
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleDownload(writer http.ResponseWriter, request *http.Request, sessionID string) {
+	currentSession := s.upsertSession(sessionID)
+	select {
+	case <-currentSession.connected:
+		s.invalidRequest(writer, request, http.StatusConflict, E.New("duplicate download for session ", sessionID))
+		return
+	default:
+		close(currentSession.connected)
+	}
+	defer s.removeSession(sessionID, currentSession)
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	if !s.noSSEHeader {
+		writer.Header().Set("Content-Type", "text/event-stream")
+	}
+	writer.WriteHeader(http.StatusOK)
+	writer.(http.Flusher).Flush()
+	var metadata M.Metadata
+	metadata.Source = sHttp.SourceAddress(request)
+	conn := v2rayhttp.NewHTTP2Wrapper(&v2rayhttp.ServerHTTPConn{
+		HTTP2Conn: v2rayhttp.NewHTTPConn(currentSession.queue, writer),
+		Flusher:   writer.(http.Flusher),
+	})
+	s.handler.NewConnection(request.Context(), conn, metadata)
+	conn.CloseWrapper()
+}
+
+func (s *Server) invalidRequest(writer http.ResponseWriter, request *http.Request, statusCode int, err error) {
+	if statusCode > 0 {
+		writer.WriteHeader(statusCode)
+	}
+	s.handler.NewError(request.Context(), E.Cause(err, "process connection from ", request.RemoteAddr))
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if s.tlsConfig != nil {
+		if len(s.tlsConfig.NextProtos()) == 0 {
+			s.tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+		} else if !common.Contains(s.tlsConfig.NextProtos(), http2.NextProtoTLS) {
+			s.tlsConfig.SetNextProtos(append([]string{"h2"}, s.tlsConfig.NextProtos()...))
+		}
+		listener = aTLS.NewListener(listener, s.tlsConfig)
+	}
+	return s.httpServer.Serve(listener)
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) Close() error {
+	return common.Close(common.PtrOrNil(s.httpServer))
+}